@@ -11,21 +11,23 @@ import (
 	"strings"
 
 	"google.golang.org/api/drive/v3"
-	gapi "google.golang.org/api/googleapi"
 
-	"github.com/steipete/gogcli/internal/config"
 	"github.com/steipete/gogcli/internal/googleapi"
 	"github.com/steipete/gogcli/internal/outfmt"
 	"github.com/steipete/gogcli/internal/ui"
 )
 
-var newDriveService = googleapi.NewDrive
+var (
+	newDriveService    = googleapi.NewDrive
+	newDriveHTTPClient = googleapi.NewDriveClient
+)
 
 const (
 	driveMimeGoogleDoc     = "application/vnd.google-apps.document"
 	driveMimeGoogleSheet   = "application/vnd.google-apps.spreadsheet"
 	driveMimeGoogleSlides  = "application/vnd.google-apps.presentation"
 	driveMimeGoogleDrawing = "application/vnd.google-apps.drawing"
+	driveMimeFolder        = "application/vnd.google-apps.folder"
 	mimePDF                = "application/pdf"
 	mimeCSV                = "text/csv"
 	mimeDocx               = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
@@ -43,29 +45,36 @@ const (
 )
 
 type DriveCmd struct {
-	Ls          DriveLsCmd          `cmd:"" name:"ls" help:"List files in a folder (default: root)"`
-	Search      DriveSearchCmd      `cmd:"" name:"search" help:"Full-text search across Drive"`
-	Get         DriveGetCmd         `cmd:"" name:"get" help:"Get file metadata"`
-	Download    DriveDownloadCmd    `cmd:"" name:"download" help:"Download a file (exports Google Docs formats)"`
-	Copy        DriveCopyCmd        `cmd:"" name:"copy" help:"Copy a file"`
-	Upload      DriveUploadCmd      `cmd:"" name:"upload" help:"Upload a file"`
-	Mkdir       DriveMkdirCmd       `cmd:"" name:"mkdir" help:"Create a folder"`
-	Delete      DriveDeleteCmd      `cmd:"" name:"delete" help:"Delete a file (moves to trash)" aliases:"rm,del"`
-	Move        DriveMoveCmd        `cmd:"" name:"move" help:"Move a file to a different folder"`
-	Rename      DriveRenameCmd      `cmd:"" name:"rename" help:"Rename a file or folder"`
-	Share       DriveShareCmd       `cmd:"" name:"share" help:"Share a file or folder"`
-	Unshare     DriveUnshareCmd     `cmd:"" name:"unshare" help:"Remove a permission from a file"`
-	Permissions DrivePermissionsCmd `cmd:"" name:"permissions" help:"List permissions on a file"`
-	URL         DriveURLCmd         `cmd:"" name:"url" help:"Print web URLs for files"`
-	Comments    DriveCommentsCmd    `cmd:"" name:"comments" help:"Manage comments on files"`
-	Drives      DriveDrivesCmd      `cmd:"" name:"drives" help:"List shared drives (Team Drives)"`
+	Ls          DriveLsCmd               `cmd:"" name:"ls" help:"List files in a folder (default: root)"`
+	Tree        DriveTreeCmd             `cmd:"" name:"tree" help:"Show a folder hierarchy as a tree"`
+	Du          DriveDuCmd               `cmd:"" name:"du" help:"Summarize folder sizes and storage quota"`
+	Search      DriveSearchCmd           `cmd:"" name:"search" help:"Full-text search across Drive"`
+	Get         DriveGetCmd              `cmd:"" name:"get" help:"Get file metadata"`
+	Download    DriveDownloadCmd         `cmd:"" name:"download" help:"Download a file (exports Google Docs formats)"`
+	Copy        DriveCopyCmd             `cmd:"" name:"copy" help:"Copy a file"`
+	Upload      DriveUploadCmd           `cmd:"" name:"upload" help:"Upload files or directories"`
+	Sync        DriveSyncCmd             `cmd:"" name:"sync" help:"Sync a local directory with a Drive folder (push/pull)"`
+	Mkdir       DriveMkdirCmd            `cmd:"" name:"mkdir" help:"Create a folder"`
+	Delete      DriveDeleteCmd           `cmd:"" name:"delete" help:"Move a file to trash (or delete permanently with --permanent)" aliases:"rm,del"`
+	Move        DriveMoveCmd             `cmd:"" name:"move" help:"Move a file to a different folder"`
+	Rename      DriveRenameCmd           `cmd:"" name:"rename" help:"Rename a file or folder"`
+	Share       DriveShareCmd            `cmd:"" name:"share" help:"Share a file or folder (--recursive for a whole tree)"`
+	Unshare     DriveUnshareCmd          `cmd:"" name:"unshare" help:"Remove a permission from a file (--recursive --email for a whole tree)"`
+	Permissions DrivePermissionsGroupCmd `cmd:"" name:"permissions" help:"List or audit permissions"`
+	URL         DriveURLCmd              `cmd:"" name:"url" help:"Print web URLs for files"`
+	Trash       DriveTrashCmd            `cmd:"" name:"trash" help:"List, restore and empty trash"`
+	Changes     DriveChangesCmd          `cmd:"" name:"changes" help:"Follow the Drive changes feed"`
+	Revisions   DriveRevisionsCmd        `cmd:"" name:"revisions" help:"List, download and manage file revisions"`
+	Comments    DriveCommentsCmd         `cmd:"" name:"comments" help:"Manage comments on files"`
+	Drives      DriveDrivesCmd           `cmd:"" name:"drives" help:"List and manage shared drives (Team Drives)"`
 }
 
 type DriveLsCmd struct {
 	Max    int64  `name:"max" aliases:"limit" help:"Max results" default:"20"`
 	Page   string `name:"page" help:"Page token"`
 	Query  string `name:"query" help:"Drive query filter"`
-	Parent string `name:"parent" help:"Folder ID to list (default: root)"`
+	Parent string `name:"parent" help:"Folder ID or path to list (default: root)"`
+	Drive  string `name:"drive" help:"Shared drive ID (or drive:<name>) to list; defaults --parent to the drive root"`
 }
 
 func (c *DriveLsCmd) Run(ctx context.Context, flags *RootFlags) error {
@@ -75,19 +84,30 @@ func (c *DriveLsCmd) Run(ctx context.Context, flags *RootFlags) error {
 		return err
 	}
 
+	svc, err := newDriveService(ctx, account)
+	if err != nil {
+		return err
+	}
+	driveID, err := resolveSharedDriveRef(ctx, svc, c.Drive)
+	if err != nil {
+		return err
+	}
+
 	folderID := strings.TrimSpace(c.Parent)
-	if folderID == "" {
+	switch {
+	case folderID == "" && driveID != "":
+		folderID = driveID
+	case folderID == "":
 		folderID = "root"
 	}
-
-	svc, err := newDriveService(ctx, account)
+	folderID, err = resolveDriveFileID(ctx, svc, folderID)
 	if err != nil {
 		return err
 	}
 
 	q := buildDriveListQuery(folderID, c.Query)
 
-	resp, err := svc.Files.List().
+	call := svc.Files.List().
 		Q(q).
 		PageSize(c.Max).
 		PageToken(c.Page).
@@ -95,8 +115,11 @@ func (c *DriveLsCmd) Run(ctx context.Context, flags *RootFlags) error {
 		SupportsAllDrives(true).
 		IncludeItemsFromAllDrives(true).
 		Fields("nextPageToken, files(id, name, mimeType, size, modifiedTime, parents, webViewLink)").
-		Context(ctx).
-		Do()
+		Context(ctx)
+	if driveID != "" {
+		call = call.Corpora("drive").DriveId(driveID)
+	}
+	resp, err := call.Do()
 	if err != nil {
 		return err
 	}
@@ -135,6 +158,7 @@ type DriveSearchCmd struct {
 	Query []string `arg:"" name:"query" help:"Search query"`
 	Max   int64    `name:"max" aliases:"limit" help:"Max results" default:"20"`
 	Page  string   `name:"page" help:"Page token"`
+	Drive string   `name:"drive" help:"Only search this shared drive (ID or drive:<name>)"`
 }
 
 func (c *DriveSearchCmd) Run(ctx context.Context, flags *RootFlags) error {
@@ -153,7 +177,12 @@ func (c *DriveSearchCmd) Run(ctx context.Context, flags *RootFlags) error {
 		return err
 	}
 
-	resp, err := svc.Files.List().
+	driveID, err := resolveSharedDriveRef(ctx, svc, c.Drive)
+	if err != nil {
+		return err
+	}
+
+	call := svc.Files.List().
 		Q(buildDriveSearchQuery(query)).
 		PageSize(c.Max).
 		PageToken(c.Page).
@@ -161,8 +190,11 @@ func (c *DriveSearchCmd) Run(ctx context.Context, flags *RootFlags) error {
 		SupportsAllDrives(true).
 		IncludeItemsFromAllDrives(true).
 		Fields("nextPageToken, files(id, name, mimeType, size, modifiedTime, parents, webViewLink)").
-		Context(ctx).
-		Do()
+		Context(ctx)
+	if driveID != "" {
+		call = call.Corpora("drive").DriveId(driveID)
+	}
+	resp, err := call.Do()
 	if err != nil {
 		return err
 	}
@@ -198,7 +230,7 @@ func (c *DriveSearchCmd) Run(ctx context.Context, flags *RootFlags) error {
 }
 
 type DriveGetCmd struct {
-	FileID string `arg:"" name:"fileId" help:"File ID"`
+	FileID string `arg:"" name:"fileId" help:"File ID or path (/My Drive/..., drive:<shared drive>/...)"`
 }
 
 func (c *DriveGetCmd) Run(ctx context.Context, flags *RootFlags) error {
@@ -216,6 +248,10 @@ func (c *DriveGetCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if err != nil {
 		return err
 	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return err
+	}
 
 	f, err := svc.Files.Get(fileID).
 		SupportsAllDrives(true).
@@ -247,7 +283,7 @@ func (c *DriveGetCmd) Run(ctx context.Context, flags *RootFlags) error {
 }
 
 type DriveDownloadCmd struct {
-	FileID string         `arg:"" name:"fileId" help:"File ID"`
+	FileID string         `arg:"" name:"fileId" help:"File ID or path (/My Drive/..., drive:<shared drive>/...)"`
 	Output OutputPathFlag `embed:""`
 	Format string         `name:"format" help:"Export format for Google Docs files: pdf|csv|xlsx|pptx|txt|png|docx (default: auto)"`
 }
@@ -268,6 +304,10 @@ func (c *DriveDownloadCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if err != nil {
 		return err
 	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return err
+	}
 
 	meta, err := svc.Files.Get(fileID).
 		SupportsAllDrives(true).
@@ -304,9 +344,9 @@ func (c *DriveDownloadCmd) Run(ctx context.Context, flags *RootFlags) error {
 }
 
 type DriveCopyCmd struct {
-	FileID string `arg:"" name:"fileId" help:"File ID"`
+	FileID string `arg:"" name:"fileId" help:"File ID or path (/My Drive/..., drive:<shared drive>/...)"`
 	Name   string `arg:"" name:"name" help:"New file name"`
-	Parent string `name:"parent" help:"Destination folder ID"`
+	Parent string `name:"parent" help:"Destination folder ID or path"`
 }
 
 func (c *DriveCopyCmd) Run(ctx context.Context, flags *RootFlags) error {
@@ -316,9 +356,14 @@ func (c *DriveCopyCmd) Run(ctx context.Context, flags *RootFlags) error {
 }
 
 type DriveUploadCmd struct {
-	LocalPath string `arg:"" name:"localPath" help:"Path to local file"`
-	Name      string `name:"name" help:"Override filename"`
-	Parent    string `name:"parent" help:"Destination folder ID"`
+	LocalPaths  []string `arg:"" name:"localPath" help:"Local files, directories (with --recursive), or glob patterns"`
+	Name        string   `name:"name" help:"Override filename (single file only)"`
+	Parent      string   `name:"parent" help:"Destination folder ID or path"`
+	Recursive   bool     `name:"recursive" short:"r" help:"Upload directories, recreating the folder tree (existing folders are reused)"`
+	Convert     bool     `name:"convert" help:"Convert Office/CSV files (.docx/.xlsx/.pptx/.csv) to Google Docs/Sheets/Slides"`
+	Concurrency int      `name:"concurrency" help:"Parallel uploads for directories and multiple files" default:"4"`
+	ChunkSize   int      `name:"chunk-size" help:"Chunk size in MiB for resumable uploads (files larger than this are uploaded in chunks)" default:"8"`
+	Resume      bool     `name:"resume" help:"Continue an interrupted upload of the same file"`
 }
 
 func (c *DriveUploadCmd) Run(ctx context.Context, flags *RootFlags) error {
@@ -328,50 +373,66 @@ func (c *DriveUploadCmd) Run(ctx context.Context, flags *RootFlags) error {
 		return err
 	}
 
-	localPath := strings.TrimSpace(c.LocalPath)
-	if localPath == "" {
-		return usage("empty localPath")
-	}
-	localPath, err = config.ExpandPath(localPath)
+	paths, err := expandDriveUploadPaths(c.LocalPaths)
 	if err != nil {
 		return err
 	}
-
-	f, err := os.Open(localPath) //nolint:gosec // user-provided path
+	chunkSize, err := driveUploadChunkSize(c.ChunkSize)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	fileName := strings.TrimSpace(c.Name)
-	if fileName == "" {
-		fileName = filepath.Base(localPath)
+	single := len(paths) == 1
+	for _, p := range paths {
+		st, statErr := os.Stat(p)
+		if statErr != nil {
+			return statErr
+		}
+		if st.IsDir() {
+			if !c.Recursive {
+				return usagef("%s is a directory (use --recursive)", p)
+			}
+			single = false
+		}
+	}
+	if !single && strings.TrimSpace(c.Name) != "" {
+		return usage("--name only applies to a single file")
 	}
 
 	svc, err := newDriveService(ctx, account)
 	if err != nil {
 		return err
 	}
+	parent, err := resolveDriveFileID(ctx, svc, c.Parent)
+	if err != nil {
+		return err
+	}
 
-	meta := &drive.File{Name: fileName}
-	parent := strings.TrimSpace(c.Parent)
-	if parent != "" {
-		meta.Parents = []string{parent}
+	opts := driveUploadOptions{
+		Account:   account,
+		Name:      c.Name,
+		Parent:    parent,
+		ChunkSize: chunkSize,
+		Resume:    c.Resume,
+		Convert:   c.Convert,
 	}
 
-	mimeType := guessMimeType(localPath)
-	created, err := svc.Files.Create(meta).
-		SupportsAllDrives(true).
-		Media(f, gapi.ContentType(mimeType)).
-		Fields("id, name, mimeType, size, webViewLink").
-		Context(ctx).
-		Do()
+	if !single {
+		return runDriveUploadTree(ctx, svc, paths, c.Concurrency, opts)
+	}
+
+	res, err := uploadDriveLocalFile(ctx, svc, paths[0], opts)
 	if err != nil {
 		return err
 	}
+	created := res.File
 
 	if outfmt.IsJSON(ctx) {
-		return outfmt.WriteJSON(os.Stdout, map[string]any{strFile: created})
+		return outfmt.WriteJSON(os.Stdout, map[string]any{
+			strFile:     created,
+			"bytesSent": res.BytesSent,
+			"resumed":   res.Resumed,
+		})
 	}
 
 	u.Out().Printf("id\t%s", created.Id)
@@ -379,12 +440,51 @@ func (c *DriveUploadCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if created.WebViewLink != "" {
 		u.Out().Printf("link\t%s", created.WebViewLink)
 	}
+	if res.Resumed {
+		u.Out().Printf("resumed\ttrue")
+	}
+	return nil
+}
+
+func runDriveUploadTree(ctx context.Context, svc *drive.Service, paths []string, concurrency int, opts driveUploadOptions) error {
+	res, err := uploadDriveTree(ctx, svc, paths, opts.Parent, concurrency, opts)
+	if err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		if writeErr := outfmt.WriteJSON(os.Stdout, res); writeErr != nil {
+			return writeErr
+		}
+	} else {
+		w, flush := tableWriter(ctx)
+		fmt.Fprintln(w, "PATH\tID\tSTATUS")
+		for _, f := range res.Folders {
+			status := "exists"
+			if f.Created {
+				status = "created"
+			}
+			fmt.Fprintf(w, "%s/\t%s\t%s\n", f.Path, f.ID, status)
+		}
+		for _, f := range res.Files {
+			id, status := f.ID, "uploaded"
+			if f.Error != "" {
+				id, status = "-", "error: "+f.Error
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", f.Path, id, status)
+		}
+		flush()
+	}
+
+	if res.Failed > 0 {
+		return fmt.Errorf("%d of %d uploads failed", res.Failed, len(res.Files))
+	}
 	return nil
 }
 
 type DriveMkdirCmd struct {
 	Name   string `arg:"" name:"name" help:"Folder name"`
-	Parent string `name:"parent" help:"Parent folder ID"`
+	Parent string `name:"parent" help:"Parent folder ID or path"`
 }
 
 func (c *DriveMkdirCmd) Run(ctx context.Context, flags *RootFlags) error {
@@ -404,19 +504,12 @@ func (c *DriveMkdirCmd) Run(ctx context.Context, flags *RootFlags) error {
 		return err
 	}
 
-	f := &drive.File{
-		Name:     name,
-		MimeType: "application/vnd.google-apps.folder",
-	}
-	if strings.TrimSpace(c.Parent) != "" {
-		f.Parents = []string{strings.TrimSpace(c.Parent)}
+	parent, err := resolveDriveFileID(ctx, svc, c.Parent)
+	if err != nil {
+		return err
 	}
 
-	created, err := svc.Files.Create(f).
-		SupportsAllDrives(true).
-		Fields("id, name, webViewLink").
-		Context(ctx).
-		Do()
+	created, err := createDriveFolder(ctx, svc, name, parent)
 	if err != nil {
 		return err
 	}
@@ -434,7 +527,8 @@ func (c *DriveMkdirCmd) Run(ctx context.Context, flags *RootFlags) error {
 }
 
 type DriveDeleteCmd struct {
-	FileID string `arg:"" name:"fileId" help:"File ID"`
+	FileID    string `arg:"" name:"fileId" help:"File ID or path (/My Drive/..., drive:<shared drive>/...)"`
+	Permanent bool   `name:"permanent" help:"Delete permanently instead of moving to trash"`
 }
 
 func (c *DriveDeleteCmd) Run(ctx context.Context, flags *RootFlags) error {
@@ -448,7 +542,11 @@ func (c *DriveDeleteCmd) Run(ctx context.Context, flags *RootFlags) error {
 		return usage("empty fileId")
 	}
 
-	if confirmErr := confirmDestructive(ctx, flags, fmt.Sprintf("delete drive file %s", fileID)); confirmErr != nil {
+	action := fmt.Sprintf("move drive file %s to trash", fileID)
+	if c.Permanent {
+		action = fmt.Sprintf("permanently delete drive file %s", fileID)
+	}
+	if confirmErr := confirmDestructive(ctx, flags, action); confirmErr != nil {
 		return confirmErr
 	}
 
@@ -456,24 +554,35 @@ func (c *DriveDeleteCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if err != nil {
 		return err
 	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return err
+	}
 
-	if err := svc.Files.Delete(fileID).SupportsAllDrives(true).Context(ctx).Do(); err != nil {
+	if c.Permanent {
+		err = svc.Files.Delete(fileID).SupportsAllDrives(true).Context(ctx).Do()
+	} else {
+		_, err = svc.Files.Update(fileID, &drive.File{Trashed: true}).SupportsAllDrives(true).Fields("id").Context(ctx).Do()
+	}
+	if err != nil {
 		return err
 	}
 	if outfmt.IsJSON(ctx) {
 		return outfmt.WriteJSON(os.Stdout, map[string]any{
-			"deleted": true,
-			"id":      fileID,
+			"deleted":   true,
+			"permanent": c.Permanent,
+			"id":        fileID,
 		})
 	}
 	u.Out().Printf("deleted\ttrue")
+	u.Out().Printf("permanent\t%t", c.Permanent)
 	u.Out().Printf("id\t%s", fileID)
 	return nil
 }
 
 type DriveMoveCmd struct {
-	FileID string `arg:"" name:"fileId" help:"File ID"`
-	Parent string `name:"parent" help:"New parent folder ID (required)"`
+	FileID string `arg:"" name:"fileId" help:"File ID or path (/My Drive/..., drive:<shared drive>/...)"`
+	Parent string `name:"parent" help:"New parent folder ID or path (required)"`
 }
 
 func (c *DriveMoveCmd) Run(ctx context.Context, flags *RootFlags) error {
@@ -495,6 +604,14 @@ func (c *DriveMoveCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if err != nil {
 		return err
 	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return err
+	}
+	parent, err = resolveDriveFileID(ctx, svc, parent)
+	if err != nil {
+		return err
+	}
 
 	meta, err := svc.Files.Get(fileID).
 		SupportsAllDrives(true).
@@ -528,7 +645,7 @@ func (c *DriveMoveCmd) Run(ctx context.Context, flags *RootFlags) error {
 }
 
 type DriveRenameCmd struct {
-	FileID  string `arg:"" name:"fileId" help:"File ID"`
+	FileID  string `arg:"" name:"fileId" help:"File ID or path (/My Drive/..., drive:<shared drive>/...)"`
 	NewName string `arg:"" name:"newName" help:"New name"`
 }
 
@@ -551,6 +668,10 @@ func (c *DriveRenameCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if err != nil {
 		return err
 	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return err
+	}
 
 	updated, err := svc.Files.Update(fileID, &drive.File{Name: newName}).
 		SupportsAllDrives(true).
@@ -571,11 +692,13 @@ func (c *DriveRenameCmd) Run(ctx context.Context, flags *RootFlags) error {
 }
 
 type DriveShareCmd struct {
-	FileID       string `arg:"" name:"fileId" help:"File ID"`
+	FileID       string `arg:"" name:"fileId" help:"File ID or path (/My Drive/..., drive:<shared drive>/...)"`
 	Anyone       bool   `name:"anyone" help:"Make publicly accessible"`
 	Email        string `name:"email" help:"Share with specific user"`
 	Role         string `name:"role" help:"Permission: reader|writer" default:"reader"`
 	Discoverable bool   `name:"discoverable" help:"Allow file discovery in search (anyone/domain only)"`
+	Recursive    bool   `name:"recursive" short:"r" help:"Share the folder and everything below it"`
+	DryRun       bool   `name:"dry-run" help:"With --recursive, show the plan without changing anything"`
 }
 
 func (c *DriveShareCmd) Run(ctx context.Context, flags *RootFlags) error {
@@ -592,6 +715,9 @@ func (c *DriveShareCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if !c.Anyone && strings.TrimSpace(c.Email) == "" {
 		return usage("must specify --anyone or --email")
 	}
+	if c.DryRun && !c.Recursive {
+		return usage("--dry-run requires --recursive")
+	}
 	role := strings.TrimSpace(c.Role)
 	if role == "" {
 		role = "reader"
@@ -604,6 +730,10 @@ func (c *DriveShareCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if err != nil {
 		return err
 	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return err
+	}
 
 	perm := &drive.Permission{Role: role}
 	if c.Anyone {
@@ -614,6 +744,20 @@ func (c *DriveShareCmd) Run(ctx context.Context, flags *RootFlags) error {
 		perm.EmailAddress = strings.TrimSpace(c.Email)
 	}
 
+	if c.Recursive {
+		targets, targetsErr := driveBulkTargets(ctx, svc, fileID, 0)
+		if targetsErr != nil {
+			return targetsErr
+		}
+		if !c.DryRun {
+			action := fmt.Sprintf("share %d items under %s with %s as %s", len(targets), targets[0].Path, drivePermissionWho(perm.Type, perm.EmailAddress, ""), role)
+			if confirmErr := confirmDestructive(ctx, flags, action); confirmErr != nil {
+				return confirmErr
+			}
+		}
+		return writeDriveBulkPermissionResult(ctx, c.DryRun, len(targets), shareDriveTree(ctx, svc, targets, perm, c.DryRun))
+	}
+
 	created, err := svc.Permissions.Create(fileID, perm).
 		SupportsAllDrives(true).
 		SendNotificationEmail(false).
@@ -643,8 +787,12 @@ func (c *DriveShareCmd) Run(ctx context.Context, flags *RootFlags) error {
 }
 
 type DriveUnshareCmd struct {
-	FileID       string `arg:"" name:"fileId" help:"File ID"`
-	PermissionID string `arg:"" name:"permissionId" help:"Permission ID"`
+	FileID       string `arg:"" name:"fileId" help:"File ID or path (/My Drive/..., drive:<shared drive>/...)"`
+	PermissionID string `arg:"" name:"permissionId" optional:"" help:"Permission ID (or use --email/--anyone)"`
+	Email        string `name:"email" help:"Remove this user's or group's access"`
+	Anyone       bool   `name:"anyone" help:"Remove the anyone-with-the-link permission"`
+	Recursive    bool   `name:"recursive" short:"r" help:"Remove access from the folder and everything below it"`
+	DryRun       bool   `name:"dry-run" help:"Show what would be removed without changing anything"`
 }
 
 func (c *DriveUnshareCmd) Run(ctx context.Context, flags *RootFlags) error {
@@ -655,9 +803,13 @@ func (c *DriveUnshareCmd) Run(ctx context.Context, flags *RootFlags) error {
 	}
 	fileID := strings.TrimSpace(c.FileID)
 	permissionID := strings.TrimSpace(c.PermissionID)
+	email := strings.TrimSpace(c.Email)
 	if fileID == "" {
 		return usage("empty fileId")
 	}
+	if email != "" || c.Anyone || c.Recursive {
+		return c.runMatching(ctx, flags, account, fileID, email)
+	}
 	if permissionID == "" {
 		return usage("empty permissionId")
 	}
@@ -670,6 +822,10 @@ func (c *DriveUnshareCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if err != nil {
 		return err
 	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return err
+	}
 
 	if err := svc.Permissions.Delete(fileID, permissionID).SupportsAllDrives(true).Context(ctx).Do(); err != nil {
 		return err
@@ -689,8 +845,47 @@ func (c *DriveUnshareCmd) Run(ctx context.Context, flags *RootFlags) error {
 	return nil
 }
 
+// runMatching removes permissions matched by --email/--anyone instead of by
+// permission ID, on one file or (with --recursive) a whole tree.
+func (c *DriveUnshareCmd) runMatching(ctx context.Context, flags *RootFlags, account string, fileID string, email string) error {
+	if strings.TrimSpace(c.PermissionID) != "" {
+		return usage("permissionId cannot be combined with --email, --anyone or --recursive")
+	}
+	if (email == "") == !c.Anyone {
+		return usage("must specify exactly one of --email or --anyone")
+	}
+
+	svc, err := newDriveService(ctx, account)
+	if err != nil {
+		return err
+	}
+	maxDepth := -1
+	if c.Recursive {
+		maxDepth = 0
+	}
+	targets, err := driveBulkTargets(ctx, svc, fileID, maxDepth)
+	if err != nil {
+		return err
+	}
+
+	if !c.DryRun {
+		who := drivePermissionWho("", email, "")
+		if c.Anyone {
+			who = "anyone"
+		}
+		action := fmt.Sprintf("remove %s's access from %s", who, targets[0].Path)
+		if c.Recursive {
+			action = fmt.Sprintf("remove %s's access from %s and everything below it", who, targets[0].Path)
+		}
+		if confirmErr := confirmDestructive(ctx, flags, action); confirmErr != nil {
+			return confirmErr
+		}
+	}
+	return writeDriveBulkPermissionResult(ctx, c.DryRun, len(targets), unshareDriveTree(ctx, svc, targets, email, c.Anyone, c.DryRun))
+}
+
 type DrivePermissionsCmd struct {
-	FileID string `arg:"" name:"fileId" help:"File ID"`
+	FileID string `arg:"" name:"fileId" help:"File ID or path (/My Drive/..., drive:<shared drive>/...)"`
 	Max    int64  `name:"max" aliases:"limit" help:"Max results" default:"100"`
 	Page   string `name:"page" help:"Page token"`
 }
@@ -710,6 +905,10 @@ func (c *DrivePermissionsCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if err != nil {
 		return err
 	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return err
+	}
 
 	call := svc.Permissions.List(fileID).
 		SupportsAllDrives(true).
@@ -754,7 +953,7 @@ func (c *DrivePermissionsCmd) Run(ctx context.Context, flags *RootFlags) error {
 }
 
 type DriveURLCmd struct {
-	FileIDs []string `arg:"" name:"fileId" help:"File IDs"`
+	FileIDs []string `arg:"" name:"fileId" help:"File IDs or paths"`
 }
 
 func (c *DriveURLCmd) Run(ctx context.Context, flags *RootFlags) error {
@@ -769,7 +968,16 @@ func (c *DriveURLCmd) Run(ctx context.Context, flags *RootFlags) error {
 		return err
 	}
 
-	for _, id := range c.FileIDs {
+	fileIDs := make([]string, 0, len(c.FileIDs))
+	for _, ref := range c.FileIDs {
+		id, err := resolveDriveFileID(ctx, svc, ref)
+		if err != nil {
+			return err
+		}
+		fileIDs = append(fileIDs, id)
+	}
+
+	for _, id := range fileIDs {
 		link, err := driveWebLink(ctx, svc, id)
 		if err != nil {
 			return err
@@ -781,8 +989,8 @@ func (c *DriveURLCmd) Run(ctx context.Context, flags *RootFlags) error {
 		}
 	}
 	if outfmt.IsJSON(ctx) {
-		urls := make([]map[string]string, 0, len(c.FileIDs))
-		for _, id := range c.FileIDs {
+		urls := make([]map[string]string, 0, len(fileIDs))
+		for _, id := range fileIDs {
 			link, err := driveWebLink(ctx, svc, id)
 			if err != nil {
 				return err
@@ -821,7 +1029,7 @@ func escapeDriveQueryString(s string) string {
 }
 
 func driveType(mimeType string) string {
-	if mimeType == "application/vnd.google-apps.folder" {
+	if mimeType == driveMimeFolder {
 		return "folder"
 	}
 	return strFile
@@ -900,6 +1108,17 @@ func guessMimeType(path string) string {
 }
 
 func downloadDriveFile(ctx context.Context, svc *drive.Service, meta *drive.File, destPath string, format string) (string, int64, error) {
+	return downloadDriveContent(meta, destPath, format, func(exportMimeType string) (*http.Response, error) {
+		if exportMimeType != "" {
+			return driveExportDownload(ctx, svc, meta.Id, exportMimeType)
+		}
+		return driveDownload(ctx, svc, meta.Id)
+	})
+}
+
+// downloadDriveContent picks the export type for Google-native files and
+// writes whatever fetch returns to disk. fetch gets "" for binary files.
+func downloadDriveContent(meta *drive.File, destPath string, format string, fetch func(exportMimeType string) (*http.Response, error)) (string, int64, error) {
 	isGoogleDoc := strings.HasPrefix(meta.MimeType, "application/vnd.google-apps.")
 
 	var (
@@ -920,10 +1139,10 @@ func downloadDriveFile(ctx context.Context, svc *drive.Service, meta *drive.File
 			}
 		}
 		outPath = replaceExt(destPath, driveExportExtension(exportMimeType))
-		resp, err = driveExportDownload(ctx, svc, meta.Id, exportMimeType)
+		resp, err = fetch(exportMimeType)
 	} else {
 		outPath = destPath
-		resp, err = driveDownload(ctx, svc, meta.Id)
+		resp, err = fetch("")
 	}
 	if err != nil {
 		return "", 0, err
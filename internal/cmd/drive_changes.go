@@ -0,0 +1,441 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/steipete/gogcli/internal/config"
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+const (
+	driveChangeFields = "nextPageToken, newStartPageToken, changes(changeType, time, removed, fileId, driveId, file(id, name, mimeType, parents, trashed, createdTime, modifiedTime, size, md5Checksum, webViewLink, lastModifyingUser(displayName, emailAddress)))"
+
+	driveChangeAdded    = "added"
+	driveChangeModified = "modified"
+	driveChangeRemoved  = "removed"
+	driveChangeTrashed  = "trashed"
+)
+
+// DriveChangesCmd is the parent command for changes subcommands
+type DriveChangesCmd struct {
+	Watch DriveChangesWatchCmd `cmd:"" name:"watch" help:"Follow the Drive changes feed (NDJSON with --json), resuming from the saved page token"`
+	Reset DriveChangesResetCmd `cmd:"" name:"reset" help:"Forget the saved page token"`
+}
+
+type DriveChangesWatchCmd struct {
+	Folder    string        `name:"folder" help:"Only report changes inside this folder (ID or path, includes subfolders)"`
+	Drive     string        `name:"drive" help:"Shared drive ID to follow (default: My Drive and shared drives you can access)"`
+	Interval  time.Duration `name:"interval" help:"Poll interval" default:"30s"`
+	Once      bool          `name:"once" help:"Report pending changes and exit (for cron)"`
+	FromNow   bool          `name:"from-now" help:"Ignore the saved page token and start from the current state"`
+	HookURL   string        `name:"hook-url" help:"Webhook URL to POST each batch of changes to"`
+	HookToken string        `name:"hook-token" help:"Webhook bearer token"`
+}
+
+// driveChangeEvent is one line of `drive changes watch` output and one item
+// of the webhook payload.
+type driveChangeEvent struct {
+	Type         string   `json:"type"`
+	FileID       string   `json:"fileId"`
+	Name         string   `json:"name,omitempty"`
+	MimeType     string   `json:"mimeType,omitempty"`
+	Parents      []string `json:"parents,omitempty"`
+	DriveID      string   `json:"driveId,omitempty"`
+	Time         string   `json:"time"`
+	ModifiedTime string   `json:"modifiedTime,omitempty"`
+	Size         int64    `json:"size,omitempty"`
+	Md5Checksum  string   `json:"md5Checksum,omitempty"`
+	WebViewLink  string   `json:"webViewLink,omitempty"`
+	ModifiedBy   string   `json:"modifiedBy,omitempty"`
+}
+
+type driveChangesHookPayload struct {
+	Source    string             `json:"source"`
+	Account   string             `json:"account"`
+	DriveID   string             `json:"driveId,omitempty"`
+	PageToken string             `json:"pageToken"`
+	Changes   []driveChangeEvent `json:"changes"`
+}
+
+type driveChangesState struct {
+	Account                string `json:"account"`
+	DriveID                string `json:"driveId,omitempty"`
+	PageToken              string `json:"pageToken"`
+	UpdatedAtMs            int64  `json:"updatedAtMs,omitempty"`
+	LastDeliveryStatus     string `json:"lastDeliveryStatus,omitempty"`
+	LastDeliveryAtMs       int64  `json:"lastDeliveryAtMs,omitempty"`
+	LastDeliveryStatusNote string `json:"lastDeliveryStatusNote,omitempty"`
+}
+
+func (c *DriveChangesWatchCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	if c.Interval <= 0 {
+		return usage("--interval must be > 0")
+	}
+	driveID := strings.TrimSpace(c.Drive)
+
+	svc, err := newDriveService(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	var scope *driveChangesScope
+	if strings.TrimSpace(c.Folder) != "" {
+		scope, err = newDriveChangesScope(ctx, svc, c.Folder)
+		if err != nil {
+			return err
+		}
+	}
+
+	statePath, err := driveChangesStatePath(account, driveID)
+	if err != nil {
+		return err
+	}
+	state, _, err := loadDriveChangesState(statePath)
+	if err != nil {
+		return err
+	}
+	state.Account, state.DriveID = account, driveID
+	if state.PageToken == "" || c.FromNow {
+		call := svc.Changes.GetStartPageToken().SupportsAllDrives(true).Context(ctx)
+		if driveID != "" {
+			call = call.DriveId(driveID)
+		}
+		start, startErr := call.Do()
+		if startErr != nil {
+			return startErr
+		}
+		state.PageToken = start.StartPageToken
+		state.UpdatedAtMs = time.Now().UnixMilli()
+		if saveErr := saveDriveChangesState(statePath, state); saveErr != nil {
+			return saveErr
+		}
+		u.Err().Printf("changes: starting at page token %s", state.PageToken)
+	}
+
+	w := &driveChangesWatcher{
+		svc:        svc,
+		account:    account,
+		driveID:    driveID,
+		scope:      scope,
+		statePath:  statePath,
+		state:      state,
+		hookURL:    strings.TrimSpace(c.HookURL),
+		hookToken:  c.HookToken,
+		hookClient: &http.Client{Timeout: defaultHookRequestTimeoutSec * time.Second},
+		emit:       driveChangesEmitter(ctx, u),
+	}
+
+	for {
+		pollErr := w.poll(ctx)
+		if c.Once {
+			return pollErr
+		}
+		if pollErr != nil {
+			u.Err().Printf("changes: %v (retrying in %s)", pollErr, c.Interval)
+		}
+
+		timer := time.NewTimer(c.Interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+type DriveChangesResetCmd struct {
+	Drive string `name:"drive" help:"Shared drive ID whose token to forget"`
+}
+
+func (c *DriveChangesResetCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	statePath, err := driveChangesStatePath(account, strings.TrimSpace(c.Drive))
+	if err != nil {
+		return err
+	}
+	removed := true
+	if err := os.Remove(statePath); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		removed = false
+	}
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"reset": removed, "path": statePath})
+	}
+	u.Out().Printf("reset\t%t", removed)
+	return nil
+}
+
+type driveChangesWatcher struct {
+	svc        *drive.Service
+	account    string
+	driveID    string
+	scope      *driveChangesScope
+	statePath  string
+	state      driveChangesState
+	hookURL    string
+	hookToken  string
+	hookClient *http.Client
+	emit       func(driveChangeEvent) error
+}
+
+// poll drains the changes feed. The saved token only advances after a page
+// has been delivered (if configured) and printed, so a failed webhook is
+// retried on the next poll.
+func (w *driveChangesWatcher) poll(ctx context.Context) error {
+	since := time.UnixMilli(w.state.UpdatedAtMs)
+	for {
+		call := w.svc.Changes.List(w.state.PageToken).
+			PageSize(1000).
+			SupportsAllDrives(true).
+			IncludeItemsFromAllDrives(true).
+			IncludeRemoved(true).
+			Fields(driveChangeFields).
+			Context(ctx)
+		if w.driveID != "" {
+			call = call.DriveId(w.driveID)
+		}
+		resp, err := call.Do()
+		if err != nil {
+			return err
+		}
+
+		events := make([]driveChangeEvent, 0, len(resp.Changes))
+		for _, ch := range resp.Changes {
+			if ch.ChangeType == "drive" {
+				continue
+			}
+			if w.scope != nil && !w.scope.includes(ch) {
+				continue
+			}
+			events = append(events, driveChangeToEvent(ch, since))
+		}
+
+		next := resp.NextPageToken
+		if next == "" {
+			next = resp.NewStartPageToken
+		}
+
+		// Deliver before printing: a failed hook retries the page on the
+		// next poll, and its events must not be printed twice.
+		if w.hookURL != "" && len(events) > 0 {
+			if err := w.sendHook(ctx, next, events); err != nil {
+				return err
+			}
+		}
+		for _, ev := range events {
+			if err := w.emit(ev); err != nil {
+				return err
+			}
+		}
+
+		if next != "" {
+			w.state.PageToken = next
+		}
+		w.state.UpdatedAtMs = time.Now().UnixMilli()
+		if err := saveDriveChangesState(w.statePath, w.state); err != nil {
+			return err
+		}
+		if resp.NextPageToken == "" {
+			return nil
+		}
+	}
+}
+
+// sendHook posts one page of events. pageToken is the token that resumes
+// after this page.
+func (w *driveChangesWatcher) sendHook(ctx context.Context, pageToken string, events []driveChangeEvent) error {
+	data, err := json.Marshal(driveChangesHookPayload{
+		Source:    "gog-drive",
+		Account:   w.account,
+		DriveID:   w.driveID,
+		PageToken: pageToken,
+		Changes:   events,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.hookURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.hookToken != "" {
+		req.Header.Set("Authorization", "Bearer "+w.hookToken)
+	}
+
+	w.state.LastDeliveryAtMs = time.Now().UnixMilli()
+	resp, err := w.hookClient.Do(req)
+	if err != nil {
+		w.state.LastDeliveryStatus = "error"
+		w.state.LastDeliveryStatusNote = err.Error()
+		_ = saveDriveChangesState(w.statePath, w.state)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		w.state.LastDeliveryStatus = gmailWatchStatusHTTPError
+		w.state.LastDeliveryStatusNote = fmt.Sprintf("status %d", resp.StatusCode)
+		_ = saveDriveChangesState(w.statePath, w.state)
+		return fmt.Errorf("hook status %d", resp.StatusCode)
+	}
+	w.state.LastDeliveryStatus = "ok"
+	w.state.LastDeliveryStatusNote = ""
+	return nil
+}
+
+// driveChangeToEvent classifies a change. The changes feed does not say
+// whether a file is new, so files created after the previous poll count as
+// added.
+func driveChangeToEvent(ch *drive.Change, since time.Time) driveChangeEvent {
+	ev := driveChangeEvent{Type: driveChangeModified, FileID: ch.FileId, DriveID: ch.DriveId, Time: ch.Time}
+	f := ch.File
+	if f != nil {
+		ev.Name = f.Name
+		ev.MimeType = f.MimeType
+		ev.Parents = f.Parents
+		ev.ModifiedTime = f.ModifiedTime
+		ev.Size = f.Size
+		ev.Md5Checksum = f.Md5Checksum
+		ev.WebViewLink = f.WebViewLink
+		if f.LastModifyingUser != nil {
+			ev.ModifiedBy = f.LastModifyingUser.EmailAddress
+		}
+	}
+	switch {
+	case ch.Removed || f == nil:
+		ev.Type = driveChangeRemoved
+	case f.Trashed:
+		ev.Type = driveChangeTrashed
+	default:
+		if created, err := time.Parse(time.RFC3339, f.CreatedTime); err == nil && !since.IsZero() && !created.Before(since) {
+			ev.Type = driveChangeAdded
+		}
+	}
+	return ev
+}
+
+func driveChangesEmitter(ctx context.Context, u *ui.UI) func(driveChangeEvent) error {
+	if outfmt.IsJSON(ctx) {
+		enc := json.NewEncoder(os.Stdout)
+		return func(ev driveChangeEvent) error { return enc.Encode(ev) }
+	}
+	return func(ev driveChangeEvent) error {
+		name := ev.Name
+		if name == "" {
+			name = "-"
+		}
+		u.Out().Printf("%s\t%s\t%s\t%s", formatDateTime(ev.Time), ev.Type, ev.FileID, name)
+		return nil
+	}
+}
+
+// driveChangesScope tracks the folder IDs below a watched folder. Folders that
+// show up in the feed under a tracked folder are added as they appear.
+type driveChangesScope struct {
+	folders map[string]bool
+	files   map[string]bool
+}
+
+func newDriveChangesScope(ctx context.Context, svc *drive.Service, ref string) (*driveChangesScope, error) {
+	root, err := getDriveFolder(ctx, svc, ref)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := walkDriveFolder(ctx, svc, root.Id, 0)
+	if err != nil {
+		return nil, err
+	}
+	s := &driveChangesScope{folders: map[string]bool{root.Id: true}, files: map[string]bool{}}
+	for _, e := range entries {
+		if e.IsFolder() {
+			s.folders[e.File.Id] = true
+		} else {
+			s.files[e.File.Id] = true
+		}
+	}
+	return s, nil
+}
+
+func (s *driveChangesScope) includes(ch *drive.Change) bool {
+	if ch.File == nil || ch.Removed {
+		return s.files[ch.FileId] || s.folders[ch.FileId]
+	}
+	inside := false
+	for _, p := range ch.File.Parents {
+		if s.folders[p] {
+			inside = true
+			break
+		}
+	}
+	known := s.files[ch.FileId] || s.folders[ch.FileId]
+	if !inside {
+		// Moved out of the folder: report once, then stop tracking.
+		delete(s.files, ch.FileId)
+		return known
+	}
+	if ch.File.MimeType == driveMimeFolder {
+		s.folders[ch.FileId] = true
+	} else {
+		s.files[ch.FileId] = true
+	}
+	return true
+}
+
+func driveChangesStatePath(account string, driveID string) (string, error) {
+	dir, err := config.EnsureDriveChangesDir()
+	if err != nil {
+		return "", err
+	}
+	name := sanitizeAccountForPath(account)
+	if driveID != "" {
+		name += "-" + sanitizeAccountForPath(driveID)
+	}
+	return filepath.Join(dir, name+".json"), nil
+}
+
+func loadDriveChangesState(path string) (driveChangesState, bool, error) {
+	var s driveChangesState
+	data, err := os.ReadFile(path) //nolint:gosec // path is derived from the config dir
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, false, nil
+		}
+		return s, false, fmt.Errorf("read changes state: %w", err)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, false, fmt.Errorf("decode changes state: %w", err)
+	}
+	return s, true, nil
+}
+
+func saveDriveChangesState(path string, s driveChangesState) error {
+	payload, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(payload, '\n'), 0o600)
+}
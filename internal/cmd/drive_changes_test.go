@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+func TestDriveChangeToEvent(t *testing.T) {
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		change *drive.Change
+		want   string
+	}{
+		{&drive.Change{FileId: "a", File: &drive.File{Id: "a", CreatedTime: "2026-03-02T00:00:00Z"}}, driveChangeAdded},
+		{&drive.Change{FileId: "b", File: &drive.File{Id: "b", CreatedTime: "2026-01-01T00:00:00Z"}}, driveChangeModified},
+		{&drive.Change{FileId: "c", File: &drive.File{Id: "c", Trashed: true}}, driveChangeTrashed},
+		{&drive.Change{FileId: "d", Removed: true}, driveChangeRemoved},
+	}
+	for _, tc := range cases {
+		if got := driveChangeToEvent(tc.change, since).Type; got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.change.FileId, got, tc.want)
+		}
+	}
+}
+
+func TestDriveChangesScope(t *testing.T) {
+	s := &driveChangesScope{folders: map[string]bool{"root": true}, files: map[string]bool{"old": true}}
+
+	if !s.includes(&drive.Change{FileId: "sub", File: &drive.File{MimeType: driveMimeFolder, Parents: []string{"root"}}}) {
+		t.Fatalf("expected new subfolder to be included")
+	}
+	if !s.includes(&drive.Change{FileId: "f", File: &drive.File{Parents: []string{"sub"}}}) {
+		t.Fatalf("expected file in new subfolder to be included")
+	}
+	if s.includes(&drive.Change{FileId: "x", File: &drive.File{Parents: []string{"elsewhere"}}}) {
+		t.Fatalf("expected unrelated file to be excluded")
+	}
+	if !s.includes(&drive.Change{FileId: "old", File: &drive.File{Parents: []string{"elsewhere"}}}) {
+		t.Fatalf("expected file moved out to be reported once")
+	}
+	if s.includes(&drive.Change{FileId: "old", File: &drive.File{Parents: []string{"elsewhere"}}}) {
+		t.Fatalf("expected file moved out to be forgotten")
+	}
+}
+
+func TestDriveChangesWatch_OnceWithHook(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var tokens []string
+	setupDriveTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/changes/startPageToken"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"startPageToken": "10"})
+		case strings.HasSuffix(r.URL.Path, "/changes"):
+			tokens = append(tokens, r.URL.Query().Get("pageToken"))
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"newStartPageToken": "11",
+				"changes": []map[string]any{
+					{"changeType": "file", "fileId": "f1", "time": "2026-03-01T00:00:00Z", "file": map[string]any{"id": "f1", "name": "a.txt"}},
+					{"changeType": "file", "fileId": "f2", "time": "2026-03-01T00:00:00Z", "removed": true},
+				},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	hookStatus := http.StatusOK
+	var payloads []driveChangesHookPayload
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("missing bearer token")
+		}
+		var p driveChangesHookPayload
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		payloads = append(payloads, p)
+		w.WriteHeader(hookStatus)
+	}))
+	t.Cleanup(hook.Close)
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := outfmt.WithMode(ui.WithUI(context.Background(), u), outfmt.Mode{JSON: true})
+	flags := &RootFlags{Account: "a@b.com"}
+	args := []string{"watch", "--once", "--hook-url", hook.URL, "--hook-token", "secret"}
+
+	// A failed delivery must leave the saved token where it was.
+	// Its events are not printed either, so the retry doesn't repeat them.
+	hookStatus = http.StatusBadGateway
+	failedOut := captureStdout(t, func() {
+		if runErr := runKong(t, &DriveChangesCmd{}, args, ctx, flags); runErr == nil {
+			t.Fatalf("expected hook error")
+		}
+	})
+	if failedOut != "" {
+		t.Fatalf("expected no events printed on a failed delivery, got:\n%s", failedOut)
+	}
+
+	hookStatus = http.StatusOK
+	out := captureStdout(t, func() {
+		if runErr := runKong(t, &DriveChangesCmd{}, args, ctx, flags); runErr != nil {
+			t.Fatalf("watch: %v", runErr)
+		}
+	})
+
+	if strings.Join(tokens, ",") != "10,10" {
+		t.Fatalf("unexpected page tokens: %v", tokens)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"type":"removed"`) {
+		t.Fatalf("unexpected ndjson:\n%s", out)
+	}
+	if len(payloads) != 2 || payloads[1].PageToken != "11" || len(payloads[1].Changes) != 2 {
+		t.Fatalf("unexpected payloads: %+v", payloads)
+	}
+
+	path, err := driveChangesStatePath("a@b.com", "")
+	if err != nil {
+		t.Fatalf("state path: %v", err)
+	}
+	state, ok, err := loadDriveChangesState(path)
+	if err != nil || !ok || state.PageToken != "11" || state.LastDeliveryStatus != "ok" {
+		t.Fatalf("unexpected state %+v ok=%v err=%v", state, ok, err)
+	}
+}
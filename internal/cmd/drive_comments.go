@@ -23,7 +23,7 @@ type DriveCommentsCmd struct {
 }
 
 type DriveCommentsListCmd struct {
-	FileID        string `arg:"" name:"fileId" help:"File ID"`
+	FileID        string `arg:"" name:"fileId" help:"File ID or path"`
 	Max           int64  `name:"max" help:"Max results" default:"100"`
 	Page          string `name:"page" help:"Page token"`
 	IncludeQuoted bool   `name:"include-quoted" help:"Include the quoted content the comment is anchored to"`
@@ -44,6 +44,10 @@ func (c *DriveCommentsListCmd) Run(ctx context.Context, flags *RootFlags) error
 	if err != nil {
 		return err
 	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return err
+	}
 
 	var call *drive.CommentsListCall
 	if c.IncludeQuoted {
@@ -125,7 +129,7 @@ func (c *DriveCommentsListCmd) Run(ctx context.Context, flags *RootFlags) error
 }
 
 type DriveCommentsGetCmd struct {
-	FileID    string `arg:"" name:"fileId" help:"File ID"`
+	FileID    string `arg:"" name:"fileId" help:"File ID or path"`
 	CommentID string `arg:"" name:"commentId" help:"Comment ID"`
 }
 
@@ -148,6 +152,10 @@ func (c *DriveCommentsGetCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if err != nil {
 		return err
 	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return err
+	}
 
 	comment, err := svc.Comments.Get(fileID, commentID).
 		Fields("id, author, content, createdTime, modifiedTime, resolved, quotedFileContent, anchor, replies").
@@ -179,7 +187,7 @@ func (c *DriveCommentsGetCmd) Run(ctx context.Context, flags *RootFlags) error {
 }
 
 type DriveCommentsCreateCmd struct {
-	FileID  string `arg:"" name:"fileId" help:"File ID"`
+	FileID  string `arg:"" name:"fileId" help:"File ID or path"`
 	Content string `arg:"" name:"content" help:"Comment text"`
 	Quoted  string `name:"quoted" help:"Text to anchor the comment to (for Google Docs)"`
 }
@@ -203,6 +211,10 @@ func (c *DriveCommentsCreateCmd) Run(ctx context.Context, flags *RootFlags) erro
 	if err != nil {
 		return err
 	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return err
+	}
 
 	comment := &drive.Comment{
 		Content: content,
@@ -234,7 +246,7 @@ func (c *DriveCommentsCreateCmd) Run(ctx context.Context, flags *RootFlags) erro
 }
 
 type DriveCommentsUpdateCmd struct {
-	FileID    string `arg:"" name:"fileId" help:"File ID"`
+	FileID    string `arg:"" name:"fileId" help:"File ID or path"`
 	CommentID string `arg:"" name:"commentId" help:"Comment ID"`
 	Content   string `arg:"" name:"content" help:"New comment text"`
 }
@@ -262,6 +274,10 @@ func (c *DriveCommentsUpdateCmd) Run(ctx context.Context, flags *RootFlags) erro
 	if err != nil {
 		return err
 	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return err
+	}
 
 	comment := &drive.Comment{
 		Content: content,
@@ -286,7 +302,7 @@ func (c *DriveCommentsUpdateCmd) Run(ctx context.Context, flags *RootFlags) erro
 }
 
 type DriveCommentsDeleteCmd struct {
-	FileID    string `arg:"" name:"fileId" help:"File ID"`
+	FileID    string `arg:"" name:"fileId" help:"File ID or path"`
 	CommentID string `arg:"" name:"commentId" help:"Comment ID"`
 }
 
@@ -313,6 +329,10 @@ func (c *DriveCommentsDeleteCmd) Run(ctx context.Context, flags *RootFlags) erro
 	if err != nil {
 		return err
 	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return err
+	}
 
 	if err := svc.Comments.Delete(fileID, commentID).Context(ctx).Do(); err != nil {
 		return err
@@ -333,7 +353,7 @@ func (c *DriveCommentsDeleteCmd) Run(ctx context.Context, flags *RootFlags) erro
 }
 
 type DriveCommentReplyCmd struct {
-	FileID    string `arg:"" name:"fileId" help:"File ID"`
+	FileID    string `arg:"" name:"fileId" help:"File ID or path"`
 	CommentID string `arg:"" name:"commentId" help:"Comment ID"`
 	Content   string `arg:"" name:"content" help:"Reply text"`
 }
@@ -361,6 +381,10 @@ func (c *DriveCommentReplyCmd) Run(ctx context.Context, flags *RootFlags) error
 	if err != nil {
 		return err
 	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return err
+	}
 
 	reply := &drive.Reply{
 		Content: content,
@@ -43,6 +43,14 @@ func copyViaDrive(ctx context.Context, flags *RootFlags, opts copyViaDriveOption
 	if err != nil {
 		return err
 	}
+	id, err = resolveDriveFileID(ctx, svc, id)
+	if err != nil {
+		return err
+	}
+	parent, err = resolveDriveFileID(ctx, svc, parent)
+	if err != nil {
+		return err
+	}
 
 	meta, err := svc.Files.Get(id).
 		SupportsAllDrives(true).
@@ -63,7 +71,6 @@ func copyViaDrive(ctx context.Context, flags *RootFlags, opts copyViaDriveOption
 		return fmt.Errorf("file is not a %s (mimeType=%q)", label, meta.MimeType)
 	}
 
-	parent = strings.TrimSpace(parent)
 	req := &drive.File{Name: name}
 	if parent != "" {
 		req.Parents = []string{parent}
@@ -2,22 +2,38 @@ package cmd
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"strings"
 
+	"google.golang.org/api/drive/v3"
+
 	"github.com/steipete/gogcli/internal/outfmt"
 	"github.com/steipete/gogcli/internal/ui"
 )
 
-// DriveDrivesCmd lists all shared drives the user has access to.
+// DriveDrivesCmd is the parent command for shared drive subcommands
 type DriveDrivesCmd struct {
-	Max   int64  `name:"max" aliases:"limit" help:"Max results (max allowed: 100)" default:"100"`
-	Page  string `name:"page" help:"Page token"`
-	Query string `name:"query" short:"q" help:"Search query for filtering shared drives"`
+	List    DriveDrivesListCmd    `cmd:"" default:"withargs" help:"List shared drives"`
+	Create  DriveDrivesCreateCmd  `cmd:"" name:"create" help:"Create a shared drive"`
+	Rename  DriveDrivesRenameCmd  `cmd:"" name:"rename" help:"Rename a shared drive"`
+	Hide    DriveDrivesHideCmd    `cmd:"" name:"hide" help:"Hide a shared drive from the default view"`
+	Unhide  DriveDrivesUnhideCmd  `cmd:"" name:"unhide" help:"Show a hidden shared drive again"`
+	Delete  DriveDrivesDeleteCmd  `cmd:"" name:"delete" help:"Delete a shared drive"`
+	Members DriveDrivesMembersCmd `cmd:"" name:"members" help:"List, add and remove shared drive members"`
+}
+
+// DriveDrivesListCmd lists all shared drives the user has access to.
+type DriveDrivesListCmd struct {
+	Max    int64  `name:"max" aliases:"limit" help:"Max results (max allowed: 100)" default:"100"`
+	Page   string `name:"page" help:"Page token"`
+	Query  string `name:"query" short:"q" help:"Search query for filtering shared drives"`
+	Hidden bool   `name:"hidden" help:"Only list hidden shared drives"`
 }
 
-func (c *DriveDrivesCmd) Run(ctx context.Context, flags *RootFlags) error {
+func (c *DriveDrivesListCmd) Run(ctx context.Context, flags *RootFlags) error {
 	u := ui.FromContext(ctx)
 	account, err := requireAccount(flags)
 	if err != nil {
@@ -37,7 +53,14 @@ func (c *DriveDrivesCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if page := strings.TrimSpace(c.Page); page != "" {
 		call = call.PageToken(page)
 	}
-	if q := strings.TrimSpace(c.Query); q != "" {
+	q := strings.TrimSpace(c.Query)
+	if c.Hidden {
+		if q != "" {
+			q += " and "
+		}
+		q += "hidden = true"
+	}
+	if q != "" {
 		call = call.Q(q)
 	}
 
@@ -73,3 +96,356 @@ func (c *DriveDrivesCmd) Run(ctx context.Context, flags *RootFlags) error {
 	printNextPageHint(u, resp.NextPageToken)
 	return nil
 }
+
+type DriveDrivesCreateCmd struct {
+	Name string `arg:"" name:"name" help:"Shared drive name"`
+}
+
+func (c *DriveDrivesCreateCmd) Run(ctx context.Context, flags *RootFlags) error {
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSpace(c.Name)
+	if name == "" {
+		return usage("empty name")
+	}
+
+	svc, err := newDriveService(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	requestID, err := newDriveRequestID()
+	if err != nil {
+		return err
+	}
+	created, err := svc.Drives.Create(requestID, &drive.Drive{Name: name}).
+		Fields("id, name, createdTime, hidden").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return err
+	}
+	return writeSharedDrive(ctx, created)
+}
+
+type DriveDrivesRenameCmd struct {
+	DriveID string `arg:"" name:"driveId" help:"Shared drive ID (or drive:<name>)"`
+	Name    string `arg:"" name:"name" help:"New name"`
+}
+
+func (c *DriveDrivesRenameCmd) Run(ctx context.Context, flags *RootFlags) error {
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSpace(c.Name)
+	if name == "" {
+		return usage("empty name")
+	}
+
+	svc, driveID, err := sharedDriveSetup(ctx, account, c.DriveID)
+	if err != nil {
+		return err
+	}
+	updated, err := svc.Drives.Update(driveID, &drive.Drive{Name: name}).
+		Fields("id, name, createdTime, hidden").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return err
+	}
+	return writeSharedDrive(ctx, updated)
+}
+
+type DriveDrivesHideCmd struct {
+	DriveID string `arg:"" name:"driveId" help:"Shared drive ID (or drive:<name>)"`
+}
+
+func (c *DriveDrivesHideCmd) Run(ctx context.Context, flags *RootFlags) error {
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	svc, driveID, err := sharedDriveSetup(ctx, account, c.DriveID)
+	if err != nil {
+		return err
+	}
+	d, err := svc.Drives.Hide(driveID).Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+	return writeSharedDrive(ctx, d)
+}
+
+type DriveDrivesUnhideCmd struct {
+	DriveID string `arg:"" name:"driveId" help:"Shared drive ID (or drive:<name>)"`
+}
+
+func (c *DriveDrivesUnhideCmd) Run(ctx context.Context, flags *RootFlags) error {
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	svc, driveID, err := sharedDriveSetup(ctx, account, c.DriveID)
+	if err != nil {
+		return err
+	}
+	d, err := svc.Drives.Unhide(driveID).Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+	return writeSharedDrive(ctx, d)
+}
+
+type DriveDrivesDeleteCmd struct {
+	DriveID   string `arg:"" name:"driveId" help:"Shared drive ID (or drive:<name>)"`
+	WithItems bool   `name:"with-items" help:"Also delete everything in the drive (requires Workspace admin; uses domain admin access)"`
+}
+
+func (c *DriveDrivesDeleteCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+
+	svc, driveID, err := sharedDriveSetup(ctx, account, c.DriveID)
+	if err != nil {
+		return err
+	}
+
+	action := fmt.Sprintf("delete shared drive %s", driveID)
+	if c.WithItems {
+		action = fmt.Sprintf("delete shared drive %s and all of its files", driveID)
+	}
+	if confirmErr := confirmDestructive(ctx, flags, action); confirmErr != nil {
+		return confirmErr
+	}
+
+	call := svc.Drives.Delete(driveID).Context(ctx)
+	if c.WithItems {
+		call = call.AllowItemDeletion(true).UseDomainAdminAccess(true)
+	}
+	if err := call.Do(); err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"deleted": true, "id": driveID})
+	}
+	u.Out().Printf("deleted\ttrue")
+	u.Out().Printf("id\t%s", driveID)
+	return nil
+}
+
+// DriveDrivesMembersCmd manages shared drive membership. Members are
+// permissions on the drive itself.
+type DriveDrivesMembersCmd struct {
+	List   DriveDrivesMembersListCmd   `cmd:"" default:"withargs" help:"List members"`
+	Add    DriveDrivesMembersAddCmd    `cmd:"" name:"add" help:"Add a member (or change their role)"`
+	Remove DriveDrivesMembersRemoveCmd `cmd:"" name:"remove" aliases:"rm" help:"Remove a member"`
+}
+
+type DriveDrivesMembersListCmd struct {
+	DriveID string `arg:"" name:"driveId" help:"Shared drive ID (or drive:<name>)"`
+}
+
+func (c *DriveDrivesMembersListCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	svc, driveID, err := sharedDriveSetup(ctx, account, c.DriveID)
+	if err != nil {
+		return err
+	}
+	members, err := listDrivePermissions(ctx, svc, driveID)
+	if err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"driveId": driveID, "members": members})
+	}
+	if len(members) == 0 {
+		u.Err().Println("No members")
+		return nil
+	}
+	w, flush := tableWriter(ctx)
+	defer flush()
+	fmt.Fprintln(w, "ID\tTYPE\tROLE\tMEMBER")
+	for _, p := range members {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Id, p.Type, p.Role, drivePermissionWho(p.Type, p.EmailAddress, p.Domain))
+	}
+	return nil
+}
+
+type DriveDrivesMembersAddCmd struct {
+	DriveID string `arg:"" name:"driveId" help:"Shared drive ID (or drive:<name>)"`
+	Email   string `arg:"" name:"email" help:"User or group email"`
+	Role    string `name:"role" help:"Role: organizer|fileOrganizer|writer|commenter|reader" default:"writer"`
+	Group   bool   `name:"group" help:"The email is a Google Group"`
+	Notify  bool   `name:"notify" help:"Send a notification email"`
+}
+
+func (c *DriveDrivesMembersAddCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	email := strings.TrimSpace(c.Email)
+	if email == "" {
+		return usage("empty email")
+	}
+	role, err := validateSharedDriveRole(c.Role)
+	if err != nil {
+		return err
+	}
+
+	svc, driveID, err := sharedDriveSetup(ctx, account, c.DriveID)
+	if err != nil {
+		return err
+	}
+
+	perm := &drive.Permission{Type: "user", Role: role, EmailAddress: email}
+	if c.Group {
+		perm.Type = "group"
+	}
+	created, err := svc.Permissions.Create(driveID, perm).
+		SupportsAllDrives(true).
+		SendNotificationEmail(c.Notify).
+		Fields("id, type, role, emailAddress").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"driveId": driveID, "permissionId": created.Id, "member": created})
+	}
+	u.Out().Printf("permission_id\t%s", created.Id)
+	u.Out().Printf("member\t%s", created.EmailAddress)
+	u.Out().Printf("role\t%s", created.Role)
+	return nil
+}
+
+type DriveDrivesMembersRemoveCmd struct {
+	DriveID string `arg:"" name:"driveId" help:"Shared drive ID (or drive:<name>)"`
+	Member  string `arg:"" name:"member" help:"Member email or permission ID"`
+}
+
+func (c *DriveDrivesMembersRemoveCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	member := strings.TrimSpace(c.Member)
+	if member == "" {
+		return usage("empty member")
+	}
+
+	svc, driveID, err := sharedDriveSetup(ctx, account, c.DriveID)
+	if err != nil {
+		return err
+	}
+
+	permissionID := member
+	if strings.Contains(member, "@") {
+		members, listErr := listDrivePermissions(ctx, svc, driveID)
+		if listErr != nil {
+			return listErr
+		}
+		permissionID = ""
+		for _, p := range members {
+			if driveMatchesPermission(p, member, false) {
+				permissionID = p.Id
+				break
+			}
+		}
+		if permissionID == "" {
+			return fmt.Errorf("%s is not a member of shared drive %s", member, driveID)
+		}
+	}
+
+	if confirmErr := confirmDestructive(ctx, flags, fmt.Sprintf("remove %s from shared drive %s", member, driveID)); confirmErr != nil {
+		return confirmErr
+	}
+	if err := svc.Permissions.Delete(driveID, permissionID).SupportsAllDrives(true).Context(ctx).Do(); err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"removed": true, "driveId": driveID, "permissionId": permissionID})
+	}
+	u.Out().Printf("removed\ttrue")
+	u.Out().Printf("permission_id\t%s", permissionID)
+	return nil
+}
+
+func sharedDriveSetup(ctx context.Context, account string, ref string) (*drive.Service, string, error) {
+	if strings.TrimSpace(ref) == "" {
+		return nil, "", usage("empty driveId")
+	}
+	svc, err := newDriveService(ctx, account)
+	if err != nil {
+		return nil, "", err
+	}
+	driveID, err := resolveSharedDriveRef(ctx, svc, ref)
+	if err != nil {
+		return nil, "", err
+	}
+	return svc, driveID, nil
+}
+
+// resolveSharedDriveRef accepts a shared drive ID or drive:<name>.
+func resolveSharedDriveRef(ctx context.Context, svc *drive.Service, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if name, ok := strings.CutPrefix(ref, "drive:"); ok {
+		return resolveSharedDriveID(ctx, svc, strings.TrimSuffix(name, "/"))
+	}
+	return ref, nil
+}
+
+func validateSharedDriveRole(role string) (string, error) {
+	role = strings.TrimSpace(role)
+	switch role {
+	case "organizer", "fileOrganizer", "writer", "commenter", "reader":
+		return role, nil
+	case "manager":
+		return "organizer", nil
+	case "contentManager":
+		return "fileOrganizer", nil
+	case "contributor":
+		return "writer", nil
+	}
+	return "", usagef("invalid --role %q (expected organizer|fileOrganizer|writer|commenter|reader)", role)
+}
+
+func writeSharedDrive(ctx context.Context, d *drive.Drive) error {
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"drive": d})
+	}
+	u := ui.FromContext(ctx)
+	u.Out().Printf("id\t%s", d.Id)
+	u.Out().Printf("name\t%s", d.Name)
+	u.Out().Printf("hidden\t%t", d.Hidden)
+	if d.CreatedTime != "" {
+		u.Out().Printf("created\t%s", formatDateTime(d.CreatedTime))
+	}
+	return nil
+}
+
+// newDriveRequestID returns the idempotency key drives.create requires.
+func newDriveRequestID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
@@ -291,3 +291,83 @@ func TestDriveDrivesCmd_TextPlain_MissingCreatedTime(t *testing.T) {
 		t.Fatalf("missing row w/ '-' created time: %q", out)
 	}
 }
+
+func TestDriveDrivesAdmin(t *testing.T) {
+	var calls []string
+	setupDriveTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/drives":
+			if r.URL.Query().Get("requestId") == "" {
+				t.Errorf("missing requestId")
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "0D1", "name": "Legal"})
+		case r.Method == http.MethodPost && r.URL.Path == "/drives/0D1/hide":
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "0D1", "name": "Legal", "hidden": true})
+		case r.Method == http.MethodGet && r.URL.Path == "/files/0D1/permissions":
+			_ = json.NewEncoder(w).Encode(map[string]any{"permissions": []map[string]any{
+				{"id": "p1", "type": "user", "role": "organizer", "emailAddress": "a@b.com"},
+				{"id": "p2", "type": "user", "role": "writer", "emailAddress": "eve@contractor.io"},
+			}})
+		case r.Method == http.MethodPost && r.URL.Path == "/files/0D1/permissions":
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["role"] != "fileOrganizer" || body["type"] != "group" {
+				t.Errorf("unexpected permission: %v", body)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "p3", "role": body["role"], "emailAddress": body["emailAddress"]})
+		case r.Method == http.MethodDelete && r.URL.Path == "/files/0D1/permissions/p2":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	created := runDriveCmdJSON(t, &DriveDrivesCmd{}, []string{"create", "Legal"})
+	if d, _ := created["drive"].(map[string]any); d["id"] != "0D1" {
+		t.Fatalf("unexpected create: %v", created)
+	}
+	hidden := runDriveCmdJSON(t, &DriveDrivesCmd{}, []string{"hide", "0D1"})
+	if d, _ := hidden["drive"].(map[string]any); d["hidden"] != true {
+		t.Fatalf("unexpected hide: %v", hidden)
+	}
+	members := runDriveCmdJSON(t, &DriveDrivesCmd{}, []string{"members", "0D1"})
+	if list, _ := members["members"].([]any); len(list) != 2 {
+		t.Fatalf("unexpected members: %v", members)
+	}
+	added := runDriveCmdJSON(t, &DriveDrivesCmd{}, []string{"members", "add", "0D1", "legal@b.com", "--role", "contentManager", "--group"})
+	if added["permissionId"] != "p3" {
+		t.Fatalf("unexpected add: %v", added)
+	}
+	removed := runDriveCmdJSON(t, &DriveDrivesCmd{}, []string{"members", "remove", "0D1", "EVE@contractor.io"})
+	if removed["permissionId"] != "p2" || calls[len(calls)-1] != "DELETE /files/0D1/permissions/p2" {
+		t.Fatalf("unexpected remove: %v (calls %v)", removed, calls)
+	}
+}
+
+func TestDriveLsAndSearch_SharedDrive(t *testing.T) {
+	var queries []string
+	setupDriveTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/drives":
+			_ = json.NewEncoder(w).Encode(map[string]any{"drives": []map[string]any{{"id": "0D1", "name": "Legal"}}})
+		case r.URL.Path == "/files":
+			q := r.URL.Query()
+			if q.Get("corpora") != "drive" || q.Get("driveId") != "0D1" {
+				t.Errorf("expected drive corpora, got %v", q)
+			}
+			queries = append(queries, q.Get("q"))
+			_ = json.NewEncoder(w).Encode(map[string]any{"files": []any{}})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	_ = runDriveCmdJSON(t, &DriveLsCmd{}, []string{"--drive", "drive:Legal"})
+	_ = runDriveCmdJSON(t, &DriveSearchCmd{}, []string{"contract", "--drive", "0D1"})
+	if len(queries) != 2 || !strings.HasPrefix(queries[0], "'0D1' in parents") || !strings.HasPrefix(queries[1], "fullText contains 'contract'") {
+		t.Fatalf("unexpected queries: %v", queries)
+	}
+}
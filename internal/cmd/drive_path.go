@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+)
+
+const (
+	driveMyDriveName     = "My Drive"
+	driveSharedPathScope = "drive:"
+)
+
+// isDrivePath reports whether ref is a path ("/My Drive/a/b", "/a/b" or
+// "drive:<shared drive>/a/b") rather than a file ID.
+func isDrivePath(ref string) bool {
+	return strings.HasPrefix(ref, "/") || strings.HasPrefix(ref, driveSharedPathScope)
+}
+
+// resolveDriveFileID returns ref unchanged when it is a file ID, otherwise
+// walks the path one folder at a time and returns the ID it points to.
+func resolveDriveFileID(ctx context.Context, svc *drive.Service, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if !isDrivePath(ref) {
+		return ref, nil
+	}
+
+	var (
+		driveID string
+		current string
+		parts   []string
+	)
+	if rest, ok := strings.CutPrefix(ref, driveSharedPathScope); ok {
+		segs := splitDrivePath(rest)
+		if len(segs) == 0 {
+			return "", usagef("invalid path %q (expected drive:<name>/...)", ref)
+		}
+		id, err := resolveSharedDriveID(ctx, svc, segs[0])
+		if err != nil {
+			return "", err
+		}
+		driveID, current, parts = id, id, segs[1:]
+	} else {
+		parts = splitDrivePath(ref)
+		if len(parts) > 0 && parts[0] == driveMyDriveName {
+			parts = parts[1:]
+		}
+		current = "root"
+	}
+
+	for i, name := range parts {
+		child, err := findDriveChild(ctx, svc, current, driveID, name, i < len(parts)-1)
+		if err != nil {
+			return "", fmt.Errorf("resolve %s: %w", ref, err)
+		}
+		current = child.Id
+	}
+	return current, nil
+}
+
+// splitDrivePath splits on "/" (use "\/" for a slash inside a name) and drops
+// empty segments.
+func splitDrivePath(p string) []string {
+	var (
+		parts []string
+		b     strings.Builder
+	)
+	flush := func() {
+		if b.Len() > 0 {
+			parts = append(parts, b.String())
+			b.Reset()
+		}
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case p[i] == '\\' && i+1 < len(p) && p[i+1] == '/':
+			b.WriteByte('/')
+			i++
+		case p[i] == '/':
+			flush()
+		default:
+			b.WriteByte(p[i])
+		}
+	}
+	flush()
+	return parts
+}
+
+func resolveSharedDriveID(ctx context.Context, svc *drive.Service, name string) (string, error) {
+	resp, err := svc.Drives.List().
+		Q(fmt.Sprintf("name = '%s'", escapeDriveQueryString(name))).
+		PageSize(10).
+		Fields("drives(id, name)").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return "", err
+	}
+
+	var ids []string
+	for _, d := range resp.Drives {
+		if d.Name == name {
+			ids = append(ids, d.Id)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("shared drive %q not found", name)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("ambiguous shared drive name %q (ids: %s); use the drive ID", name, strings.Join(ids, ", "))
+	}
+}
+
+// findDriveChild looks up the single non-trashed item called name inside
+// parent. Drive allows duplicate names, so more than one match is an error.
+func findDriveChild(ctx context.Context, svc *drive.Service, parent string, driveID string, name string, folderOnly bool) (*drive.File, error) {
+	q := fmt.Sprintf("name = '%s' and '%s' in parents and trashed = false",
+		escapeDriveQueryString(name), escapeDriveQueryString(parent))
+	if folderOnly {
+		q += fmt.Sprintf(" and mimeType = '%s'", driveMimeFolder)
+	}
+
+	call := svc.Files.List().
+		Q(q).
+		PageSize(10).
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Fields("files(id, name, mimeType)").
+		Context(ctx)
+	if driveID != "" {
+		call = call.Corpora("drive").DriveId(driveID)
+	}
+	resp, err := call.Do()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*drive.File
+	for _, f := range resp.Files {
+		if f.Name == name && (!folderOnly || f.MimeType == driveMimeFolder) {
+			matches = append(matches, f)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		if folderOnly {
+			return nil, fmt.Errorf("folder %q not found", name)
+		}
+		return nil, fmt.Errorf("%q not found", name)
+	case 1:
+		return matches[0], nil
+	default:
+		ids := make([]string, 0, len(matches))
+		for _, f := range matches {
+			ids = append(ids, f.Id)
+		}
+		return nil, fmt.Errorf("ambiguous name %q: %d items match (ids: %s); use a file ID", name, len(matches), strings.Join(ids, ", "))
+	}
+}
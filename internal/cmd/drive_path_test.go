@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSplitDrivePath(t *testing.T) {
+	got := splitDrivePath(`/My Drive//Reports/Q3\/Q4 plan.xlsx/`)
+	want := []string{"My Drive", "Reports", "Q3/Q4 plan.xlsx"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("splitDrivePath = %q, want %q", got, want)
+	}
+}
+
+func TestResolveDriveFileID(t *testing.T) {
+	children := map[string][]map[string]any{
+		"root": {
+			{"id": "f-reports", "name": "Reports", "mimeType": driveMimeFolder},
+			{"id": "f-notes", "name": "Reports.txt", "mimeType": "text/plain"},
+		},
+		"f-reports": {
+			{"id": "q3", "name": "q3.xlsx", "mimeType": "application/vnd.ms-excel"},
+			{"id": "dup1", "name": "dup.txt", "mimeType": "text/plain"},
+			{"id": "dup2", "name": "dup.txt", "mimeType": "text/plain"},
+		},
+		"sd1": {
+			{"id": "f-team", "name": "Plans", "mimeType": driveMimeFolder},
+		},
+	}
+	svc, log := newDriveFolderTestService(t, children, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/drives") {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"drives": []map[string]any{{"id": "sd1", "name": "Team"}}})
+			return
+		}
+		http.NotFound(w, r)
+	})
+	ctx := context.Background()
+
+	cases := map[string]string{
+		"abc123":                      "abc123",
+		"/":                           "root",
+		"/My Drive":                   "root",
+		"/My Drive/Reports/q3.xlsx":   "q3",
+		"/Reports/q3.xlsx":            "q3",
+		"drive:Team":                  "sd1",
+		"drive:Team/Plans":            "f-team",
+		"  /Reports  ":                "f-reports",
+		"/My Drive/Reports/../Nope/x": "",
+	}
+	for ref, want := range cases {
+		got, err := resolveDriveFileID(ctx, svc, ref)
+		if want == "" {
+			if err == nil || !strings.Contains(err.Error(), "not found") {
+				t.Fatalf("resolve %q: expected not found, got %q, %v", ref, got, err)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Fatalf("resolve %q = %q, %v; want %q", ref, got, err, want)
+		}
+	}
+
+	_, err := resolveDriveFileID(ctx, svc, "/Reports/dup.txt")
+	if err == nil || !strings.Contains(err.Error(), "ambiguous") || !strings.Contains(err.Error(), "dup1, dup2") {
+		t.Fatalf("expected ambiguity error listing ids, got %v", err)
+	}
+
+	for _, entry := range *log {
+		if strings.Contains(entry, "abc123") {
+			t.Fatalf("plain IDs must not hit the API, got %v", *log)
+		}
+	}
+}
@@ -0,0 +1,421 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+const (
+	drivePermissionFields = "id, type, role, emailAddress, domain, displayName, deleted, permissionDetails(inherited, inheritedFrom)"
+
+	drivePermAnyone   = "anyone"
+	drivePermExternal = "external"
+	drivePermInternal = "internal"
+)
+
+// DrivePermissionsGroupCmd is the parent command for permissions subcommands;
+// listing stays on DrivePermissionsCmd and is the default.
+type DrivePermissionsGroupCmd struct {
+	List  DrivePermissionsCmd      `cmd:"" default:"withargs" help:"List permissions on a file"`
+	Audit DrivePermissionsAuditCmd `cmd:"" name:"audit" help:"Report non-owner shares on a folder tree"`
+}
+
+type DrivePermissionsAuditCmd struct {
+	FolderID    string `arg:"" name:"folderId" help:"Folder (or file) ID or path"`
+	Recursive   bool   `name:"recursive" short:"r" help:"Audit the whole tree (default: the folder and its direct children)"`
+	Domain      string `name:"domain" help:"Domain treated as internal (default: the account's domain)"`
+	External    bool   `name:"external" help:"Only report anyone links and shares outside the domain"`
+	Concurrency int    `name:"concurrency" help:"Parallel permission lookups" default:"4"`
+}
+
+// drivePermissionFinding is one non-owner permission found by an audit.
+type drivePermissionFinding struct {
+	FileID       string `json:"fileId"`
+	Path         string `json:"path"`
+	MimeType     string `json:"mimeType,omitempty"`
+	PermissionID string `json:"permissionId"`
+	Type         string `json:"type"`
+	Role         string `json:"role"`
+	EmailAddress string `json:"emailAddress,omitempty"`
+	Domain       string `json:"domain,omitempty"`
+	Category     string `json:"category"`
+	Inherited    bool   `json:"inherited,omitempty"`
+}
+
+func (c *DrivePermissionsAuditCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(c.FolderID) == "" {
+		return usage("empty folderId")
+	}
+	domain := strings.ToLower(strings.TrimSpace(c.Domain))
+	if domain == "" {
+		_, domain, _ = strings.Cut(strings.ToLower(account), "@")
+	}
+
+	svc, err := newDriveService(ctx, account)
+	if err != nil {
+		return err
+	}
+	maxDepth := 1
+	if c.Recursive {
+		maxDepth = 0
+	}
+	targets, err := driveBulkTargets(ctx, svc, c.FolderID, maxDepth)
+	if err != nil {
+		return err
+	}
+
+	perms := make([][]*drive.Permission, len(targets))
+	errs := make([]error, len(targets))
+	concurrency := c.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			perms[i], errs[i] = listDrivePermissions(ctx, svc, targets[i].ID)
+		}(i)
+	}
+	wg.Wait()
+
+	findings := []drivePermissionFinding{}
+	counts := map[string]int{drivePermAnyone: 0, drivePermExternal: 0, drivePermInternal: 0}
+	for i, t := range targets {
+		if errs[i] != nil {
+			return fmt.Errorf("%s: %w", t.Path, errs[i])
+		}
+		for _, p := range perms[i] {
+			if p.Role == "owner" || p.Deleted {
+				continue
+			}
+			category := classifyDrivePermission(p, domain)
+			if c.External && category == drivePermInternal {
+				continue
+			}
+			counts[category]++
+			findings = append(findings, drivePermissionFinding{
+				FileID:       t.ID,
+				Path:         t.Path,
+				MimeType:     t.MimeType,
+				PermissionID: p.Id,
+				Type:         p.Type,
+				Role:         p.Role,
+				EmailAddress: p.EmailAddress,
+				Domain:       p.Domain,
+				Category:     category,
+				Inherited:    drivePermissionInherited(p),
+			})
+		}
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{
+			"folderId": targets[0].ID,
+			"domain":   domain,
+			"scanned":  len(targets),
+			"counts":   counts,
+			"findings": findings,
+		})
+	}
+
+	if len(findings) == 0 {
+		u.Err().Printf("No shares found (%d items scanned)", len(targets))
+		return nil
+	}
+	w, flush := tableWriter(ctx)
+	fmt.Fprintln(w, "CATEGORY\tROLE\tWHO\tPATH\tPERMISSION")
+	for _, f := range findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", f.Category, f.Role, drivePermissionWho(f.Type, f.EmailAddress, f.Domain), f.Path, f.PermissionID)
+	}
+	flush()
+	u.Err().Printf("%d items scanned: %d anyone, %d external, %d internal", len(targets), counts[drivePermAnyone], counts[drivePermExternal], counts[drivePermInternal])
+	return nil
+}
+
+// driveBulkTarget is a file or folder touched by a bulk permission command.
+type driveBulkTarget struct {
+	ID       string
+	Path     string
+	MimeType string
+}
+
+// driveBulkTargets resolves ref and, for folders, appends its descendants down
+// to maxDepth (0 means unlimited, negative means only ref itself). The root is
+// always first, and folders come before their contents.
+func driveBulkTargets(ctx context.Context, svc *drive.Service, ref string, maxDepth int) ([]driveBulkTarget, error) {
+	id, err := resolveDriveFileID(ctx, svc, strings.TrimSpace(ref))
+	if err != nil {
+		return nil, err
+	}
+	root, err := svc.Files.Get(id).
+		SupportsAllDrives(true).
+		Fields("id, name, mimeType").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+	out := []driveBulkTarget{{ID: root.Id, Path: root.Name, MimeType: root.MimeType}}
+	if root.MimeType != driveMimeFolder || maxDepth < 0 {
+		return out, nil
+	}
+	entries, err := walkDriveFolder(ctx, svc, root.Id, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		out = append(out, driveBulkTarget{ID: e.File.Id, Path: path.Join(root.Name, e.Path), MimeType: e.File.MimeType})
+	}
+	return out, nil
+}
+
+func listDrivePermissions(ctx context.Context, svc *drive.Service, fileID string) ([]*drive.Permission, error) {
+	var out []*drive.Permission
+	pageToken := ""
+	for {
+		call := svc.Permissions.List(fileID).
+			SupportsAllDrives(true).
+			PageSize(100).
+			Fields("nextPageToken, permissions(" + drivePermissionFields + ")").
+			Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Do()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, resp.Permissions...)
+		if resp.NextPageToken == "" {
+			return out, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// classifyDrivePermission buckets a permission relative to the internal domain.
+func classifyDrivePermission(p *drive.Permission, domain string) string {
+	switch p.Type {
+	case "anyone":
+		return drivePermAnyone
+	case "domain":
+		if domain != "" && strings.EqualFold(p.Domain, domain) {
+			return drivePermInternal
+		}
+		return drivePermExternal
+	}
+	_, emailDomain, _ := strings.Cut(strings.ToLower(p.EmailAddress), "@")
+	if domain != "" && emailDomain == domain {
+		return drivePermInternal
+	}
+	return drivePermExternal
+}
+
+// drivePermissionInherited reports whether Drive says the permission comes
+// from a parent folder. Drive only fills permissionDetails for shared drive
+// items.
+func drivePermissionInherited(p *drive.Permission) bool {
+	if len(p.PermissionDetails) == 0 {
+		return false
+	}
+	for _, d := range p.PermissionDetails {
+		if !d.Inherited {
+			return false
+		}
+	}
+	return true
+}
+
+func drivePermissionWho(typ, email, domain string) string {
+	switch {
+	case typ == "anyone":
+		return "anyone"
+	case email != "":
+		return email
+	case domain != "":
+		return domain
+	}
+	return "-"
+}
+
+func driveMatchesPermission(p *drive.Permission, email string, anyone bool) bool {
+	if anyone {
+		return p.Type == "anyone"
+	}
+	return (p.Type == "user" || p.Type == "group") && strings.EqualFold(p.EmailAddress, email)
+}
+
+// driveRoleRank orders Drive roles by the access they grant.
+var driveRoleRank = map[string]int{
+	"reader":        1,
+	"commenter":     2,
+	"writer":        3,
+	"fileOrganizer": 4,
+	"organizer":     5,
+	"owner":         6,
+}
+
+// driveRoleCovers reports whether an existing role grants at least role.
+func driveRoleCovers(existing, role string) bool {
+	have, ok := driveRoleRank[existing]
+	return ok && have >= driveRoleRank[role]
+}
+
+// driveBulkPermissionItem is one line of a recursive share/unshare plan.
+type driveBulkPermissionItem struct {
+	FileID       string `json:"fileId"`
+	Path         string `json:"path"`
+	Action       string `json:"action"`
+	PermissionID string `json:"permissionId,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// shareDriveTree adds perm to every target that does not already grant it
+// (or a higher role).
+// Targets are handled in order so permissions inherited from a folder that
+// was just shared are seen as existing on its children.
+func shareDriveTree(ctx context.Context, svc *drive.Service, targets []driveBulkTarget, perm *drive.Permission, dryRun bool) []driveBulkPermissionItem {
+	items := make([]driveBulkPermissionItem, 0, len(targets))
+	for _, t := range targets {
+		item := driveBulkPermissionItem{FileID: t.ID, Path: t.Path, Action: "share"}
+		existing, err := listDrivePermissions(ctx, svc, t.ID)
+		if err != nil {
+			item.Error = err.Error()
+			items = append(items, item)
+			continue
+		}
+		for _, p := range existing {
+			if driveMatchesPermission(p, perm.EmailAddress, perm.Type == "anyone") && driveRoleCovers(p.Role, perm.Role) {
+				item.Action = "exists"
+				item.PermissionID = p.Id
+				break
+			}
+		}
+		if item.Action == "share" && !dryRun {
+			created, createErr := svc.Permissions.Create(t.ID, perm).
+				SupportsAllDrives(true).
+				SendNotificationEmail(false).
+				Fields("id").
+				Context(ctx).
+				Do()
+			if createErr != nil {
+				item.Error = createErr.Error()
+			} else {
+				item.PermissionID = created.Id
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// unshareDriveTree removes the matching direct permission from every target.
+// Inherited permissions can only be removed on the folder they come from and
+// are reported as such.
+func unshareDriveTree(ctx context.Context, svc *drive.Service, targets []driveBulkTarget, email string, anyone bool, dryRun bool) []driveBulkPermissionItem {
+	items := []driveBulkPermissionItem{}
+	for _, t := range targets {
+		existing, err := listDrivePermissions(ctx, svc, t.ID)
+		if err != nil {
+			items = append(items, driveBulkPermissionItem{FileID: t.ID, Path: t.Path, Action: "unshare", Error: err.Error()})
+			continue
+		}
+		for _, p := range existing {
+			if p.Role == "owner" || !driveMatchesPermission(p, email, anyone) {
+				continue
+			}
+			item := driveBulkPermissionItem{FileID: t.ID, Path: t.Path, Action: "unshare", PermissionID: p.Id}
+			switch {
+			case drivePermissionInherited(p):
+				item.Action = "inherited"
+			case !dryRun:
+				if delErr := svc.Permissions.Delete(t.ID, p.Id).SupportsAllDrives(true).Context(ctx).Do(); delErr != nil {
+					item.Error = delErr.Error()
+				}
+			}
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// writeDriveBulkPermissionResult prints a share/unshare plan or result and
+// returns an error when any change failed.
+func writeDriveBulkPermissionResult(ctx context.Context, dryRun bool, scanned int, items []driveBulkPermissionItem) error {
+	u := ui.FromContext(ctx)
+	changed, failed := 0, 0
+	for _, it := range items {
+		switch {
+		case it.Error != "":
+			failed++
+		case it.Action == "share" || it.Action == "unshare":
+			changed++
+		}
+	}
+
+	if outfmt.IsJSON(ctx) {
+		if err := outfmt.WriteJSON(os.Stdout, map[string]any{
+			"dryRun":  dryRun,
+			"scanned": scanned,
+			"changed": changed,
+			"failed":  failed,
+			"items":   items,
+		}); err != nil {
+			return err
+		}
+	} else {
+		if len(items) == 0 {
+			u.Err().Printf("Nothing to change (%d items scanned)", scanned)
+			return nil
+		}
+		sorted := append([]driveBulkPermissionItem(nil), items...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+		w, flush := tableWriter(ctx)
+		fmt.Fprintln(w, "PATH\tACTION\tSTATUS")
+		for _, it := range sorted {
+			status := "done"
+			switch {
+			case it.Error != "":
+				status = "error: " + it.Error
+			case it.Action == "exists":
+				status = "already shared"
+			case it.Action == "inherited":
+				status = "inherited from parent, skipped"
+			case dryRun:
+				status = "planned"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", it.Path, it.Action, status)
+		}
+		flush()
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d permission changes failed", failed, changed+failed)
+	}
+	return nil
+}
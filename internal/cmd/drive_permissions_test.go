@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/api/drive/v3"
+)
+
+func TestClassifyDrivePermission(t *testing.T) {
+	cases := []struct {
+		perm *drive.Permission
+		want string
+	}{
+		{&drive.Permission{Type: "anyone"}, drivePermAnyone},
+		{&drive.Permission{Type: "user", EmailAddress: "Bob@Example.com"}, drivePermInternal},
+		{&drive.Permission{Type: "user", EmailAddress: "eve@contractor.io"}, drivePermExternal},
+		{&drive.Permission{Type: "domain", Domain: "example.com"}, drivePermInternal},
+		{&drive.Permission{Type: "domain", Domain: "other.com"}, drivePermExternal},
+	}
+	for _, tc := range cases {
+		if got := classifyDrivePermission(tc.perm, "example.com"); got != tc.want {
+			t.Fatalf("%+v: got %q, want %q", tc.perm, got, tc.want)
+		}
+	}
+}
+
+// newDrivePermissionsTestServer serves a tree root/{a.txt, sub/{b.txt}} where
+// root and b.txt are shared with a contractor and a.txt has an anyone link.
+// It records permission writes.
+func newDrivePermissionsTestServer(t *testing.T) *[]string {
+	t.Helper()
+
+	children := map[string][]map[string]any{
+		"root1": {
+			{"id": "a", "name": "a.txt", "mimeType": "text/plain"},
+			{"id": "sub", "name": "sub", "mimeType": driveMimeFolder},
+		},
+		"sub": {{"id": "b", "name": "b.txt", "mimeType": "text/plain"}},
+	}
+	owner := map[string]any{"id": "p-owner", "type": "user", "role": "owner", "emailAddress": "a@b.com"}
+	contractor := map[string]any{"id": "p-eve", "type": "user", "role": "writer", "emailAddress": "eve@contractor.io"}
+	perms := map[string][]map[string]any{
+		"root1": {owner, contractor},
+		"a":     {owner, {"id": "anyoneWithLink", "type": "anyone", "role": "reader"}},
+		"sub":   {owner},
+		"b":     {owner, contractor},
+	}
+
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	setupDriveTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		// parts: files[/id[/permissions[/pid]]]
+		switch {
+		case len(parts) == 1 && parts[0] == "files":
+			q := r.URL.Query().Get("q")
+			for id, files := range children {
+				if strings.Contains(q, "'"+id+"' in parents") {
+					_ = json.NewEncoder(w).Encode(map[string]any{"files": files})
+					return
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"files": []any{}})
+		case len(parts) == 2 && parts[1] == "root1":
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "root1", "name": "Project", "mimeType": driveMimeFolder})
+		case len(parts) == 3 && parts[2] == "permissions" && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]any{"permissions": perms[parts[1]]})
+		case len(parts) == 3 && parts[2] == "permissions" && r.Method == http.MethodPost:
+			mu.Lock()
+			calls = append(calls, "POST "+parts[1])
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "p-new"})
+		case len(parts) == 4 && r.Method == http.MethodDelete:
+			mu.Lock()
+			calls = append(calls, "DELETE "+parts[1]+"/"+parts[3])
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	return &calls
+}
+
+func TestDrivePermissionsAudit_Recursive(t *testing.T) {
+	_ = newDrivePermissionsTestServer(t)
+
+	parsed := runDriveCmdJSON(t, &DrivePermissionsGroupCmd{}, []string{"audit", "root1", "--recursive", "--domain", "example.com"})
+	if parsed["scanned"] != float64(4) {
+		t.Fatalf("expected 4 items scanned, got %v", parsed)
+	}
+	counts, _ := parsed["counts"].(map[string]any)
+	if counts["anyone"] != float64(1) || counts["external"] != float64(2) {
+		t.Fatalf("unexpected counts: %v", counts)
+	}
+	findings, _ := parsed["findings"].([]any)
+	paths := []string{}
+	for _, f := range findings {
+		paths = append(paths, f.(map[string]any)["path"].(string))
+	}
+	sort.Strings(paths)
+	if strings.Join(paths, ",") != "Project,Project/a.txt,Project/sub/b.txt" {
+		t.Fatalf("unexpected findings: %v", paths)
+	}
+}
+
+func TestDriveUnshare_RecursiveByEmail(t *testing.T) {
+	calls := newDrivePermissionsTestServer(t)
+
+	plan := runDriveCmdJSON(t, &DriveUnshareCmd{}, []string{"root1", "--recursive", "--email", "EVE@contractor.io", "--dry-run"})
+	if len(*calls) != 0 || plan["changed"] != float64(2) {
+		t.Fatalf("unexpected dry-run: calls=%v plan=%v", *calls, plan)
+	}
+
+	_ = runDriveCmdJSON(t, &DriveUnshareCmd{}, []string{"root1", "--recursive", "--email", "eve@contractor.io"})
+	if strings.Join(*calls, ";") != "DELETE root1/p-eve;DELETE b/p-eve" {
+		t.Fatalf("unexpected calls: %v", *calls)
+	}
+}
+
+func TestDriveShare_RecursiveSkipsExisting(t *testing.T) {
+	calls := newDrivePermissionsTestServer(t)
+
+	parsed := runDriveCmdJSON(t, &DriveShareCmd{}, []string{"root1", "--recursive", "--email", "eve@contractor.io", "--role", "writer"})
+	if strings.Join(*calls, ";") != "POST a;POST sub" {
+		t.Fatalf("unexpected calls: %v", *calls)
+	}
+	if parsed["changed"] != float64(2) || parsed["scanned"] != float64(4) {
+		t.Fatalf("unexpected json: %v", parsed)
+	}
+}
+
+func TestDriveShare_RecursiveHigherRoleExists(t *testing.T) {
+	calls := newDrivePermissionsTestServer(t)
+
+	// eve is already a writer on root1 and b.txt, which covers reader.
+	parsed := runDriveCmdJSON(t, &DriveShareCmd{}, []string{"root1", "--recursive", "--email", "eve@contractor.io", "--role", "reader"})
+	if strings.Join(*calls, ";") != "POST a;POST sub" || parsed["changed"] != float64(2) {
+		t.Fatalf("unexpected share: calls=%v json=%v", *calls, parsed)
+	}
+	if driveRoleCovers("commenter", "writer") || !driveRoleCovers("organizer", "fileOrganizer") || driveRoleCovers("unknown", "reader") {
+		t.Fatalf("unexpected role ordering")
+	}
+}
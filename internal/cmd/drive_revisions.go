@@ -0,0 +1,402 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+	gapi "google.golang.org/api/googleapi"
+
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+const driveRevisionFields = "id, mimeType, modifiedTime, keepForever, published, size, originalFilename, md5Checksum, lastModifyingUser(displayName, emailAddress), exportLinks"
+
+// DriveRevisionsCmd is the parent command for revisions subcommands
+type DriveRevisionsCmd struct {
+	List     DriveRevisionsListCmd     `cmd:"" name:"list" aliases:"ls" help:"List revisions of a file"`
+	Get      DriveRevisionsGetCmd      `cmd:"" name:"get" help:"Get revision metadata"`
+	Download DriveRevisionsDownloadCmd `cmd:"" name:"download" help:"Download (or export) a specific revision"`
+	Restore  DriveRevisionsRestoreCmd  `cmd:"" name:"restore" help:"Make an old revision current again (binary files only)"`
+	Keep     DriveRevisionsKeepCmd     `cmd:"" name:"keep" help:"Keep a revision forever (or release it with --off)"`
+	Delete   DriveRevisionsDeleteCmd   `cmd:"" name:"delete" help:"Delete a revision (binary files only)"`
+}
+
+type DriveRevisionsListCmd struct {
+	FileID string `arg:"" name:"fileId" help:"File ID or path"`
+	Max    int64  `name:"max" aliases:"limit" help:"Max results" default:"200"`
+	Page   string `name:"page" help:"Page token"`
+}
+
+func (c *DriveRevisionsListCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	_, svc, fileID, err := driveRevisionsSetup(ctx, flags, c.FileID)
+	if err != nil {
+		return err
+	}
+
+	call := svc.Revisions.List(fileID).
+		Fields(gapi.Field("nextPageToken, revisions(" + driveRevisionFields + ")")).
+		Context(ctx)
+	if c.Max > 0 {
+		call = call.PageSize(c.Max)
+	}
+	if strings.TrimSpace(c.Page) != "" {
+		call = call.PageToken(c.Page)
+	}
+	resp, err := call.Do()
+	if err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{
+			"fileId":        fileID,
+			"revisions":     resp.Revisions,
+			"nextPageToken": resp.NextPageToken,
+		})
+	}
+
+	if len(resp.Revisions) == 0 {
+		u.Err().Println("No revisions")
+		return nil
+	}
+
+	w, flush := tableWriter(ctx)
+	defer flush()
+	fmt.Fprintln(w, "ID\tMODIFIED\tSIZE\tKEEP\tAUTHOR")
+	for _, r := range resp.Revisions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n",
+			r.Id,
+			formatDateTime(r.ModifiedTime),
+			formatDriveSize(r.Size),
+			r.KeepForever,
+			driveRevisionAuthor(r),
+		)
+	}
+	printNextPageHint(u, resp.NextPageToken)
+	return nil
+}
+
+type DriveRevisionsGetCmd struct {
+	FileID     string `arg:"" name:"fileId" help:"File ID or path"`
+	RevisionID string `arg:"" name:"revisionId" help:"Revision ID"`
+}
+
+func (c *DriveRevisionsGetCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	_, svc, fileID, err := driveRevisionsSetup(ctx, flags, c.FileID)
+	if err != nil {
+		return err
+	}
+	revisionID := strings.TrimSpace(c.RevisionID)
+	if revisionID == "" {
+		return usage("empty revisionId")
+	}
+
+	rev, err := getDriveRevision(ctx, svc, fileID, revisionID)
+	if err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"fileId": fileID, "revision": rev})
+	}
+
+	u.Out().Printf("id\t%s", rev.Id)
+	u.Out().Printf("modified\t%s", rev.ModifiedTime)
+	if rev.MimeType != "" {
+		u.Out().Printf("type\t%s", rev.MimeType)
+	}
+	u.Out().Printf("size\t%s", formatDriveSize(rev.Size))
+	u.Out().Printf("keep_forever\t%t", rev.KeepForever)
+	if author := driveRevisionAuthor(rev); author != "-" {
+		u.Out().Printf("author\t%s", author)
+	}
+	if rev.OriginalFilename != "" {
+		u.Out().Printf("original_filename\t%s", rev.OriginalFilename)
+	}
+	if len(rev.ExportLinks) > 0 {
+		formats := make([]string, 0, len(rev.ExportLinks))
+		for mimeType := range rev.ExportLinks {
+			formats = append(formats, mimeType)
+		}
+		sort.Strings(formats)
+		u.Out().Printf("export_types\t%s", strings.Join(formats, ", "))
+	}
+	return nil
+}
+
+type DriveRevisionsDownloadCmd struct {
+	FileID     string         `arg:"" name:"fileId" help:"File ID or path"`
+	RevisionID string         `arg:"" name:"revisionId" help:"Revision ID"`
+	Output     OutputPathFlag `embed:""`
+	Format     string         `name:"format" help:"Export format for Google Docs files: pdf|csv|xlsx|pptx|txt|png|docx (default: auto)"`
+}
+
+func (c *DriveRevisionsDownloadCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, svc, fileID, err := driveRevisionsSetup(ctx, flags, c.FileID)
+	if err != nil {
+		return err
+	}
+	revisionID := strings.TrimSpace(c.RevisionID)
+	if revisionID == "" {
+		return usage("empty revisionId")
+	}
+
+	meta, err := svc.Files.Get(fileID).
+		SupportsAllDrives(true).
+		Fields("id, name, mimeType").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return err
+	}
+	rev, err := getDriveRevision(ctx, svc, fileID, revisionID)
+	if err != nil {
+		return err
+	}
+
+	// Default file name: <fileId>_r<revisionId>_<name>.
+	pathMeta := *meta
+	pathMeta.Id = meta.Id + "_r" + rev.Id
+	destPath, err := resolveDriveDownloadDestPath(&pathMeta, c.Output.Path)
+	if err != nil {
+		return err
+	}
+
+	downloadedPath, size, err := downloadDriveContent(meta, destPath, c.Format, func(exportMimeType string) (*http.Response, error) {
+		if exportMimeType == "" {
+			return driveRevisionDownload(ctx, svc, fileID, rev.Id)
+		}
+		link := rev.ExportLinks[exportMimeType]
+		if link == "" {
+			return nil, fmt.Errorf("revision %s cannot be exported as %s", rev.Id, exportMimeType)
+		}
+		client, clientErr := newDriveHTTPClient(ctx, account)
+		if clientErr != nil {
+			return nil, clientErr
+		}
+		return driveRevisionExport(ctx, client, link)
+	})
+	if err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{
+			"path":       downloadedPath,
+			"size":       size,
+			"revisionId": rev.Id,
+		})
+	}
+
+	u.Out().Printf("path\t%s", downloadedPath)
+	u.Out().Printf("size\t%s", formatDriveSize(size))
+	u.Out().Printf("revision\t%s", rev.Id)
+	return nil
+}
+
+type DriveRevisionsRestoreCmd struct {
+	FileID     string `arg:"" name:"fileId" help:"File ID or path"`
+	RevisionID string `arg:"" name:"revisionId" help:"Revision ID to restore"`
+}
+
+func (c *DriveRevisionsRestoreCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	_, svc, fileID, err := driveRevisionsSetup(ctx, flags, c.FileID)
+	if err != nil {
+		return err
+	}
+	revisionID := strings.TrimSpace(c.RevisionID)
+	if revisionID == "" {
+		return usage("empty revisionId")
+	}
+
+	rev, err := getDriveRevision(ctx, svc, fileID, revisionID)
+	if err != nil {
+		return err
+	}
+	if len(rev.ExportLinks) > 0 {
+		return fmt.Errorf("cannot restore Google Docs/Sheets/Slides revisions in place; use `gog drive revisions download %s %s --format ...` and restore from the Docs version history", fileID, rev.Id)
+	}
+
+	resp, err := driveRevisionDownload(ctx, svc, fileID, rev.Id)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("download revision failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	updated, err := svc.Files.Update(fileID, &drive.File{}).
+		SupportsAllDrives(true).
+		Media(resp.Body, gapi.ContentType(rev.MimeType)).
+		Fields("id, name, headRevisionId, modifiedTime").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{
+			strFile:            updated,
+			"restoredRevision": rev.Id,
+		})
+	}
+
+	u.Out().Printf("id\t%s", updated.Id)
+	u.Out().Printf("name\t%s", updated.Name)
+	u.Out().Printf("restored_revision\t%s", rev.Id)
+	u.Out().Printf("head_revision\t%s", updated.HeadRevisionId)
+	return nil
+}
+
+type DriveRevisionsKeepCmd struct {
+	FileID     string `arg:"" name:"fileId" help:"File ID or path"`
+	RevisionID string `arg:"" name:"revisionId" help:"Revision ID"`
+	Off        bool   `name:"off" help:"Stop keeping the revision forever (Drive may purge it later)"`
+}
+
+func (c *DriveRevisionsKeepCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	_, svc, fileID, err := driveRevisionsSetup(ctx, flags, c.FileID)
+	if err != nil {
+		return err
+	}
+	revisionID := strings.TrimSpace(c.RevisionID)
+	if revisionID == "" {
+		return usage("empty revisionId")
+	}
+
+	updated, err := svc.Revisions.Update(fileID, revisionID, &drive.Revision{
+		KeepForever:     !c.Off,
+		ForceSendFields: []string{"KeepForever"},
+	}).
+		Fields("id, keepForever, modifiedTime").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"fileId": fileID, "revision": updated})
+	}
+
+	u.Out().Printf("id\t%s", updated.Id)
+	u.Out().Printf("keep_forever\t%t", updated.KeepForever)
+	return nil
+}
+
+type DriveRevisionsDeleteCmd struct {
+	FileID     string `arg:"" name:"fileId" help:"File ID or path"`
+	RevisionID string `arg:"" name:"revisionId" help:"Revision ID"`
+}
+
+func (c *DriveRevisionsDeleteCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	_, svc, fileID, err := driveRevisionsSetup(ctx, flags, c.FileID)
+	if err != nil {
+		return err
+	}
+	revisionID := strings.TrimSpace(c.RevisionID)
+	if revisionID == "" {
+		return usage("empty revisionId")
+	}
+
+	if confirmErr := confirmDestructive(ctx, flags, fmt.Sprintf("delete revision %s of drive file %s", revisionID, fileID)); confirmErr != nil {
+		return confirmErr
+	}
+
+	if err := svc.Revisions.Delete(fileID, revisionID).Context(ctx).Do(); err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{
+			"deleted":    true,
+			"fileId":     fileID,
+			"revisionId": revisionID,
+		})
+	}
+	u.Out().Printf("deleted\ttrue")
+	u.Out().Printf("file_id\t%s", fileID)
+	u.Out().Printf("revision_id\t%s", revisionID)
+	return nil
+}
+
+// driveRevisionsSetup does the account, service and file ID (or path)
+// resolution shared by all revisions subcommands.
+func driveRevisionsSetup(ctx context.Context, flags *RootFlags, fileRef string) (string, *drive.Service, string, error) {
+	account, err := requireAccount(flags)
+	if err != nil {
+		return "", nil, "", err
+	}
+	fileID := strings.TrimSpace(fileRef)
+	if fileID == "" {
+		return "", nil, "", usage("empty fileId")
+	}
+
+	svc, err := newDriveService(ctx, account)
+	if err != nil {
+		return "", nil, "", err
+	}
+	fileID, err = resolveDriveFileID(ctx, svc, fileID)
+	if err != nil {
+		return "", nil, "", err
+	}
+	return account, svc, fileID, nil
+}
+
+func getDriveRevision(ctx context.Context, svc *drive.Service, fileID string, revisionID string) (*drive.Revision, error) {
+	rev, err := svc.Revisions.Get(fileID, revisionID).
+		Fields(driveRevisionFields).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+	if rev == nil {
+		return nil, errors.New("revision not found")
+	}
+	return rev, nil
+}
+
+func driveRevisionAuthor(r *drive.Revision) string {
+	if r.LastModifyingUser == nil {
+		return "-"
+	}
+	if r.LastModifyingUser.EmailAddress != "" {
+		return r.LastModifyingUser.EmailAddress
+	}
+	if r.LastModifyingUser.DisplayName != "" {
+		return r.LastModifyingUser.DisplayName
+	}
+	return "-"
+}
+
+var driveRevisionDownload = func(ctx context.Context, svc *drive.Service, fileID string, revisionID string) (*http.Response, error) {
+	return svc.Revisions.Get(fileID, revisionID).Context(ctx).Download()
+}
+
+// driveRevisionExport fetches one of a revision's exportLinks; Google-native
+// revisions cannot be exported through files.export.
+var driveRevisionExport = func(ctx context.Context, client *http.Client, link string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+func setupDriveRevisionsTest(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+
+	origNew := newDriveService
+	origClient := newDriveUploadClient
+	origHTTPClient := newDriveHTTPClient
+	t.Cleanup(func() {
+		newDriveService = origNew
+		newDriveUploadClient = origClient
+		newDriveHTTPClient = origHTTPClient
+	})
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	svc, err := drive.NewService(context.Background(),
+		option.WithoutAuthentication(),
+		option.WithHTTPClient(srv.Client()),
+		option.WithEndpoint(srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	newDriveService = func(context.Context, string) (*drive.Service, error) { return svc, nil }
+	newDriveUploadClient = func(context.Context, string) (*http.Client, error) { return srv.Client(), nil }
+	newDriveHTTPClient = func(context.Context, string) (*http.Client, error) { return srv.Client(), nil }
+	return srv.URL
+}
+
+func runDriveRevisionsJSON(t *testing.T, args []string) map[string]any {
+	t.Helper()
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := outfmt.WithMode(ui.WithUI(context.Background(), u), outfmt.Mode{JSON: true})
+
+	out := captureStdout(t, func() {
+		if execErr := runKong(t, &DriveRevisionsCmd{}, args, ctx, &RootFlags{Account: "a@b.com", Force: true}); execErr != nil {
+			t.Fatalf("revisions %v: %v", args, execErr)
+		}
+	})
+	var parsed map[string]any
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("json: %v\n%s", err, out)
+	}
+	return parsed
+}
+
+func TestDriveRevisionsList_JSON(t *testing.T) {
+	setupDriveRevisionsTest(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || !strings.HasSuffix(r.URL.Path, "/files/f1/revisions") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"revisions": []map[string]any{
+			{"id": "1", "modifiedTime": "2026-01-01T00:00:00Z"},
+			{"id": "2", "modifiedTime": "2026-01-02T00:00:00Z", "keepForever": true},
+		}})
+	})
+
+	parsed := runDriveRevisionsJSON(t, []string{"list", "f1"})
+	revs, _ := parsed["revisions"].([]any)
+	if parsed["fileId"] != "f1" || len(revs) != 2 {
+		t.Fatalf("unexpected json: %v", parsed)
+	}
+}
+
+func TestDriveRevisionsDownload_ExportsGoogleSheetRevision(t *testing.T) {
+	var baseURL string
+	baseURL = setupDriveRevisionsTest(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/files/s1"):
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "s1", "name": "Budget", "mimeType": driveMimeGoogleSheet})
+		case strings.HasSuffix(r.URL.Path, "/files/s1/revisions/7"):
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "7", "exportLinks": map[string]string{
+				mimeCSV: baseURL + "/export/7.csv",
+			}})
+		case r.URL.Path == "/export/7.csv":
+			_, _ = io.WriteString(w, "a,b\n1,2\n")
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	out := filepath.Join(t.TempDir(), "budget")
+	parsed := runDriveRevisionsJSON(t, []string{"download", "s1", "7", "--out", out})
+
+	if parsed["path"] != out+".csv" || parsed["revisionId"] != "7" {
+		t.Fatalf("unexpected json: %v", parsed)
+	}
+	data, err := os.ReadFile(out + ".csv")
+	if err != nil || string(data) != "a,b\n1,2\n" {
+		t.Fatalf("unexpected export content %q: %v", data, err)
+	}
+}
+
+func TestDriveRevisionsDownload_MissingExportFormat(t *testing.T) {
+	setupDriveRevisionsTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/files/d1"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "d1", "name": "Doc", "mimeType": driveMimeGoogleDoc})
+		case strings.HasSuffix(r.URL.Path, "/files/d1/revisions/3"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "3", "exportLinks": map[string]string{mimePDF: "http://unused"}})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := ui.WithUI(context.Background(), u)
+	args := []string{"download", "d1", "3", "--format", "txt", "--out", filepath.Join(t.TempDir(), "doc")}
+	err = runKong(t, &DriveRevisionsCmd{}, args, ctx, &RootFlags{Account: "a@b.com"})
+	if err == nil || !strings.Contains(err.Error(), "cannot be exported") {
+		t.Fatalf("expected export error, got %v", err)
+	}
+}
+
+func TestDriveRevisionsKeepAndDelete(t *testing.T) {
+	var patched map[string]any
+	deleted := false
+	setupDriveRevisionsTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPatch && strings.HasSuffix(r.URL.Path, "/files/f1/revisions/5"):
+			_ = json.NewDecoder(r.Body).Decode(&patched)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "5", "keepForever": patched["keepForever"]})
+		case r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/files/f1/revisions/5"):
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	_ = runDriveRevisionsJSON(t, []string{"keep", "f1", "5", "--off"})
+	if v, ok := patched["keepForever"]; !ok || v != false {
+		t.Fatalf("expected keepForever=false to be sent, got %v", patched)
+	}
+
+	parsed := runDriveRevisionsJSON(t, []string{"delete", "f1", "5"})
+	if !deleted || parsed["deleted"] != true {
+		t.Fatalf("expected delete, got %v", parsed)
+	}
+}
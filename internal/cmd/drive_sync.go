@@ -0,0 +1,627 @@
+package cmd
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // Drive exposes md5Checksum for change detection
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	gapi "google.golang.org/api/googleapi"
+
+	"github.com/steipete/gogcli/internal/config"
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+const (
+	driveSyncCreate = "create"
+	driveSyncUpdate = "update"
+	driveSyncSkip   = "skip"
+	driveSyncDelete = "delete"
+
+	driveSyncPush = "push"
+	driveSyncPull = "pull"
+)
+
+type DriveSyncCmd struct {
+	Push DriveSyncPushCmd `cmd:"" name:"push" help:"Mirror a local directory into a Drive folder"`
+	Pull DriveSyncPullCmd `cmd:"" name:"pull" help:"Mirror a Drive folder into a local directory"`
+}
+
+type DriveSyncPushCmd struct {
+	LocalDir string `arg:"" name:"localDir" help:"Local directory to upload"`
+	FolderID string `arg:"" name:"folderId" help:"Destination Drive folder ID or path"`
+	DryRun   bool   `name:"dry-run" help:"Print the sync plan without changing anything"`
+	Delete   bool   `name:"delete" help:"Trash Drive files that no longer exist locally"`
+}
+
+func (c *DriveSyncPushCmd) Run(ctx context.Context, flags *RootFlags) error {
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	root, folderID, err := normalizeDriveSyncArgs(c.LocalDir, c.FolderID)
+	if err != nil {
+		return err
+	}
+	if st, statErr := os.Stat(root); statErr != nil {
+		return statErr
+	} else if !st.IsDir() {
+		return usagef("%s is not a directory", root)
+	}
+
+	svc, err := newDriveService(ctx, account)
+	if err != nil {
+		return err
+	}
+	folderID, err = resolveDriveFileID(ctx, svc, folderID)
+	if err != nil {
+		return err
+	}
+
+	local, err := listDriveSyncLocal(root)
+	if err != nil {
+		return err
+	}
+	remote, err := walkDriveFolder(ctx, svc, folderID, 0)
+	if err != nil {
+		return err
+	}
+
+	actions, err := planDriveSyncPush(local, remote, c.Delete)
+	if err != nil {
+		return err
+	}
+	if !c.DryRun {
+		if err := confirmDriveSyncDeletes(ctx, flags, actions, "Drive folder "+folderID); err != nil {
+			return err
+		}
+		if err := applyDriveSyncPush(ctx, svc, folderID, remote, actions); err != nil {
+			return err
+		}
+	}
+	return writeDriveSyncResult(ctx, driveSyncPush, c.DryRun, actions)
+}
+
+type DriveSyncPullCmd struct {
+	FolderID string `arg:"" name:"folderId" help:"Drive folder ID or path to download"`
+	LocalDir string `arg:"" name:"localDir" help:"Local destination directory"`
+	DryRun   bool   `name:"dry-run" help:"Print the sync plan without changing anything"`
+	Delete   bool   `name:"delete" help:"Remove local files that no longer exist in Drive"`
+}
+
+func (c *DriveSyncPullCmd) Run(ctx context.Context, flags *RootFlags) error {
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	root, folderID, err := normalizeDriveSyncArgs(c.LocalDir, c.FolderID)
+	if err != nil {
+		return err
+	}
+	if st, statErr := os.Stat(root); statErr == nil && !st.IsDir() {
+		return usagef("%s is not a directory", root)
+	}
+
+	svc, err := newDriveService(ctx, account)
+	if err != nil {
+		return err
+	}
+	folderID, err = resolveDriveFileID(ctx, svc, folderID)
+	if err != nil {
+		return err
+	}
+
+	remote, err := walkDriveFolder(ctx, svc, folderID, 0)
+	if err != nil {
+		return err
+	}
+	local, err := listDriveSyncLocal(root)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	actions, err := planDriveSyncPull(remote, local, c.Delete)
+	if err != nil {
+		return err
+	}
+	if !c.DryRun {
+		if err := confirmDriveSyncDeletes(ctx, flags, actions, root); err != nil {
+			return err
+		}
+		if err := applyDriveSyncPull(ctx, svc, root, actions); err != nil {
+			return err
+		}
+	}
+	return writeDriveSyncResult(ctx, driveSyncPull, c.DryRun, actions)
+}
+
+// driveSyncAction is one planned (or performed) step of a sync.
+type driveSyncAction struct {
+	Action string `json:"action"`
+	Path   string `json:"path"`
+	Folder bool   `json:"folder,omitempty"`
+	Size   int64  `json:"size,omitempty"`
+	FileID string `json:"fileId,omitempty"`
+	Reason string `json:"reason,omitempty"`
+
+	local  *driveSyncLocalFile
+	remote *drive.File
+}
+
+type driveSyncLocalFile struct {
+	Path    string // slash-separated, relative to the sync root
+	AbsPath string
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
+}
+
+func normalizeDriveSyncArgs(localDir string, folderID string) (string, string, error) {
+	localDir = strings.TrimSpace(localDir)
+	if localDir == "" {
+		return "", "", usage("empty localDir")
+	}
+	folderID = strings.TrimSpace(folderID)
+	if folderID == "" {
+		return "", "", usage("empty folderId")
+	}
+	root, err := config.ExpandPath(localDir)
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Clean(root), folderID, nil
+}
+
+// listDriveSyncLocal returns regular files and directories below root in
+// lexical order (parents before children). Symlinks and other special files
+// are ignored.
+func listDriveSyncLocal(root string) ([]driveSyncLocalFile, error) {
+	var out []driveSyncLocalFile
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if p == root {
+			return nil
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry := driveSyncLocalFile{
+			Path:    filepath.ToSlash(rel),
+			AbsPath: p,
+			IsDir:   d.IsDir(),
+			ModTime: info.ModTime(),
+		}
+		if !entry.IsDir {
+			entry.Size = info.Size()
+		}
+		out = append(out, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func planDriveSyncPush(local []driveSyncLocalFile, remote []driveWalkEntry, withDelete bool) ([]driveSyncAction, error) {
+	// Key remote files by the path pull would write them to, so an exported
+	// Doc ("Report" -> "Report.docx") matches its local copy.
+	remoteByPath := make(map[string]*drive.File, len(remote))
+	deletable := make([]string, 0, len(remote))
+	for _, e := range remote {
+		p, ok := driveSyncLocalPath(e)
+		if !ok {
+			p = e.Path
+		}
+		if _, dup := remoteByPath[p]; dup {
+			continue
+		}
+		remoteByPath[p] = e.File
+		// Native files have no local source of truth; never trash them.
+		if e.IsFolder() || !isGoogleNativeMime(e.File.MimeType) {
+			deletable = append(deletable, p)
+		}
+	}
+
+	actions := make([]driveSyncAction, 0, len(local))
+	seen := make(map[string]struct{}, len(local))
+	for i := range local {
+		l := &local[i]
+		seen[l.Path] = struct{}{}
+		r := remoteByPath[l.Path]
+
+		switch {
+		case r == nil:
+			actions = append(actions, driveSyncAction{Action: driveSyncCreate, Path: l.Path, Folder: l.IsDir, Size: l.Size, local: l})
+		case l.IsDir != (r.MimeType == driveMimeFolder):
+			actions = append(actions, driveSyncAction{Action: driveSyncSkip, Path: l.Path, Folder: l.IsDir, FileID: r.Id, Reason: "type mismatch", local: l, remote: r})
+		case l.IsDir:
+			// Folder exists on both sides; nothing to do.
+		case isGoogleNativeMime(r.MimeType):
+			actions = append(actions, driveSyncAction{Action: driveSyncSkip, Path: l.Path, FileID: r.Id, Reason: "google-native file", local: l, remote: r})
+		default:
+			changed, reason, err := driveSyncChanged(l, r)
+			if err != nil {
+				return nil, err
+			}
+			action := driveSyncSkip
+			if changed {
+				action = driveSyncUpdate
+			}
+			actions = append(actions, driveSyncAction{Action: action, Path: l.Path, Size: l.Size, FileID: r.Id, Reason: reason, local: l, remote: r})
+		}
+	}
+
+	if withDelete {
+		actions = append(actions, planDriveSyncDeletes(deletable, seen, func(p string) driveSyncAction {
+			r := remoteByPath[p]
+			return driveSyncAction{Action: driveSyncDelete, Path: p, Folder: r.MimeType == driveMimeFolder, FileID: r.Id, remote: r}
+		})...)
+	}
+	return actions, nil
+}
+
+func planDriveSyncPull(remote []driveWalkEntry, local []driveSyncLocalFile, withDelete bool) ([]driveSyncAction, error) {
+	localByPath := make(map[string]*driveSyncLocalFile, len(local))
+	for i := range local {
+		localByPath[local[i].Path] = &local[i]
+	}
+
+	actions := make([]driveSyncAction, 0, len(remote))
+	seen := make(map[string]struct{}, len(remote))
+	for _, e := range remote {
+		r := e.File
+		localPath, ok := driveSyncLocalPath(e)
+		if !ok {
+			actions = append(actions, driveSyncAction{Action: driveSyncSkip, Path: e.Path, FileID: r.Id, Reason: "not exportable", remote: r})
+			continue
+		}
+		if _, dup := seen[localPath]; dup {
+			actions = append(actions, driveSyncAction{Action: driveSyncSkip, Path: localPath, FileID: r.Id, Reason: "duplicate name", remote: r})
+			continue
+		}
+		seen[localPath] = struct{}{}
+		l := localByPath[localPath]
+		isFolder := e.IsFolder()
+
+		switch {
+		case l == nil:
+			actions = append(actions, driveSyncAction{Action: driveSyncCreate, Path: localPath, Folder: isFolder, Size: r.Size, FileID: r.Id, remote: r})
+		case l.IsDir != isFolder:
+			actions = append(actions, driveSyncAction{Action: driveSyncSkip, Path: localPath, Folder: isFolder, FileID: r.Id, Reason: "type mismatch", local: l, remote: r})
+		case isFolder:
+			// Folder exists on both sides; nothing to do.
+		default:
+			changed, reason, err := driveSyncChanged(l, r)
+			if err != nil {
+				return nil, err
+			}
+			action := driveSyncSkip
+			if changed {
+				action = driveSyncUpdate
+			}
+			actions = append(actions, driveSyncAction{Action: action, Path: localPath, Size: r.Size, FileID: r.Id, Reason: reason, local: l, remote: r})
+		}
+	}
+
+	if withDelete {
+		paths := make([]string, 0, len(local))
+		for _, l := range local {
+			paths = append(paths, l.Path)
+		}
+		actions = append(actions, planDriveSyncDeletes(paths, seen, func(p string) driveSyncAction {
+			l := localByPath[p]
+			return driveSyncAction{Action: driveSyncDelete, Path: p, Folder: l.IsDir, Size: l.Size, local: l}
+		})...)
+	}
+	return actions, nil
+}
+
+// planDriveSyncDeletes returns delete actions for destination paths missing
+// from the source. Children of a deleted folder are covered by the folder.
+func planDriveSyncDeletes(destPaths []string, keep map[string]struct{}, build func(string) driveSyncAction) []driveSyncAction {
+	var out []driveSyncAction
+	deletedDirs := make([]string, 0)
+	done := make(map[string]struct{})
+	for _, p := range destPaths {
+		if _, ok := keep[p]; ok {
+			continue
+		}
+		if _, ok := done[p]; ok {
+			continue
+		}
+		done[p] = struct{}{}
+		covered := false
+		for _, d := range deletedDirs {
+			if strings.HasPrefix(p, d+"/") {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		a := build(p)
+		if a.Folder {
+			deletedDirs = append(deletedDirs, p)
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// driveSyncChanged compares a local file with its Drive counterpart. Binary
+// files are compared by size and md5Checksum; Google-native files (which have
+// no checksum) are compared by modifiedTime.
+func driveSyncChanged(l *driveSyncLocalFile, r *drive.File) (bool, string, error) {
+	if r.Md5Checksum != "" {
+		if l.Size != r.Size {
+			return true, "size differs", nil
+		}
+		sum, err := fileMD5(l.AbsPath)
+		if err != nil {
+			return false, "", err
+		}
+		if !strings.EqualFold(sum, r.Md5Checksum) {
+			return true, "md5 differs", nil
+		}
+		return false, "md5 match", nil
+	}
+
+	remoteTime, err := time.Parse(time.RFC3339, r.ModifiedTime)
+	if err != nil {
+		return true, "unknown modified time", nil //nolint:nilerr // re-transfer when Drive time is unparseable
+	}
+	if l.ModTime.Truncate(time.Second).Equal(remoteTime.Truncate(time.Second)) {
+		return false, "modified time match", nil
+	}
+	return true, "modified time differs", nil
+}
+
+// driveSyncLocalPath maps a Drive entry to its local relative path. Google-native
+// files get the extension of their default export format.
+func driveSyncLocalPath(e driveWalkEntry) (string, bool) {
+	mimeType := e.File.MimeType
+	if !isGoogleNativeMime(mimeType) || mimeType == driveMimeFolder {
+		return e.Path, true
+	}
+	switch mimeType {
+	case driveMimeGoogleDoc, driveMimeGoogleSheet, driveMimeGoogleSlides, driveMimeGoogleDrawing:
+		return replaceExt(e.Path, driveExportExtension(driveExportMimeType(mimeType))), true
+	default:
+		return "", false
+	}
+}
+
+func isGoogleNativeMime(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "application/vnd.google-apps.")
+}
+
+func fileMD5(p string) (string, error) {
+	f, err := os.Open(p) //nolint:gosec // path comes from walking the user-provided sync root
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New() //nolint:gosec // matches Drive md5Checksum
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func confirmDriveSyncDeletes(ctx context.Context, flags *RootFlags, actions []driveSyncAction, target string) error {
+	n := 0
+	for _, a := range actions {
+		if a.Action == driveSyncDelete {
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	return confirmDestructive(ctx, flags, fmt.Sprintf("delete %d item(s) from %s", n, target))
+}
+
+func applyDriveSyncPush(ctx context.Context, svc *drive.Service, rootID string, remote []driveWalkEntry, actions []driveSyncAction) error {
+	folderIDs := map[string]string{"": rootID}
+	for _, e := range remote {
+		if e.IsFolder() {
+			if _, ok := folderIDs[e.Path]; !ok {
+				folderIDs[e.Path] = e.File.Id
+			}
+		}
+	}
+	parentOf := func(p string) string {
+		dir := path.Dir(p)
+		if dir == "." {
+			dir = ""
+		}
+		return folderIDs[dir]
+	}
+
+	for i := range actions {
+		a := &actions[i]
+		switch a.Action {
+		case driveSyncCreate:
+			parent := parentOf(a.Path)
+			if parent == "" {
+				a.Action = driveSyncSkip
+				a.Reason = "parent folder not synced"
+				continue
+			}
+			if a.Folder {
+				created, err := createDriveFolder(ctx, svc, path.Base(a.Path), parent)
+				if err != nil {
+					return fmt.Errorf("create folder %s: %w", a.Path, err)
+				}
+				folderIDs[a.Path] = created.Id
+				a.FileID = created.Id
+				continue
+			}
+			id, err := uploadDriveSyncFile(ctx, svc, a.local, "", parent)
+			if err != nil {
+				return fmt.Errorf("upload %s: %w", a.Path, err)
+			}
+			a.FileID = id
+		case driveSyncUpdate:
+			if _, err := uploadDriveSyncFile(ctx, svc, a.local, a.FileID, ""); err != nil {
+				return fmt.Errorf("update %s: %w", a.Path, err)
+			}
+		case driveSyncDelete:
+			if _, err := svc.Files.Update(a.FileID, &drive.File{Trashed: true}).
+				SupportsAllDrives(true).
+				Fields("id").
+				Context(ctx).
+				Do(); err != nil {
+				return fmt.Errorf("trash %s: %w", a.Path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// uploadDriveSyncFile creates (fileID == "") or replaces the content of a Drive
+// file, carrying over the local modification time so later syncs can compare.
+func uploadDriveSyncFile(ctx context.Context, svc *drive.Service, l *driveSyncLocalFile, fileID string, parent string) (string, error) {
+	f, err := os.Open(l.AbsPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	meta := &drive.File{ModifiedTime: l.ModTime.UTC().Format(time.RFC3339Nano)}
+	media := gapi.ContentType(guessMimeType(l.AbsPath))
+	if fileID == "" {
+		meta.Name = path.Base(l.Path)
+		meta.Parents = []string{parent}
+		created, createErr := svc.Files.Create(meta).
+			SupportsAllDrives(true).
+			Media(f, media).
+			Fields("id").
+			Context(ctx).
+			Do()
+		if createErr != nil {
+			return "", createErr
+		}
+		return created.Id, nil
+	}
+	updated, err := svc.Files.Update(fileID, meta).
+		SupportsAllDrives(true).
+		Media(f, media).
+		Fields("id").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return "", err
+	}
+	return updated.Id, nil
+}
+
+func applyDriveSyncPull(ctx context.Context, svc *drive.Service, root string, actions []driveSyncAction) error {
+	if err := os.MkdirAll(root, 0o755); err != nil { //nolint:gosec // user-visible sync destination
+		return err
+	}
+	for i := range actions {
+		a := &actions[i]
+		dest := filepath.Join(root, filepath.FromSlash(a.Path))
+		switch a.Action {
+		case driveSyncCreate, driveSyncUpdate:
+			if a.Folder {
+				if err := os.MkdirAll(dest, 0o755); err != nil { //nolint:gosec // user-visible sync destination
+					return err
+				}
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil { //nolint:gosec // user-visible sync destination
+				return err
+			}
+			outPath, _, err := downloadDriveFile(ctx, svc, a.remote, dest, "")
+			if err != nil {
+				return fmt.Errorf("download %s: %w", a.Path, err)
+			}
+			if mt, parseErr := time.Parse(time.RFC3339, a.remote.ModifiedTime); parseErr == nil {
+				if err := os.Chtimes(outPath, mt, mt); err != nil {
+					return err
+				}
+			}
+		case driveSyncDelete:
+			if err := os.RemoveAll(dest); err != nil {
+				return fmt.Errorf("delete %s: %w", a.Path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func writeDriveSyncResult(ctx context.Context, direction string, dryRun bool, actions []driveSyncAction) error {
+	groups := map[string][]driveSyncAction{
+		driveSyncCreate: {},
+		driveSyncUpdate: {},
+		driveSyncSkip:   {},
+		driveSyncDelete: {},
+	}
+	for _, a := range actions {
+		groups[a.Action] = append(groups[a.Action], a)
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{
+			"direction": direction,
+			"dryRun":    dryRun,
+			"created":   groups[driveSyncCreate],
+			"updated":   groups[driveSyncUpdate],
+			"skipped":   groups[driveSyncSkip],
+			"deleted":   groups[driveSyncDelete],
+		})
+	}
+
+	u := ui.FromContext(ctx)
+	changes := len(actions) - len(groups[driveSyncSkip])
+	if changes > 0 {
+		w, flush := tableWriter(ctx)
+		fmt.Fprintln(w, "ACTION\tPATH\tSIZE")
+		for _, a := range actions {
+			if a.Action == driveSyncSkip {
+				continue
+			}
+			p := a.Path
+			if a.Folder {
+				p += "/"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", a.Action, p, formatDriveSize(a.Size))
+		}
+		flush()
+	} else {
+		u.Err().Println("Already in sync")
+	}
+
+	if dryRun {
+		u.Out().Printf("dry_run\ttrue")
+	}
+	u.Out().Printf("created\t%d", len(groups[driveSyncCreate]))
+	u.Out().Printf("updated\t%d", len(groups[driveSyncUpdate]))
+	u.Out().Printf("skipped\t%d", len(groups[driveSyncSkip]))
+	u.Out().Printf("deleted\t%d", len(groups[driveSyncDelete]))
+	return nil
+}
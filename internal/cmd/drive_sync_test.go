@@ -0,0 +1,397 @@
+package cmd
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // test fixture checksums
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+var driveParentQueryRe = regexp.MustCompile(`'([^']+)' in parents`)
+
+// newDriveFolderTestService serves files.list from a parent->children map and
+// hands every other request to extra. It returns the request log.
+func newDriveFolderTestService(t *testing.T, children map[string][]map[string]any, extra http.HandlerFunc) (*drive.Service, *[]string) {
+	t.Helper()
+
+	var (
+		mu  sync.Mutex
+		log []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		log = append(log, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+
+		if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/files") {
+			m := driveParentQueryRe.FindStringSubmatch(r.URL.Query().Get("q"))
+			if m != nil {
+				files := children[m[1]]
+				if files == nil {
+					files = []map[string]any{}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(map[string]any{"files": files})
+				return
+			}
+		}
+		if extra != nil {
+			extra(w, r)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	svc, err := drive.NewService(context.Background(),
+		option.WithoutAuthentication(),
+		option.WithHTTPClient(srv.Client()),
+		option.WithEndpoint(srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	return svc, &log
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s)) //nolint:gosec // test fixture checksums
+	return hex.EncodeToString(sum[:])
+}
+
+func TestPlanDriveSyncPush(t *testing.T) {
+	root := t.TempDir()
+	mustWrite := func(rel, body string) {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	mustWrite("same.txt", "hello")
+	mustWrite("changed.txt", "new!!")
+	mustWrite("new/inner.txt", "x")
+
+	local, err := listDriveSyncLocal(root)
+	if err != nil {
+		t.Fatalf("listDriveSyncLocal: %v", err)
+	}
+	remote := []driveWalkEntry{
+		{Path: "changed.txt", File: &drive.File{Id: "c1", Name: "changed.txt", Size: 5, Md5Checksum: md5Hex("old!!")}},
+		{Path: "gone", File: &drive.File{Id: "g1", Name: "gone", MimeType: driveMimeFolder}},
+		{Path: "gone/child.txt", File: &drive.File{Id: "g2", Name: "child.txt"}},
+		{Path: "same.txt", File: &drive.File{Id: "s1", Name: "same.txt", Size: 5, Md5Checksum: md5Hex("hello")}},
+	}
+
+	actions, err := planDriveSyncPush(local, remote, true)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	got := make([]string, 0, len(actions))
+	for _, a := range actions {
+		got = append(got, a.Action+":"+a.Path)
+	}
+	want := []string{
+		"update:changed.txt",
+		"create:new",
+		"create:new/inner.txt",
+		"skip:same.txt",
+		"delete:gone",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected plan:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestPlanDriveSyncPush_AfterPull(t *testing.T) {
+	remote := []driveWalkEntry{
+		{Path: "Report", File: &drive.File{Id: "d1", Name: "Report", MimeType: driveMimeGoogleDoc, ModifiedTime: "2024-01-02T03:04:05Z"}},
+		{Path: "Form", File: &drive.File{Id: "f1", Name: "Form", MimeType: "application/vnd.google-apps.form"}},
+		{Path: "docs", File: &drive.File{Id: "dir1", Name: "docs", MimeType: driveMimeFolder}},
+		{Path: "docs/a.txt", File: &drive.File{Id: "a1", Name: "a.txt", Size: 1, Md5Checksum: md5Hex("a")}},
+	}
+
+	// Pull into an empty directory, then push it back with --delete.
+	root := t.TempDir()
+	pulled, err := planDriveSyncPull(remote, nil, false)
+	if err != nil {
+		t.Fatalf("plan pull: %v", err)
+	}
+	for _, a := range pulled {
+		if a.Action != driveSyncCreate {
+			continue
+		}
+		p := filepath.Join(root, filepath.FromSlash(a.Path))
+		if a.Folder {
+			if err := os.MkdirAll(p, 0o700); err != nil {
+				t.Fatalf("mkdir: %v", err)
+			}
+			continue
+		}
+		if err := os.WriteFile(p, []byte("a"), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	local, err := listDriveSyncLocal(root)
+	if err != nil {
+		t.Fatalf("listDriveSyncLocal: %v", err)
+	}
+
+	actions, err := planDriveSyncPush(local, remote, true)
+	if err != nil {
+		t.Fatalf("plan push: %v", err)
+	}
+	got := make([]string, 0, len(actions))
+	for _, a := range actions {
+		got = append(got, a.Action+":"+a.Path+":"+a.Reason)
+	}
+	want := []string{
+		"skip:Report.pdf:google-native file",
+		"skip:docs/a.txt:md5 match",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected plan:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestDriveSyncChanged_ModifiedTime(t *testing.T) {
+	mt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	l := &driveSyncLocalFile{ModTime: mt.Add(300 * time.Millisecond)}
+
+	changed, _, err := driveSyncChanged(l, &drive.File{ModifiedTime: "2025-01-02T03:04:05.000Z"})
+	if err != nil || changed {
+		t.Fatalf("expected unchanged, got changed=%v err=%v", changed, err)
+	}
+	changed, _, err = driveSyncChanged(l, &drive.File{ModifiedTime: "2025-01-02T03:09:05.000Z"})
+	if err != nil || !changed {
+		t.Fatalf("expected changed, got changed=%v err=%v", changed, err)
+	}
+}
+
+func TestDriveSyncLocalPath(t *testing.T) {
+	cases := []struct {
+		entry driveWalkEntry
+		want  string
+		ok    bool
+	}{
+		{driveWalkEntry{Path: "a/b.bin", File: &drive.File{MimeType: "application/octet-stream"}}, "a/b.bin", true},
+		{driveWalkEntry{Path: "Budget", File: &drive.File{MimeType: driveMimeGoogleSheet}}, "Budget.csv", true},
+		{driveWalkEntry{Path: "dir", File: &drive.File{MimeType: driveMimeFolder}}, "dir", true},
+		{driveWalkEntry{Path: "Form", File: &drive.File{MimeType: "application/vnd.google-apps.form"}}, "", false},
+	}
+	for _, tc := range cases {
+		got, ok := driveSyncLocalPath(tc.entry)
+		if got != tc.want || ok != tc.ok {
+			t.Fatalf("driveSyncLocalPath(%q) = %q,%v; want %q,%v", tc.entry.Path, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestDriveSafeName(t *testing.T) {
+	for in, want := range map[string]string{"a/b": "a_b", "..": "_", "": "_", "ok.txt": "ok.txt"} {
+		if got := driveSafeName(in); got != want {
+			t.Fatalf("driveSafeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDriveSyncPullCmd_JSON(t *testing.T) {
+	origNew := newDriveService
+	origDownload := driveDownload
+	origExport := driveExportDownload
+	t.Cleanup(func() {
+		newDriveService = origNew
+		driveDownload = origDownload
+		driveExportDownload = origExport
+	})
+
+	children := map[string][]map[string]any{
+		"root1": {
+			{"id": "f1", "name": "a.txt", "mimeType": "text/plain", "size": "5", "md5Checksum": md5Hex("hello"), "modifiedTime": "2025-01-01T00:00:00Z"},
+			{"id": "d1", "name": "sub", "mimeType": driveMimeFolder},
+		},
+		"d1": {
+			{"id": "doc1", "name": "Notes", "mimeType": driveMimeGoogleDoc, "modifiedTime": "2025-02-01T00:00:00Z"},
+		},
+	}
+	svc, _ := newDriveFolderTestService(t, children, nil)
+	newDriveService = func(context.Context, string) (*drive.Service, error) { return svc, nil }
+
+	driveDownload = func(_ context.Context, _ *drive.Service, fileID string) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("hello"))}, nil
+	}
+	driveExportDownload = func(_ context.Context, _ *drive.Service, fileID string, mimeType string) (*http.Response, error) {
+		if fileID != "doc1" || mimeType != mimePDF {
+			t.Fatalf("unexpected export %s %s", fileID, mimeType)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("%PDF"))}, nil
+	}
+
+	dest := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dest, "stale.txt"), []byte("old"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := ui.WithUI(context.Background(), u)
+	ctx = outfmt.WithMode(ctx, outfmt.Mode{JSON: true})
+	flags := &RootFlags{Account: "a@b.com", Force: true}
+
+	out := captureStdout(t, func() {
+		if execErr := runKong(t, &DriveSyncCmd{}, []string{"pull", "root1", dest, "--delete"}, ctx, flags); execErr != nil {
+			t.Fatalf("pull: %v", execErr)
+		}
+	})
+
+	var parsed struct {
+		Created []driveSyncAction `json:"created"`
+		Deleted []driveSyncAction `json:"deleted"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("json: %v\n%s", err, out)
+	}
+	if len(parsed.Created) != 3 || len(parsed.Deleted) != 1 || parsed.Deleted[0].Path != "stale.txt" {
+		t.Fatalf("unexpected summary: %s", out)
+	}
+	if b, readErr := os.ReadFile(filepath.Join(dest, "sub", "Notes.pdf")); readErr != nil || string(b) != "%PDF" {
+		t.Fatalf("exported doc missing: %v %q", readErr, b)
+	}
+	if _, statErr := os.Stat(filepath.Join(dest, "stale.txt")); !os.IsNotExist(statErr) {
+		t.Fatalf("expected stale.txt removed, got %v", statErr)
+	}
+	st, err := os.Stat(filepath.Join(dest, "a.txt"))
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if !st.ModTime().UTC().Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected mtime from Drive, got %v", st.ModTime())
+	}
+
+	// Second run: everything matches, nothing is transferred.
+	out = captureStdout(t, func() {
+		if execErr := runKong(t, &DriveSyncCmd{}, []string{"pull", "root1", dest}, ctx, flags); execErr != nil {
+			t.Fatalf("pull: %v", execErr)
+		}
+	})
+	var second struct {
+		Created []driveSyncAction `json:"created"`
+		Updated []driveSyncAction `json:"updated"`
+		Skipped []driveSyncAction `json:"skipped"`
+	}
+	if err := json.Unmarshal([]byte(out), &second); err != nil {
+		t.Fatalf("json: %v\n%s", err, out)
+	}
+	if len(second.Created) != 0 || len(second.Updated) != 0 || len(second.Skipped) != 2 {
+		t.Fatalf("expected only skips on second run: %s", out)
+	}
+}
+
+func TestDriveSyncPushCmd_DryRunAndApply(t *testing.T) {
+	origNew := newDriveService
+	t.Cleanup(func() { newDriveService = origNew })
+
+	children := map[string][]map[string]any{
+		"root1": {
+			{"id": "f1", "name": "same.txt", "mimeType": "text/plain", "size": "5", "md5Checksum": md5Hex("hello")},
+			{"id": "old1", "name": "old.txt", "mimeType": "text/plain", "size": "1", "md5Checksum": md5Hex("x")},
+		},
+	}
+	var created []string
+	svc, log := newDriveFolderTestService(t, children, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/upload/"):
+			created = append(created, "upload")
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "new-file"})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/files"):
+			var body drive.File
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			created = append(created, "folder:"+body.Name+":"+strings.Join(body.Parents, ","))
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "new-folder"})
+		case r.Method == http.MethodPatch && strings.HasSuffix(r.URL.Path, "/files/old1"):
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["trashed"] != true {
+				t.Errorf("expected trash update, got %v", body)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "old1"})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	newDriveService = func(context.Context, string) (*drive.Service, error) { return svc, nil }
+
+	src := t.TempDir()
+	for rel, body := range map[string]string{"same.txt": "hello", "sub/new.txt": "new"} {
+		p := filepath.Join(src, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	flags := &RootFlags{Account: "a@b.com", Force: true}
+	newCtx := func() context.Context {
+		u, uiErr := ui.New(ui.Options{Stdout: os.Stdout, Stderr: io.Discard, Color: "never"})
+		if uiErr != nil {
+			t.Fatalf("ui.New: %v", uiErr)
+		}
+		return outfmt.WithMode(ui.WithUI(context.Background(), u), outfmt.Mode{Plain: true})
+	}
+
+	dryOut := captureStdout(t, func() {
+		ctx := newCtx()
+		if execErr := runKong(t, &DriveSyncCmd{}, []string{"push", src, "root1", "--delete", "--dry-run"}, ctx, flags); execErr != nil {
+			t.Fatalf("push dry-run: %v", execErr)
+		}
+	})
+	if len(created) != 0 {
+		t.Fatalf("dry-run must not write, got %v", created)
+	}
+	for _, want := range []string{"create\tsub/\t", "create\tsub/new.txt\t", "delete\told.txt\t", "dry_run\ttrue", "skipped\t1"} {
+		if !strings.Contains(dryOut, want) {
+			t.Fatalf("missing %q in dry-run output:\n%s", want, dryOut)
+		}
+	}
+
+	_ = captureStdout(t, func() {
+		if execErr := runKong(t, &DriveSyncCmd{}, []string{"push", src, "root1", "--delete"}, newCtx(), flags); execErr != nil {
+			t.Fatalf("push: %v", execErr)
+		}
+	})
+	if strings.Join(created, ";") != "folder:sub:root1;upload" {
+		t.Fatalf("unexpected writes: %v", created)
+	}
+	trashed := false
+	for _, entry := range *log {
+		if strings.HasPrefix(entry, "PATCH ") && strings.HasSuffix(entry, "/files/old1") {
+			trashed = true
+		}
+	}
+	if !trashed {
+		t.Fatalf("expected old.txt to be trashed, log=%v", *log)
+	}
+}
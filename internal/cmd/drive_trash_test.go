@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+func TestParseDriveTrashSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"":                     {},
+		"2h":                   now.Add(-2 * time.Hour),
+		"3d":                   now.AddDate(0, 0, -3),
+		"2026-03-01T00:00:00Z": time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for in, want := range cases {
+		got, err := parseDriveTrashSince(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Fatalf("parseDriveTrashSince(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseDriveTrashSince("yesterday-ish", now); err == nil {
+		t.Fatalf("expected error for invalid --since")
+	}
+}
+
+// newDriveTrashTestServer serves a trash listing and records PATCH/DELETE
+// requests by file ID.
+func newDriveTrashTestServer(t *testing.T) *[]string {
+	t.Helper()
+
+	recent := time.Now().Add(-30 * time.Minute).UTC().Format(time.RFC3339)
+	old := time.Now().Add(-72 * time.Hour).UTC().Format(time.RFC3339)
+
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	setupDriveTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/files"):
+			if r.URL.Query().Get("q") != "trashed = true" {
+				t.Errorf("unexpected query: %q", r.URL.Query().Get("q"))
+			}
+			// The default "user" corpus misses shared drive items.
+			if corpora, driveID := r.URL.Query().Get("corpora"), r.URL.Query().Get("driveId"); corpora != "allDrives" && (corpora != "drive" || driveID != "0AD") {
+				t.Errorf("unexpected corpora %q (driveId %q)", corpora, driveID)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"files": []map[string]any{
+				{"id": "new1", "name": "report.pdf", "explicitlyTrashed": true, "trashedTime": recent, "modifiedTime": old},
+				{"id": "mine", "name": "notes.txt", "explicitlyTrashed": true, "modifiedTime": recent},
+				{"id": "child", "name": "inside.txt", "explicitlyTrashed": false, "trashedTime": recent},
+				{"id": "old1", "name": "ancient.txt", "explicitlyTrashed": true, "trashedTime": old, "modifiedTime": recent},
+			}})
+		case r.Method == http.MethodPatch:
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			calls = append(calls, "PATCH "+id+" trashed="+jsonString(body["trashed"]))
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "name": id + ".name"})
+		case r.Method == http.MethodDelete:
+			mu.Lock()
+			calls = append(calls, "DELETE "+id)
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	return &calls
+}
+
+func jsonString(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func TestDriveTrashRestore_Since(t *testing.T) {
+	calls := newDriveTrashTestServer(t)
+
+	parsed := runDriveCmdJSON(t, &DriveTrashCmd{}, []string{"restore", "--since", "2h"})
+	if strings.Join(*calls, ";") != "PATCH new1 trashed=false" {
+		t.Fatalf("unexpected calls: %v", *calls)
+	}
+	if parsed["restored"] != float64(1) || parsed["failed"] != float64(0) {
+		t.Fatalf("unexpected json: %v", parsed)
+	}
+	// My Drive items have no trashedTime; modifiedTime says nothing about
+	// when they were trashed, so they are reported instead of restored.
+	if skipped, _ := parsed["skipped"].([]any); len(skipped) != 1 || skipped[0].(map[string]any)["id"] != "mine" {
+		t.Fatalf("expected mine to be skipped, got %v", parsed["skipped"])
+	}
+}
+
+func TestDriveTrashSince_NothingMatched(t *testing.T) {
+	calls := newDriveTrashTestServer(t)
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := outfmt.WithMode(ui.WithUI(context.Background(), u), outfmt.Mode{JSON: true})
+	// Only "mine" (no trashedTime) could have been trashed in the last minute.
+	for _, args := range [][]string{
+		{"list", "--since", "1m"},
+		{"restore", "--since", "1m", "--drive", "0AD"},
+	} {
+		var runErr error
+		_ = captureStdout(t, func() {
+			runErr = runKong(t, &DriveTrashCmd{}, args, ctx, &RootFlags{Account: "a@b.com", Force: true})
+		})
+		if runErr == nil || !strings.Contains(runErr.Error(), "--since matched nothing") {
+			t.Fatalf("%v: expected an error, got %v", args, runErr)
+		}
+	}
+	if len(*calls) != 0 {
+		t.Fatalf("unexpected writes: %v", *calls)
+	}
+}
+
+func TestDriveTrashRestore_DryRunAndIDs(t *testing.T) {
+	calls := newDriveTrashTestServer(t)
+
+	parsed := runDriveCmdJSON(t, &DriveTrashCmd{}, []string{"restore", "x1", "--since", "7d", "--dry-run"})
+	if len(*calls) != 0 {
+		t.Fatalf("dry-run must not write: %v", *calls)
+	}
+	files, _ := parsed["files"].([]any)
+	if len(files) != 3 {
+		t.Fatalf("expected x1 + two trashed files, got %v", parsed)
+	}
+}
+
+func TestDriveTrashEmptyAndPermanentDelete(t *testing.T) {
+	calls := newDriveTrashTestServer(t)
+
+	_ = runDriveCmdJSON(t, &DriveTrashCmd{}, []string{"empty"})
+	_ = runDriveCmdJSON(t, &DriveDeleteCmd{}, []string{"f1"})
+	parsed := runDriveCmdJSON(t, &DriveDeleteCmd{}, []string{"f2", "--permanent"})
+
+	want := "DELETE trash;PATCH f1 trashed=true;DELETE f2"
+	if strings.Join(*calls, ";") != want {
+		t.Fatalf("unexpected calls:\n got %v\nwant %s", *calls, want)
+	}
+	if parsed["permanent"] != true || parsed["id"] != "f2" {
+		t.Fatalf("unexpected json: %v", parsed)
+	}
+}
@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+type DriveTreeCmd struct {
+	FolderID string `arg:"" name:"folderId" optional:"" help:"Folder ID or path (default: root)"`
+	Depth    int    `name:"depth" help:"Max depth to descend (0 = unlimited)" default:"3"`
+}
+
+// driveTreeNode is one file or folder in the nested `drive tree` output.
+type driveTreeNode struct {
+	ID           string           `json:"id"`
+	Name         string           `json:"name"`
+	MimeType     string           `json:"mimeType"`
+	Size         int64            `json:"size,omitempty"`
+	ModifiedTime string           `json:"modifiedTime,omitempty"`
+	Children     []*driveTreeNode `json:"children,omitempty"`
+}
+
+func (n *driveTreeNode) isFolder() bool {
+	return n.MimeType == driveMimeFolder
+}
+
+func (c *DriveTreeCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	if c.Depth < 0 {
+		return usage("--depth must be >= 0")
+	}
+
+	svc, err := newDriveService(ctx, account)
+	if err != nil {
+		return err
+	}
+	root, err := getDriveFolder(ctx, svc, c.FolderID)
+	if err != nil {
+		return err
+	}
+
+	entries, err := walkDriveFolder(ctx, svc, root.Id, c.Depth)
+	if err != nil {
+		return err
+	}
+	tree := buildDriveTree(root, entries)
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"tree": tree})
+	}
+
+	u.Out().Printf("%s/", tree.Name)
+	printDriveTree(u, tree.Children, "")
+	return nil
+}
+
+// getDriveFolder resolves ref (ID, path, or "" for My Drive) and checks that
+// it is a folder.
+func getDriveFolder(ctx context.Context, svc *drive.Service, ref string) (*drive.File, error) {
+	folderID := strings.TrimSpace(ref)
+	if folderID == "" {
+		folderID = "root"
+	}
+	folderID, err := resolveDriveFileID(ctx, svc, folderID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := svc.Files.Get(folderID).
+		SupportsAllDrives(true).
+		Fields("id, name, mimeType").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+	if f.MimeType != driveMimeFolder {
+		return nil, usagef("%s is not a folder (mimeType=%q)", ref, f.MimeType)
+	}
+	return f, nil
+}
+
+// buildDriveTree nests walk entries under root. walkDriveFolder emits entries
+// depth-first, so the parent of an entry at depth d is the last folder seen at
+// depth d-1.
+func buildDriveTree(root *drive.File, entries []driveWalkEntry) *driveTreeNode {
+	top := &driveTreeNode{ID: root.Id, Name: root.Name, MimeType: driveMimeFolder}
+	stack := []*driveTreeNode{top}
+	for _, e := range entries {
+		f := e.File
+		node := &driveTreeNode{ID: f.Id, Name: f.Name, MimeType: f.MimeType, Size: f.Size, ModifiedTime: f.ModifiedTime}
+		if e.Depth > len(stack) {
+			continue
+		}
+		stack = stack[:e.Depth]
+		parent := stack[e.Depth-1]
+		parent.Children = append(parent.Children, node)
+		if node.isFolder() {
+			stack = append(stack, node)
+		}
+	}
+	return top
+}
+
+func printDriveTree(u *ui.UI, nodes []*driveTreeNode, prefix string) {
+	for i, n := range nodes {
+		branch, next := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, next = "└── ", "    "
+		}
+		if n.isFolder() {
+			u.Out().Printf("%s%s%s/", prefix, branch, n.Name)
+			printDriveTree(u, n.Children, prefix+next)
+			continue
+		}
+		if n.Size > 0 {
+			u.Out().Printf("%s%s%s (%s)", prefix, branch, n.Name, formatDriveSize(n.Size))
+			continue
+		}
+		u.Out().Printf("%s%s%s", prefix, branch, n.Name)
+	}
+}
+
+type DriveDuCmd struct {
+	FolderID string `arg:"" name:"folderId" optional:"" help:"Folder ID or path (default: root)"`
+	Depth    int    `name:"depth" help:"Report subfolders down to this depth" default:"1"`
+	Sort     string `name:"sort" help:"Sort rows by: size|name" default:"size" enum:"size,name"`
+}
+
+type driveDuFolder struct {
+	ID      string `json:"id"`
+	Path    string `json:"path"`
+	Depth   int    `json:"depth"`
+	Size    int64  `json:"size"`
+	Files   int    `json:"files"`
+	Folders int    `json:"folders"`
+}
+
+type driveQuota struct {
+	Limit      int64 `json:"limit,omitempty"`
+	Usage      int64 `json:"usage"`
+	UsageDrive int64 `json:"usageInDrive"`
+	UsageTrash int64 `json:"usageInDriveTrash"`
+}
+
+func (c *DriveDuCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	if c.Depth < 0 {
+		return usage("--depth must be >= 0")
+	}
+
+	svc, err := newDriveService(ctx, account)
+	if err != nil {
+		return err
+	}
+	root, err := getDriveFolder(ctx, svc, c.FolderID)
+	if err != nil {
+		return err
+	}
+
+	entries, err := walkDriveFolder(ctx, svc, root.Id, 0)
+	if err != nil {
+		return err
+	}
+	total, folders := sumDriveFolderSizes(root, entries, c.Depth)
+	sortDriveDuFolders(folders, c.Sort)
+
+	about, err := svc.About.Get().Fields("storageQuota").Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+	var quota driveQuota
+	if q := about.StorageQuota; q != nil {
+		quota = driveQuota{Limit: q.Limit, Usage: q.Usage, UsageDrive: q.UsageInDrive, UsageTrash: q.UsageInDriveTrash}
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{
+			"folder":  total,
+			"folders": folders,
+			"quota":   quota,
+		})
+	}
+
+	w, flush := tableWriter(ctx)
+	fmt.Fprintln(w, "SIZE\tFILES\tPATH")
+	for _, f := range folders {
+		fmt.Fprintf(w, "%s\t%d\t%s/\n", formatDriveSize(f.Size), f.Files, f.Path)
+	}
+	fmt.Fprintf(w, "%s\t%d\t%s\n", formatDriveSize(total.Size), total.Files, "TOTAL")
+	flush()
+
+	limit := "unlimited"
+	if quota.Limit > 0 {
+		limit = formatDriveSize(quota.Limit)
+	}
+	u.Out().Printf("quota_used\t%s", formatDriveSize(quota.Usage))
+	u.Out().Printf("quota_limit\t%s", limit)
+	u.Out().Printf("quota_drive\t%s", formatDriveSize(quota.UsageDrive))
+	u.Out().Printf("quota_trash\t%s", formatDriveSize(quota.UsageTrash))
+	return nil
+}
+
+// sumDriveFolderSizes totals file sizes into every ancestor folder and returns
+// the root total plus the subfolders at or above maxDepth.
+func sumDriveFolderSizes(root *drive.File, entries []driveWalkEntry, maxDepth int) (driveDuFolder, []driveDuFolder) {
+	total := driveDuFolder{ID: root.Id, Path: root.Name}
+	var (
+		folders []*driveDuFolder
+		stack   []*driveDuFolder // open folders, indexed by depth-1
+	)
+	for _, e := range entries {
+		if e.Depth-1 > len(stack) {
+			continue
+		}
+		stack = stack[:e.Depth-1]
+
+		if e.IsFolder() {
+			total.Folders++
+			for _, anc := range stack {
+				anc.Folders++
+			}
+			df := &driveDuFolder{ID: e.File.Id, Path: e.Path, Depth: e.Depth}
+			stack = append(stack, df)
+			folders = append(folders, df)
+			continue
+		}
+
+		size := driveFileBytes(e.File)
+		total.Size += size
+		total.Files++
+		for _, anc := range stack {
+			anc.Size += size
+			anc.Files++
+		}
+	}
+
+	out := make([]driveDuFolder, 0, len(folders))
+	for _, f := range folders {
+		if f.Depth <= maxDepth {
+			out = append(out, *f)
+		}
+	}
+	return total, out
+}
+
+// driveFileBytes prefers quotaBytesUsed, which also covers Google-native files
+// that report no size.
+func driveFileBytes(f *drive.File) int64 {
+	if f.QuotaBytesUsed > 0 {
+		return f.QuotaBytesUsed
+	}
+	return f.Size
+}
+
+func sortDriveDuFolders(folders []driveDuFolder, by string) {
+	sort.SliceStable(folders, func(i, j int) bool {
+		if by == "name" || folders[i].Size == folders[j].Size {
+			return folders[i].Path < folders[j].Path
+		}
+		return folders[i].Size > folders[j].Size
+	})
+}
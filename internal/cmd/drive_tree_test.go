@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+func setupDriveTreeTest(t *testing.T) {
+	t.Helper()
+
+	origNew := newDriveService
+	t.Cleanup(func() { newDriveService = origNew })
+
+	children := map[string][]map[string]any{
+		"top": {
+			{"id": "a", "name": "Archive", "mimeType": driveMimeFolder},
+			{"id": "r", "name": "readme.txt", "mimeType": "text/plain", "size": "100"},
+			{"id": "v", "name": "Videos", "mimeType": driveMimeFolder},
+		},
+		"a": {
+			{"id": "a1", "name": "old.zip", "mimeType": "application/zip", "size": "2048"},
+			{"id": "a2", "name": "Deep", "mimeType": driveMimeFolder},
+		},
+		"a2": {
+			{"id": "a21", "name": "notes", "mimeType": "application/vnd.google-apps.document", "quotaBytesUsed": "512"},
+		},
+		"v": {
+			{"id": "v1", "name": "clip.mp4", "mimeType": "video/mp4", "size": "10240"},
+		},
+	}
+	svc, _ := newDriveFolderTestService(t, children, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/files/top"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "top", "name": "Team", "mimeType": driveMimeFolder})
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/about"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"storageQuota": map[string]any{
+				"limit": "1073741824", "usage": "524288000", "usageInDrive": "500000000", "usageInDriveTrash": "1000",
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	newDriveService = func(context.Context, string) (*drive.Service, error) { return svc, nil }
+}
+
+func runDriveTreeCmd(t *testing.T, cmd any, args []string, mode outfmt.Mode) string {
+	t.Helper()
+
+	return captureStdout(t, func() {
+		u, err := ui.New(ui.Options{Stdout: os.Stdout, Stderr: io.Discard, Color: "never"})
+		if err != nil {
+			t.Fatalf("ui.New: %v", err)
+		}
+		ctx := outfmt.WithMode(ui.WithUI(context.Background(), u), mode)
+		if execErr := runKong(t, cmd, args, ctx, &RootFlags{Account: "a@b.com"}); execErr != nil {
+			t.Fatalf("run: %v", execErr)
+		}
+	})
+}
+
+func TestDriveTreeCmd_Text(t *testing.T) {
+	setupDriveTreeTest(t)
+
+	out := runDriveTreeCmd(t, &DriveTreeCmd{}, []string{"top", "--depth", "2"}, outfmt.Mode{})
+	want := strings.Join([]string{
+		"Team/",
+		"├── Archive/",
+		"│   ├── Deep/",
+		"│   └── old.zip (2.0 KB)",
+		"├── Videos/",
+		"│   └── clip.mp4 (10.0 KB)",
+		"└── readme.txt (100 B)",
+		"",
+	}, "\n")
+	if out != want {
+		t.Fatalf("unexpected tree:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestDriveTreeCmd_JSON(t *testing.T) {
+	setupDriveTreeTest(t)
+
+	out := runDriveTreeCmd(t, &DriveTreeCmd{}, []string{"top", "--depth", "0"}, outfmt.Mode{JSON: true})
+	var parsed struct {
+		Tree driveTreeNode `json:"tree"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("json: %v\n%s", err, out)
+	}
+	if len(parsed.Tree.Children) != 3 {
+		t.Fatalf("unexpected root children: %+v", parsed.Tree)
+	}
+	deep := parsed.Tree.Children[0].Children[0]
+	if deep.Name != "Deep" || len(deep.Children) != 1 || deep.Children[0].ID != "a21" {
+		t.Fatalf("unexpected nesting: %+v", parsed.Tree.Children[0])
+	}
+}
+
+func TestDriveDuCmd_JSON(t *testing.T) {
+	setupDriveTreeTest(t)
+
+	out := runDriveTreeCmd(t, &DriveDuCmd{}, []string{"top"}, outfmt.Mode{JSON: true})
+	var parsed struct {
+		Folder  driveDuFolder   `json:"folder"`
+		Folders []driveDuFolder `json:"folders"`
+		Quota   driveQuota      `json:"quota"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("json: %v\n%s", err, out)
+	}
+	if parsed.Folder.Size != 100+2048+512+10240 || parsed.Folder.Files != 4 || parsed.Folder.Folders != 3 {
+		t.Fatalf("unexpected total: %+v", parsed.Folder)
+	}
+	if len(parsed.Folders) != 2 || parsed.Folders[0].Path != "Videos" || parsed.Folders[1].Path != "Archive" {
+		t.Fatalf("expected depth-1 folders sorted by size, got %+v", parsed.Folders)
+	}
+	if parsed.Folders[1].Size != 2048+512 || parsed.Folders[1].Files != 2 || parsed.Folders[1].Folders != 1 {
+		t.Fatalf("unexpected Archive totals: %+v", parsed.Folders[1])
+	}
+	if parsed.Quota.Limit != 1073741824 || parsed.Quota.UsageTrash != 1000 {
+		t.Fatalf("unexpected quota: %+v", parsed.Quota)
+	}
+}
@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/drive/v3"
+	gapi "google.golang.org/api/googleapi"
+
+	"github.com/steipete/gogcli/internal/config"
+)
+
+type driveUploadOptions struct {
+	Account   string
+	Name      string // defaults to the local base name
+	Parent    string
+	ChunkSize int64
+	Resume    bool
+	Convert   bool
+	Quiet     bool // no progress bar (parallel uploads)
+}
+
+type driveUploadResult struct {
+	File      *drive.File
+	BytesSent int64
+	Resumed   bool
+}
+
+// uploadDriveLocalFile uploads one file, switching to a resumable session when
+// the file is larger than the chunk size (or --resume was requested).
+func uploadDriveLocalFile(ctx context.Context, svc *drive.Service, localPath string, opts driveUploadOptions) (*driveUploadResult, error) {
+	f, err := os.Open(localPath) //nolint:gosec // user-provided path
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	mimeType := guessMimeType(localPath)
+	targetMime := ""
+	if opts.Convert {
+		targetMime = driveConvertMimeType(localPath)
+	}
+
+	fileName := strings.TrimSpace(opts.Name)
+	if fileName == "" {
+		fileName = filepath.Base(localPath)
+		if targetMime != "" {
+			fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+		}
+	}
+
+	if opts.Resume || info.Size() > opts.ChunkSize {
+		absPath, absErr := filepath.Abs(localPath)
+		if absErr != nil {
+			return nil, absErr
+		}
+		return runDriveResumableUpload(ctx, svc, f, info, driveResumableUpload{
+			Account:        opts.Account,
+			LocalPath:      absPath,
+			Name:           fileName,
+			Parent:         opts.Parent,
+			MimeType:       mimeType,
+			TargetMimeType: targetMime,
+			ChunkSize:      opts.ChunkSize,
+			Resume:         opts.Resume,
+			NoProgress:     opts.Quiet,
+		})
+	}
+
+	meta := &drive.File{Name: fileName, MimeType: targetMime}
+	if opts.Parent != "" {
+		meta.Parents = []string{opts.Parent}
+	}
+	created, err := svc.Files.Create(meta).
+		SupportsAllDrives(true).
+		Media(f, gapi.ContentType(mimeType)).
+		Fields(driveUploadResultFields).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+	return &driveUploadResult{File: created, BytesSent: info.Size()}, nil
+}
+
+// driveConvertMimeType returns the Google-native type an Office/CSV file is
+// imported as with --convert, or "" if the file is uploaded as-is.
+func driveConvertMimeType(localPath string) string {
+	switch strings.ToLower(filepath.Ext(localPath)) {
+	case extDocx, ".doc", ".odt", ".rtf":
+		return driveMimeGoogleDoc
+	case extXlsx, ".xls", ".ods", extCSV:
+		return driveMimeGoogleSheet
+	case extPptx, ".ppt", ".odp":
+		return driveMimeGoogleSlides
+	default:
+		return ""
+	}
+}
+
+// expandDriveUploadPaths expands ~ and glob patterns (for quoted globs the
+// shell did not expand).
+func expandDriveUploadPaths(args []string) ([]string, error) {
+	out := make([]string, 0, len(args))
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			continue
+		}
+		p, err := config.ExpandPath(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.ContainsAny(p, "*?[") {
+			out = append(out, p)
+			continue
+		}
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, usagef("invalid pattern %q: %v", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, usagef("no files match %q", arg)
+		}
+		out = append(out, matches...)
+	}
+	if len(out) == 0 {
+		return nil, usage("empty localPath")
+	}
+	return out, nil
+}
+
+func createDriveFolder(ctx context.Context, svc *drive.Service, name string, parent string) (*drive.File, error) {
+	f := &drive.File{
+		Name:     name,
+		MimeType: driveMimeFolder,
+	}
+	if parent != "" {
+		f.Parents = []string{parent}
+	}
+	return svc.Files.Create(f).
+		SupportsAllDrives(true).
+		Fields("id, name, webViewLink").
+		Context(ctx).
+		Do()
+}
+
+// ensureDriveFolder returns the existing folder called name under parent, or
+// creates it. The bool reports whether a folder was created.
+func ensureDriveFolder(ctx context.Context, svc *drive.Service, name string, parent string) (*drive.File, bool, error) {
+	if parent == "" {
+		parent = "root"
+	}
+	q := fmt.Sprintf("name = '%s' and '%s' in parents and mimeType = '%s' and trashed = false",
+		escapeDriveQueryString(name), escapeDriveQueryString(parent), driveMimeFolder)
+	resp, err := svc.Files.List().
+		Q(q).
+		PageSize(1).
+		OrderBy("createdTime").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Fields("files(id, name, webViewLink)").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(resp.Files) > 0 {
+		return resp.Files[0], false, nil
+	}
+	created, err := createDriveFolder(ctx, svc, name, parent)
+	if err != nil {
+		return nil, false, err
+	}
+	return created, true, nil
+}
+
+type driveUploadTreeItem struct {
+	LocalPath string `json:"localPath"`
+	Path      string `json:"path"`
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	MimeType  string `json:"mimeType,omitempty"`
+	Size      int64  `json:"size,omitempty"`
+	Created   bool   `json:"created,omitempty"`
+	Error     string `json:"error,omitempty"`
+
+	parent string
+}
+
+type driveUploadTreeResult struct {
+	Folders []driveUploadTreeItem `json:"folders"`
+	Files   []driveUploadTreeItem `json:"files"`
+	Failed  int                   `json:"failed"`
+}
+
+// uploadDriveTree mirrors local files and directories under parent. Folders are
+// resolved (reused or created) up front; files are then uploaded with at most
+// concurrency requests in flight.
+func uploadDriveTree(ctx context.Context, svc *drive.Service, paths []string, parent string, concurrency int, opts driveUploadOptions) (*driveUploadTreeResult, error) {
+	res := &driveUploadTreeResult{Folders: []driveUploadTreeItem{}, Files: []driveUploadTreeItem{}}
+
+	for _, p := range paths {
+		st, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !st.IsDir() {
+			res.Files = append(res.Files, driveUploadTreeItem{LocalPath: p, Path: filepath.Base(p), Size: st.Size(), parent: parent})
+			continue
+		}
+
+		rootName := filepath.Base(filepath.Clean(p))
+		rootFolder, created, err := ensureDriveFolder(ctx, svc, rootName, parent)
+		if err != nil {
+			return nil, fmt.Errorf("folder %s: %w", rootName, err)
+		}
+		res.Folders = append(res.Folders, driveUploadTreeItem{LocalPath: p, Path: rootName, ID: rootFolder.Id, Name: rootFolder.Name, Created: created})
+
+		entries, err := listDriveSyncLocal(p)
+		if err != nil {
+			return nil, err
+		}
+		folderIDs := map[string]string{"": rootFolder.Id}
+		for _, e := range entries {
+			dir := path.Dir(e.Path)
+			if dir == "." {
+				dir = ""
+			}
+			parentID := folderIDs[dir]
+			remotePath := path.Join(rootName, e.Path)
+			if e.IsDir {
+				folder, created, err := ensureDriveFolder(ctx, svc, path.Base(e.Path), parentID)
+				if err != nil {
+					return nil, fmt.Errorf("folder %s: %w", remotePath, err)
+				}
+				folderIDs[e.Path] = folder.Id
+				res.Folders = append(res.Folders, driveUploadTreeItem{LocalPath: e.AbsPath, Path: remotePath, ID: folder.Id, Name: folder.Name, Created: created})
+				continue
+			}
+			res.Files = append(res.Files, driveUploadTreeItem{LocalPath: e.AbsPath, Path: remotePath, Size: e.Size, parent: parentID})
+		}
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i := range res.Files {
+		wg.Add(1)
+		go func(item *driveUploadTreeItem) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				item.Error = ctx.Err().Error()
+				return
+			}
+
+			fileOpts := opts
+			fileOpts.Parent = item.parent
+			fileOpts.Quiet = true
+			out, err := uploadDriveLocalFile(ctx, svc, item.LocalPath, fileOpts)
+			if err != nil {
+				item.Error = err.Error()
+				return
+			}
+			item.ID = out.File.Id
+			item.Name = out.File.Name
+			item.MimeType = out.File.MimeType
+		}(&res.Files[i])
+	}
+	wg.Wait()
+
+	for _, f := range res.Files {
+		if f.Error != "" {
+			res.Failed++
+		}
+	}
+	return res, nil
+}
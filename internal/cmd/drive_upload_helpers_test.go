@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+func TestDriveConvertMimeType(t *testing.T) {
+	cases := map[string]string{
+		"report.DOCX": driveMimeGoogleDoc,
+		"data.csv":    driveMimeGoogleSheet,
+		"deck.pptx":   driveMimeGoogleSlides,
+		"photo.png":   "",
+		"README":      "",
+	}
+	for in, want := range cases {
+		if got := driveConvertMimeType(in); got != want {
+			t.Fatalf("driveConvertMimeType(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExpandDriveUploadPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	got, err := expandDriveUploadPaths([]string{filepath.Join(dir, "*.txt"), filepath.Join(dir, "c.md")})
+	if err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+	if len(got) != 3 || filepath.Base(got[0]) != "a.txt" || filepath.Base(got[1]) != "b.txt" || filepath.Base(got[2]) != "c.md" {
+		t.Fatalf("unexpected paths: %v", got)
+	}
+
+	_, err = expandDriveUploadPaths([]string{filepath.Join(dir, "*.pdf")})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 2 {
+		t.Fatalf("expected usage error for unmatched glob, got %v", err)
+	}
+}
+
+func TestDriveUploadCmd_DirectoryRequiresRecursive(t *testing.T) {
+	err := (&DriveUploadCmd{LocalPaths: []string{t.TempDir()}, ChunkSize: 8}).Run(context.Background(), &RootFlags{Account: "a@b.com"})
+	if err == nil || !strings.Contains(err.Error(), "--recursive") {
+		t.Fatalf("expected --recursive hint, got %v", err)
+	}
+}
+
+func TestDriveUploadCmd_RecursiveReusesFolders(t *testing.T) {
+	origNew := newDriveService
+	t.Cleanup(func() { newDriveService = origNew })
+
+	// "photos" already exists under p1; "sub" must be created inside it.
+	children := map[string][]map[string]any{
+		"p1": {{"id": "f-photos", "name": "photos", "mimeType": driveMimeFolder}},
+	}
+	var (
+		mu      sync.Mutex
+		folders []string
+		uploads []string
+	)
+	svc, _ := newDriveFolderTestService(t, children, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/upload/"):
+			meta := readDriveMultipartMeta(t, r)
+			mu.Lock()
+			uploads = append(uploads, meta.Name+"@"+strings.Join(meta.Parents, ",")+"#"+meta.MimeType)
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "id-" + meta.Name, "name": meta.Name})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/files"):
+			var body drive.File
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			folders = append(folders, body.Name+"@"+strings.Join(body.Parents, ","))
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "f-" + body.Name, "name": body.Name})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	newDriveService = func(context.Context, string) (*drive.Service, error) { return svc, nil }
+
+	src := filepath.Join(t.TempDir(), "photos")
+	for rel, body := range map[string]string{"a.txt": "a", "sub/b.csv": "x,y", "sub/c.txt": "c"} {
+		p := filepath.Join(src, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := outfmt.WithMode(ui.WithUI(context.Background(), u), outfmt.Mode{JSON: true})
+
+	out := captureStdout(t, func() {
+		args := []string{src, "--recursive", "--parent", "p1", "--convert", "--concurrency", "2"}
+		if execErr := runKong(t, &DriveUploadCmd{}, args, ctx, &RootFlags{Account: "a@b.com"}); execErr != nil {
+			t.Fatalf("upload: %v", execErr)
+		}
+	})
+
+	var parsed driveUploadTreeResult
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("json: %v\n%s", err, out)
+	}
+	if len(parsed.Folders) != 2 || parsed.Folders[0].Created || parsed.Folders[0].ID != "f-photos" || !parsed.Folders[1].Created {
+		t.Fatalf("unexpected folders: %+v", parsed.Folders)
+	}
+	if len(parsed.Files) != 3 || parsed.Failed != 0 {
+		t.Fatalf("unexpected files: %+v", parsed.Files)
+	}
+
+	if strings.Join(folders, ";") != "sub@f-photos" {
+		t.Fatalf("unexpected folder creates: %v", folders)
+	}
+	sort.Strings(uploads)
+	want := []string{"a.txt@f-photos#", "b@f-sub#" + driveMimeGoogleSheet, "c.txt@f-sub#"}
+	if strings.Join(uploads, ";") != strings.Join(want, ";") {
+		t.Fatalf("unexpected uploads:\n got %v\nwant %v", uploads, want)
+	}
+}
+
+// readDriveMultipartMeta decodes the metadata part of a multipart media upload.
+func readDriveMultipartMeta(t *testing.T, r *http.Request) drive.File {
+	t.Helper()
+
+	var meta drive.File
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		t.Errorf("content type: %v", err)
+		return meta
+	}
+	part, err := multipart.NewReader(r.Body, params["boundary"]).NextPart()
+	if err != nil {
+		t.Errorf("multipart: %v", err)
+		return meta
+	}
+	if err := json.NewDecoder(part).Decode(&meta); err != nil {
+		t.Errorf("decode metadata: %v", err)
+	}
+	return meta
+}
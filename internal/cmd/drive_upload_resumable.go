@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	gapi "google.golang.org/api/googleapi"
+
+	"github.com/steipete/gogcli/internal/config"
+	"github.com/steipete/gogcli/internal/googleapi"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+var newDriveUploadClient = googleapi.NewDriveUploadClient
+
+const (
+	driveUploadResultFields  = "id, name, mimeType, size, webViewLink"
+	driveUploadStatusResumed = 308 // "Resume Incomplete"
+)
+
+var errDriveUploadSessionExpired = errors.New("upload session expired")
+
+// driveUploadSession is persisted under the config dir so an interrupted
+// upload can continue with --resume.
+type driveUploadSession struct {
+	Account    string    `json:"account"`
+	LocalPath  string    `json:"localPath"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"modTime"`
+	Name       string    `json:"name"`
+	Parent     string    `json:"parent,omitempty"`
+	MimeType   string    `json:"mimeType"`
+	SessionURI string    `json:"sessionUri"`
+	CreatedAt  time.Time `json:"createdAt"`
+
+	TargetMimeType string `json:"targetMimeType,omitempty"`
+}
+
+type driveResumableUpload struct {
+	Account        string
+	LocalPath      string
+	Name           string
+	Parent         string
+	MimeType       string
+	TargetMimeType string // Google-native type when converting
+	ChunkSize      int64
+	Resume         bool
+	NoProgress     bool
+}
+
+// driveUploadChunkSize converts --chunk-size to bytes. Whole MiB values keep
+// chunks a multiple of 256 KiB as Drive requires.
+func driveUploadChunkSize(mib int) (int64, error) {
+	if mib <= 0 {
+		return 0, usage("--chunk-size must be positive")
+	}
+	return int64(mib) * 1024 * 1024, nil
+}
+
+// runDriveResumableUpload uploads f using Drive's resumable protocol, saving the
+// session URI before the first chunk and removing it once the upload finishes.
+func runDriveResumableUpload(ctx context.Context, svc *drive.Service, f *os.File, info os.FileInfo, opts driveResumableUpload) (*driveUploadResult, error) {
+	client, err := newDriveUploadClient(ctx, opts.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	statePath, err := driveUploadSessionPath(opts.Account, opts.LocalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	size := info.Size()
+	var (
+		sessionURI string
+		offset     int64
+		resumed    bool
+	)
+
+	if opts.Resume {
+		saved, ok, loadErr := loadDriveUploadSession(statePath)
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		if ok && saved.matches(opts, info) {
+			off, done, queryErr := queryDriveUploadOffset(ctx, client, saved.SessionURI, size)
+			switch {
+			case errors.Is(queryErr, errDriveUploadSessionExpired):
+				// Start over below.
+			case queryErr != nil:
+				return nil, queryErr
+			case done != nil:
+				_ = os.Remove(statePath)
+				return &driveUploadResult{File: done, BytesSent: size, Resumed: true}, nil
+			default:
+				sessionURI, offset, resumed = saved.SessionURI, off, true
+			}
+		}
+	}
+
+	if sessionURI == "" {
+		sessionURI, err = startDriveUploadSession(ctx, client, svc.BasePath, opts, size)
+		if err != nil {
+			return nil, err
+		}
+		if saveErr := saveDriveUploadSession(statePath, driveUploadSession{
+			Account:    opts.Account,
+			LocalPath:  opts.LocalPath,
+			Size:       size,
+			ModTime:    info.ModTime().UTC(),
+			Name:       opts.Name,
+			Parent:     opts.Parent,
+			MimeType:   opts.MimeType,
+			SessionURI: sessionURI,
+			CreatedAt:  time.Now().UTC(),
+
+			TargetMimeType: opts.TargetMimeType,
+		}); saveErr != nil {
+			return nil, saveErr
+		}
+	}
+
+	var bar *ui.Progress
+	if u := ui.FromContext(ctx); u != nil && !opts.NoProgress {
+		bar = u.Err().Progress(opts.Name, size)
+		bar.Set(offset)
+	}
+	created, err := uploadDriveChunks(ctx, client, sessionURI, f, offset, size, opts.ChunkSize, bar.Set)
+	if err != nil {
+		return nil, err
+	}
+	bar.Done()
+	_ = os.Remove(statePath)
+
+	return &driveUploadResult{File: created, BytesSent: size - offset, Resumed: resumed}, nil
+}
+
+func (s *driveUploadSession) matches(opts driveResumableUpload, info os.FileInfo) bool {
+	return s.SessionURI != "" &&
+		s.Account == opts.Account &&
+		s.LocalPath == opts.LocalPath &&
+		s.Size == info.Size() &&
+		s.ModTime.Equal(info.ModTime().UTC()) &&
+		s.Name == opts.Name &&
+		s.Parent == opts.Parent &&
+		s.TargetMimeType == opts.TargetMimeType
+}
+
+func startDriveUploadSession(ctx context.Context, client *http.Client, basePath string, opts driveResumableUpload, size int64) (string, error) {
+	meta := &drive.File{Name: opts.Name, MimeType: opts.TargetMimeType}
+	if opts.Parent != "" {
+		meta.Parents = []string{opts.Parent}
+	}
+	body, err := json.Marshal(meta)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("uploadType", "resumable")
+	params.Set("supportsAllDrives", "true")
+	params.Set("fields", driveUploadResultFields)
+	endpoint := gapi.ResolveRelative(basePath, "/upload/drive/v3/files") + "?" + params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Type", opts.MimeType)
+	req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", driveUploadHTTPError("start upload session", resp)
+	}
+	loc := resp.Header.Get("Location")
+	if loc == "" {
+		return "", errors.New("start upload session: missing Location header")
+	}
+	return loc, nil
+}
+
+// queryDriveUploadOffset asks Drive how many bytes of the session it has.
+// A finished upload returns the created file instead.
+func queryDriveUploadOffset(ctx context.Context, client *http.Client, sessionURI string, size int64) (int64, *drive.File, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, sessionURI, http.NoBody)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case driveUploadStatusResumed:
+		return parseDriveUploadRange(resp.Header.Get("Range")), nil, nil
+	case http.StatusOK, http.StatusCreated:
+		var f drive.File
+		if err := json.NewDecoder(resp.Body).Decode(&f); err != nil {
+			return 0, nil, fmt.Errorf("decode upload result: %w", err)
+		}
+		return size, &f, nil
+	case http.StatusNotFound, http.StatusGone:
+		return 0, nil, errDriveUploadSessionExpired
+	default:
+		return 0, nil, driveUploadHTTPError("query upload session", resp)
+	}
+}
+
+func uploadDriveChunks(ctx context.Context, client *http.Client, sessionURI string, f io.ReaderAt, offset int64, size int64, chunkSize int64, progress func(int64)) (*drive.File, error) {
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := f.ReadAt(buf, offset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if n == 0 && offset < size {
+			return nil, fmt.Errorf("local file shrank during upload (at %d of %d bytes)", offset, size)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, sessionURI, bytes.NewReader(buf[:n]))
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = int64(n)
+		if n > 0 {
+			req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(n)-1, size))
+		} else {
+			req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		switch resp.StatusCode {
+		case driveUploadStatusResumed:
+			// Drive may persist fewer bytes than sent; continue from what it has.
+			offset = parseDriveUploadRange(resp.Header.Get("Range"))
+			_ = resp.Body.Close()
+			progress(offset)
+		case http.StatusOK, http.StatusCreated:
+			var created drive.File
+			decodeErr := json.NewDecoder(resp.Body).Decode(&created)
+			_ = resp.Body.Close()
+			if decodeErr != nil {
+				return nil, fmt.Errorf("decode upload result: %w", decodeErr)
+			}
+			progress(size)
+			return &created, nil
+		default:
+			err := driveUploadHTTPError("upload chunk", resp)
+			_ = resp.Body.Close()
+			return nil, err
+		}
+	}
+}
+
+// parseDriveUploadRange turns a "bytes=0-N" Range header into the next offset.
+func parseDriveUploadRange(h string) int64 {
+	h = strings.TrimSpace(h)
+	if h == "" {
+		return 0
+	}
+	_, last, ok := strings.Cut(h, "-")
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(last), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n + 1
+}
+
+func driveUploadHTTPError(action string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("%s failed: %s: %s", action, resp.Status, strings.TrimSpace(string(body)))
+}
+
+func driveUploadSessionPath(account string, localPath string) (string, error) {
+	dir, err := config.EnsureDriveUploadsDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(account) + "\x00" + localPath))
+	return filepath.Join(dir, hex.EncodeToString(sum[:12])+".json"), nil
+}
+
+func loadDriveUploadSession(path string) (driveUploadSession, bool, error) {
+	var s driveUploadSession
+	data, err := os.ReadFile(path) //nolint:gosec // path is derived from the config dir
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, false, nil
+		}
+		return s, false, fmt.Errorf("read upload session: %w", err)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, false, fmt.Errorf("decode upload session: %w", err)
+	}
+	return s, true, nil
+}
+
+func saveDriveUploadSession(path string, s driveUploadSession) error {
+	payload, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(payload, '\n'), 0o600)
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+// fakeResumableDrive implements just enough of Drive's resumable upload
+// protocol to exercise chunking and resume.
+type fakeResumableDrive struct {
+	mu       sync.Mutex
+	received []byte
+	puts     int
+	starts   int
+}
+
+func (f *fakeResumableDrive) handler(t *testing.T, baseURL *string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/upload/") && r.URL.Query().Get("uploadType") == "resumable":
+			f.starts++
+			var meta drive.File
+			_ = json.NewDecoder(r.Body).Decode(&meta)
+			if meta.Name != "big.bin" || len(meta.Parents) != 1 || meta.Parents[0] != "p1" {
+				t.Errorf("unexpected metadata: %+v", meta)
+			}
+			w.Header().Set("Location", *baseURL+"/session/1")
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPut && r.URL.Path == "/session/1":
+			cr := r.Header.Get("Content-Range")
+			total, _ := strconv.Atoi(cr[strings.LastIndex(cr, "/")+1:])
+			if !strings.HasPrefix(cr, "bytes */") {
+				f.puts++
+				body, _ := io.ReadAll(r.Body)
+				f.received = append(f.received, body...)
+			}
+			if len(f.received) < total {
+				if len(f.received) > 0 {
+					w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(f.received)-1))
+				}
+				w.WriteHeader(driveUploadStatusResumed)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "up1", "name": "big.bin", "size": strconv.Itoa(total)})
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func setupResumableDriveTest(t *testing.T) (*fakeResumableDrive, string) {
+	t.Helper()
+
+	origNew := newDriveService
+	origClient := newDriveUploadClient
+	t.Cleanup(func() {
+		newDriveService = origNew
+		newDriveUploadClient = origClient
+	})
+
+	fake := &fakeResumableDrive{}
+	var baseURL string
+	srv := httptest.NewServer(fake.handler(t, &baseURL))
+	t.Cleanup(srv.Close)
+	baseURL = srv.URL
+
+	svc, err := drive.NewService(context.Background(),
+		option.WithoutAuthentication(),
+		option.WithHTTPClient(srv.Client()),
+		option.WithEndpoint(srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	newDriveService = func(context.Context, string) (*drive.Service, error) { return svc, nil }
+	newDriveUploadClient = func(context.Context, string) (*http.Client, error) { return srv.Client(), nil }
+
+	local := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(local, bytes.Repeat([]byte("abcdefgh"), 3*1024*1024/8+100), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return fake, local
+}
+
+func runDriveUploadJSON(t *testing.T, args []string) map[string]any {
+	t.Helper()
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := outfmt.WithMode(ui.WithUI(context.Background(), u), outfmt.Mode{JSON: true})
+
+	out := captureStdout(t, func() {
+		if execErr := runKong(t, &DriveUploadCmd{}, args, ctx, &RootFlags{Account: "a@b.com"}); execErr != nil {
+			t.Fatalf("upload: %v", execErr)
+		}
+	})
+	var parsed map[string]any
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("json: %v\n%s", err, out)
+	}
+	return parsed
+}
+
+func TestDriveUploadCmd_ResumableChunks(t *testing.T) {
+	fake, local := setupResumableDriveTest(t)
+	info, _ := os.Stat(local)
+
+	parsed := runDriveUploadJSON(t, []string{local, "--parent", "p1", "--chunk-size", "1"})
+
+	if fake.starts != 1 || fake.puts != 4 {
+		t.Fatalf("expected 1 session and 4 chunks, got starts=%d puts=%d", fake.starts, fake.puts)
+	}
+	if int64(len(fake.received)) != info.Size() {
+		t.Fatalf("server received %d bytes, want %d", len(fake.received), info.Size())
+	}
+	if parsed["bytesSent"] != float64(info.Size()) || parsed["resumed"] != false {
+		t.Fatalf("unexpected json: %v", parsed)
+	}
+	if file, _ := parsed["file"].(map[string]any); file["id"] != "up1" {
+		t.Fatalf("unexpected file: %v", parsed)
+	}
+
+	abs, _ := filepath.Abs(local)
+	statePath, err := driveUploadSessionPath("a@b.com", abs)
+	if err != nil {
+		t.Fatalf("driveUploadSessionPath: %v", err)
+	}
+	if _, statErr := os.Stat(statePath); !os.IsNotExist(statErr) {
+		t.Fatalf("expected session file removed, got %v", statErr)
+	}
+}
+
+func TestDriveUploadCmd_Resume(t *testing.T) {
+	fake, local := setupResumableDriveTest(t)
+	info, _ := os.Stat(local)
+	data, _ := os.ReadFile(local)
+
+	// Pretend a previous run got 1 MiB through before crashing.
+	fake.received = append([]byte(nil), data[:1024*1024]...)
+	abs, _ := filepath.Abs(local)
+	statePath, err := driveUploadSessionPath("a@b.com", abs)
+	if err != nil {
+		t.Fatalf("driveUploadSessionPath: %v", err)
+	}
+	svc, _ := newDriveService(context.Background(), "a@b.com")
+	if err := saveDriveUploadSession(statePath, driveUploadSession{
+		Account:    "a@b.com",
+		LocalPath:  abs,
+		Size:       info.Size(),
+		ModTime:    info.ModTime().UTC(),
+		Name:       "big.bin",
+		Parent:     "p1",
+		MimeType:   "application/octet-stream",
+		SessionURI: strings.TrimSuffix(svc.BasePath, "/") + "/session/1",
+		CreatedAt:  time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	parsed := runDriveUploadJSON(t, []string{local, "--parent", "p1", "--chunk-size", "1", "--resume"})
+
+	if fake.starts != 0 {
+		t.Fatalf("expected existing session to be reused, got %d new sessions", fake.starts)
+	}
+	if !bytes.Equal(fake.received, data) {
+		t.Fatalf("server content mismatch: got %d bytes, want %d", len(fake.received), len(data))
+	}
+	if parsed["resumed"] != true || parsed["bytesSent"] != float64(info.Size()-1024*1024) {
+		t.Fatalf("unexpected json: %v", parsed)
+	}
+}
+
+func TestParseDriveUploadRange(t *testing.T) {
+	cases := map[string]int64{"": 0, "bytes=0-0": 1, "bytes=0-262143": 262144, "garbage": 0}
+	for in, want := range cases {
+		if got := parseDriveUploadRange(in); got != want {
+			t.Fatalf("parseDriveUploadRange(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
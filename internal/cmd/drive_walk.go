@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+	gapi "google.golang.org/api/googleapi"
+)
+
+const driveWalkFields = "id, name, mimeType, size, quotaBytesUsed, md5Checksum, modifiedTime, parents"
+
+// driveWalkEntry is a file or folder found while walking a Drive folder tree.
+type driveWalkEntry struct {
+	Path  string // slash-separated, relative to the walk root
+	Depth int    // 1 for direct children of the root
+	File  *drive.File
+}
+
+func (e driveWalkEntry) IsFolder() bool {
+	return e.File != nil && e.File.MimeType == driveMimeFolder
+}
+
+// listDriveChildren pages through all non-trashed children of folderID.
+func listDriveChildren(ctx context.Context, svc *drive.Service, folderID string, fields string) ([]*drive.File, error) {
+	q := buildDriveListQuery(folderID, "")
+	var out []*drive.File
+	pageToken := ""
+	for {
+		call := svc.Files.List().
+			Q(q).
+			PageSize(1000).
+			OrderBy("folder,name").
+			SupportsAllDrives(true).
+			IncludeItemsFromAllDrives(true).
+			Fields(gapi.Field("nextPageToken, files(" + fields + ")")).
+			Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Do()
+		if err != nil {
+			return nil, fmt.Errorf("list folder %s: %w", folderID, err)
+		}
+		out = append(out, resp.Files...)
+		if resp.NextPageToken == "" {
+			return out, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// walkDriveFolder lists folderID recursively, depth-first, with entries sorted
+// by name within each folder. maxDepth <= 0 means unlimited.
+func walkDriveFolder(ctx context.Context, svc *drive.Service, folderID string, maxDepth int) ([]driveWalkEntry, error) {
+	var out []driveWalkEntry
+	var walk func(id string, prefix string, depth int) error
+	walk = func(id string, prefix string, depth int) error {
+		files, err := listDriveChildren(ctx, svc, id, driveWalkFields)
+		if err != nil {
+			return err
+		}
+		sort.SliceStable(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+		for _, f := range files {
+			entry := driveWalkEntry{Path: path.Join(prefix, driveSafeName(f.Name)), Depth: depth, File: f}
+			out = append(out, entry)
+			if entry.IsFolder() && (maxDepth <= 0 || depth < maxDepth) {
+				if err := walk(f.Id, entry.Path, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(folderID, "", 1); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// driveSafeName makes a Drive file name usable as a single path segment.
+// Drive allows slashes and dot-only names that would otherwise escape the
+// walk root when mapped onto the local filesystem.
+func driveSafeName(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+	switch strings.TrimSpace(name) {
+	case "", ".", "..":
+		return "_"
+	}
+	return name
+}
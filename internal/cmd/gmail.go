@@ -26,13 +26,19 @@ type GmailCmd struct {
 	Attachment GmailAttachmentCmd `cmd:"" name:"attachment" group:"Read" help:"Download a single attachment"`
 	URL        GmailURLCmd        `cmd:"" name:"url" group:"Read" help:"Print Gmail web URLs for threads"`
 	History    GmailHistoryCmd    `cmd:"" name:"history" group:"Read" help:"Gmail history"`
+	Export     GmailExportCmd     `cmd:"" name:"export" group:"Read" help:"Export messages matching a query to mbox, Maildir or .eml"`
+	Sync       GmailSyncCmd       `cmd:"" name:"sync" group:"Read" help:"Keep a local mirror of the mailbox up to date"`
+	Import     GmailImportCmd     `cmd:"" name:"import" group:"Write" help:"Import mbox, Maildir or .eml files into the mailbox"`
 
 	Labels GmailLabelsCmd `cmd:"" name:"labels" group:"Organize" help:"Label operations"`
 	Batch  GmailBatchCmd  `cmd:"" name:"batch" group:"Organize" help:"Batch operations"`
 
-	Send   GmailSendCmd   `cmd:"" name:"send" group:"Write" help:"Send an email"`
-	Track  GmailTrackCmd  `cmd:"" name:"track" group:"Write" help:"Email open tracking"`
-	Drafts GmailDraftsCmd `cmd:"" name:"drafts" group:"Write" help:"Draft operations"`
+	Send    GmailSendCmd    `cmd:"" name:"send" group:"Write" help:"Send an email"`
+	Reply   GmailReplyCmd   `cmd:"" name:"reply" group:"Write" help:"Reply to a message, quoting it"`
+	Forward GmailForwardCmd `cmd:"" name:"forward" group:"Write" help:"Forward a message with its attachments"`
+	Track   GmailTrackCmd   `cmd:"" name:"track" group:"Write" help:"Email open tracking"`
+	Drafts  GmailDraftsCmd  `cmd:"" name:"drafts" group:"Write" help:"Draft operations"`
+	Queue   GmailQueueCmd   `cmd:"" name:"queue" group:"Write" help:"Scheduled sends (gmail send --at)"`
 
 	Settings GmailSettingsCmd `cmd:"" name:"settings" group:"Admin" help:"Settings and admin"`
 
@@ -3,7 +3,9 @@ package cmd
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 
 	"google.golang.org/api/gmail/v1"
 
@@ -11,13 +13,119 @@ import (
 	"github.com/steipete/gogcli/internal/ui"
 )
 
+const (
+	// gmailBatchChunk is the per-request ID limit of batchModify/batchDelete.
+	gmailBatchChunk      = 1000
+	gmailBatchSampleSize = 10
+)
+
 type GmailBatchCmd struct {
 	Delete GmailBatchDeleteCmd `cmd:"" name:"delete" help:"Permanently delete multiple messages"`
 	Modify GmailBatchModifyCmd `cmd:"" name:"modify" help:"Modify labels on multiple messages"`
 }
 
+// GmailBatchSelectFlags picks the messages for a batch command: explicit IDs or
+// every match of a Gmail search.
+type GmailBatchSelectFlags struct {
+	MessageIDs []string `arg:"" name:"messageId" optional:"" help:"Message IDs"`
+	Query      string   `name:"query" short:"q" help:"Gmail search query selecting the messages (instead of IDs)"`
+	Max        int64    `name:"max" help:"With --query, act on at most this many messages (default: all)"`
+	DryRun     bool     `name:"dry-run" help:"With --query, print the match count and a sample without changing anything"`
+}
+
+func (s GmailBatchSelectFlags) validate() error {
+	query := strings.TrimSpace(s.Query)
+	switch {
+	case query == "" && len(s.MessageIDs) == 0:
+		return usage("provide message IDs or --query")
+	case query != "" && len(s.MessageIDs) > 0:
+		return usage("use either message IDs or --query, not both")
+	case query == "" && (s.Max != 0 || s.DryRun):
+		return usage("--max and --dry-run require --query")
+	case s.Max < 0:
+		return usage("--max must be >= 0")
+	}
+	return nil
+}
+
+func (s GmailBatchSelectFlags) resolve(ctx context.Context, svc *gmail.Service) ([]string, error) {
+	if strings.TrimSpace(s.Query) == "" {
+		return s.MessageIDs, nil
+	}
+	ids := []string{}
+	err := listGmailMessageIDs(ctx, svc, strings.TrimSpace(s.Query), s.Max, false, func(id string) error {
+		ids = append(ids, id)
+		return nil
+	})
+	return ids, err
+}
+
+// forEachGmailBatchChunk calls fn with consecutive slices of at most
+// gmailBatchChunk IDs.
+func forEachGmailBatchChunk(ids []string, fn func(chunk []string) error) error {
+	for start := 0; start < len(ids); start += gmailBatchChunk {
+		end := min(start+gmailBatchChunk, len(ids))
+		if err := fn(ids[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type gmailBatchSampleItem struct {
+	ID      string `json:"id"`
+	Date    string `json:"date,omitempty"`
+	From    string `json:"from,omitempty"`
+	Subject string `json:"subject,omitempty"`
+}
+
+// writeGmailBatchDryRun reports what a --query batch would touch, with
+// headers of the first few matches so the query can be sanity-checked.
+func writeGmailBatchDryRun(ctx context.Context, svc *gmail.Service, action string, query string, ids []string) error {
+	sample := make([]gmailBatchSampleItem, 0, min(len(ids), gmailBatchSampleSize))
+	for _, id := range ids[:min(len(ids), gmailBatchSampleSize)] {
+		msg, err := svc.Users.Messages.Get("me", id).
+			Format("metadata").
+			MetadataHeaders("From", "Subject", "Date").
+			Context(ctx).
+			Do()
+		if err != nil {
+			return err
+		}
+		sample = append(sample, gmailBatchSampleItem{
+			ID:      id,
+			Date:    formatGmailDate(headerValue(msg.Payload, "Date")),
+			From:    sanitizeTab(headerValue(msg.Payload, "From")),
+			Subject: sanitizeTab(headerValue(msg.Payload, "Subject")),
+		})
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{
+			"dryRun": true,
+			"action": action,
+			"query":  query,
+			"count":  len(ids),
+			"sample": sample,
+		})
+	}
+
+	u := ui.FromContext(ctx)
+	u.Err().Printf("Would %s %d messages matching %q", action, len(ids), query)
+	if len(sample) == 0 {
+		return nil
+	}
+	w, flush := tableWriter(ctx)
+	defer flush()
+	fmt.Fprintln(w, "ID\tDATE\tFROM\tSUBJECT")
+	for _, it := range sample {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", it.ID, it.Date, it.From, it.Subject)
+	}
+	return nil
+}
+
 type GmailBatchDeleteCmd struct {
-	MessageIDs []string `arg:"" name:"messageId" help:"Message IDs"`
+	Select GmailBatchSelectFlags `embed:""`
 }
 
 func (c *GmailBatchDeleteCmd) Run(ctx context.Context, flags *RootFlags) error {
@@ -26,34 +134,61 @@ func (c *GmailBatchDeleteCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if err != nil {
 		return err
 	}
+	if err = c.Select.validate(); err != nil {
+		return err
+	}
 
 	svc, err := newGmailService(ctx, account)
 	if err != nil {
 		return err
 	}
 
-	err = svc.Users.Messages.BatchDelete("me", &gmail.BatchDeleteMessagesRequest{
-		Ids: c.MessageIDs,
-	}).Do()
+	ids, err := c.Select.resolve(ctx, svc)
+	if err != nil {
+		return err
+	}
+	query := strings.TrimSpace(c.Select.Query)
+	if c.Select.DryRun {
+		return writeGmailBatchDryRun(ctx, svc, "delete", query, ids)
+	}
+	if query != "" {
+		if len(ids) == 0 {
+			return writeGmailBatchEmpty(ctx, "deleted", query)
+		}
+		if err = confirmDestructive(ctx, flags, fmt.Sprintf("permanently delete %d messages matching %q", len(ids), query)); err != nil {
+			return err
+		}
+	}
+
+	done := 0
+	err = forEachGmailBatchChunk(ids, func(chunk []string) error {
+		if err := svc.Users.Messages.BatchDelete("me", &gmail.BatchDeleteMessagesRequest{
+			Ids: chunk,
+		}).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("delete failed after %d of %d messages: %w", done, len(ids), err)
+		}
+		done += len(chunk)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
 	if outfmt.IsJSON(ctx) {
 		return outfmt.WriteJSON(os.Stdout, map[string]any{
-			"deleted": c.MessageIDs,
-			"count":   len(c.MessageIDs),
+			"deleted": ids,
+			"count":   len(ids),
 		})
 	}
 
-	u.Out().Printf("Deleted %d messages", len(c.MessageIDs))
+	u.Out().Printf("Deleted %d messages", len(ids))
 	return nil
 }
 
 type GmailBatchModifyCmd struct {
-	MessageIDs []string `arg:"" name:"messageId" help:"Message IDs"`
-	Add        string   `name:"add" help:"Labels to add (comma-separated, name or ID)"`
-	Remove     string   `name:"remove" help:"Labels to remove (comma-separated, name or ID)"`
+	Select GmailBatchSelectFlags `embed:""`
+	Add    string                `name:"add" help:"Labels to add (comma-separated, name or ID)"`
+	Remove string                `name:"remove" help:"Labels to remove (comma-separated, name or ID)"`
 }
 
 func (c *GmailBatchModifyCmd) Run(ctx context.Context, flags *RootFlags) error {
@@ -68,6 +203,9 @@ func (c *GmailBatchModifyCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if len(addLabels) == 0 && len(removeLabels) == 0 {
 		return errors.New("must specify --add and/or --remove")
 	}
+	if err = c.Select.validate(); err != nil {
+		return err
+	}
 
 	svc, err := newGmailService(ctx, account)
 	if err != nil {
@@ -82,24 +220,54 @@ func (c *GmailBatchModifyCmd) Run(ctx context.Context, flags *RootFlags) error {
 	addIDs := resolveLabelIDs(addLabels, idMap)
 	removeIDs := resolveLabelIDs(removeLabels, idMap)
 
-	err = svc.Users.Messages.BatchModify("me", &gmail.BatchModifyMessagesRequest{
-		Ids:            c.MessageIDs,
-		AddLabelIds:    addIDs,
-		RemoveLabelIds: removeIDs,
-	}).Do()
+	ids, err := c.Select.resolve(ctx, svc)
+	if err != nil {
+		return err
+	}
+	query := strings.TrimSpace(c.Select.Query)
+	if c.Select.DryRun {
+		return writeGmailBatchDryRun(ctx, svc, "modify", query, ids)
+	}
+	if query != "" && len(ids) == 0 {
+		return writeGmailBatchEmpty(ctx, "modified", query)
+	}
+
+	done := 0
+	err = forEachGmailBatchChunk(ids, func(chunk []string) error {
+		if err := svc.Users.Messages.BatchModify("me", &gmail.BatchModifyMessagesRequest{
+			Ids:            chunk,
+			AddLabelIds:    addIDs,
+			RemoveLabelIds: removeIDs,
+		}).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("modify failed after %d of %d messages: %w", done, len(ids), err)
+		}
+		done += len(chunk)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
 	if outfmt.IsJSON(ctx) {
 		return outfmt.WriteJSON(os.Stdout, map[string]any{
-			"modified":      c.MessageIDs,
-			"count":         len(c.MessageIDs),
+			"modified":      ids,
+			"count":         len(ids),
 			"addedLabels":   addIDs,
 			"removedLabels": removeIDs,
 		})
 	}
 
-	u.Out().Printf("Modified %d messages", len(c.MessageIDs))
+	u.Out().Printf("Modified %d messages", len(ids))
+	return nil
+}
+
+func writeGmailBatchEmpty(ctx context.Context, key string, query string) error {
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{
+			key:     []string{},
+			"count": 0,
+		})
+	}
+	ui.FromContext(ctx).Err().Printf("No messages match %q", query)
 	return nil
 }
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+func TestGmailBatchModifyCmd_QueryChunks(t *testing.T) {
+	var (
+		chunks  []int
+		queries []string
+	)
+	setupGmailTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/users/me/labels"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"labels": []map[string]any{{"id": "INBOX", "name": "INBOX", "type": "system"}}})
+		case strings.HasSuffix(r.URL.Path, "/users/me/messages"):
+			queries = append(queries, r.URL.Query().Get("q"))
+			start := 0
+			if tok := r.URL.Query().Get("pageToken"); tok != "" {
+				_, _ = fmt.Sscanf(tok, "p%d", &start)
+			}
+			msgs := []map[string]any{}
+			for i := start; i < start+500 && i < 1500; i++ {
+				msgs = append(msgs, map[string]any{"id": fmt.Sprintf("m%d", i)})
+			}
+			resp := map[string]any{"messages": msgs}
+			if start+500 < 1500 {
+				resp["nextPageToken"] = fmt.Sprintf("p%d", start+500)
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		case strings.HasSuffix(r.URL.Path, "/messages/batchModify"):
+			var body gmail.BatchModifyMessagesRequest
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			chunks = append(chunks, len(body.Ids))
+			w.WriteHeader(http.StatusNoContent)
+		case strings.Contains(r.URL.Path, "/users/me/messages/"):
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id":      "m0",
+				"payload": map[string]any{"headers": []map[string]any{{"name": "Subject", "value": "Weekly digest"}}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	plan := runDriveCmdJSON(t, &GmailBatchModifyCmd{}, []string{"--query", "list:news.example.com", "--remove", "INBOX", "--dry-run"})
+	sample, _ := plan["sample"].([]any)
+	if plan["count"] != float64(1500) || len(sample) != gmailBatchSampleSize || len(chunks) != 0 {
+		t.Fatalf("unexpected dry-run: count=%v sample=%d chunks=%v", plan["count"], len(sample), chunks)
+	}
+	if sample[0].(map[string]any)["subject"] != "Weekly digest" {
+		t.Fatalf("unexpected sample: %v", sample[0])
+	}
+
+	parsed := runDriveCmdJSON(t, &GmailBatchModifyCmd{}, []string{"--query", "list:news.example.com", "--remove", "INBOX"})
+	if parsed["count"] != float64(1500) || fmt.Sprint(chunks) != "[1000 500]" {
+		t.Fatalf("unexpected modify: count=%v chunks=%v", parsed["count"], chunks)
+	}
+	if queries[len(queries)-1] != "list:news.example.com" {
+		t.Fatalf("unexpected query: %v", queries)
+	}
+
+	chunks = nil
+	_ = runDriveCmdJSON(t, &GmailBatchModifyCmd{}, []string{"--query", "list:news.example.com", "--remove", "INBOX", "--max", "1200"})
+	if fmt.Sprint(chunks) != "[1000 200]" {
+		t.Fatalf("unexpected chunks with --max: %v", chunks)
+	}
+}
+
+func TestGmailBatchDeleteCmd_QueryRequiresForce(t *testing.T) {
+	deleted := false
+	setupGmailTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/users/me/messages"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"messages": []map[string]any{{"id": "m1"}}})
+		case strings.HasSuffix(r.URL.Path, "/messages/batchDelete"):
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := ui.WithUI(context.Background(), u)
+	err = runKong(t, &GmailBatchDeleteCmd{}, []string{"--query", "from:spam@example.com"}, ctx, &RootFlags{Account: "a@b.com", NoInput: true})
+	if err == nil || !strings.Contains(err.Error(), "--force") || deleted {
+		t.Fatalf("expected refusal without --force, got %v (deleted=%v)", err, deleted)
+	}
+
+	if err := runKong(t, &GmailBatchDeleteCmd{}, []string{"m1", "--dry-run"}, ctx, &RootFlags{Account: "a@b.com"}); err == nil {
+		t.Fatalf("expected --dry-run without --query to fail")
+	}
+}
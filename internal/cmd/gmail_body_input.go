@@ -16,19 +16,51 @@ func resolveBodyInput(body, bodyFile string) (string, error) {
 	if strings.TrimSpace(body) != "" {
 		return "", usage("use only one of --body or --body-file")
 	}
+	return readBodyFile(bodyFile)
+}
+
+// resolveComposeBody resolves the plain-text and HTML bodies from the
+// --body/--body-file/--body-html flags, or renders them from
+// --body-md/--body-md-file.
+func resolveComposeBody(body, bodyFile, bodyHTML, bodyMD, bodyMDFile string) (string, string, error) {
+	bodyMDFile = strings.TrimSpace(bodyMDFile)
+	if bodyMD == "" && bodyMDFile == "" {
+		text, err := resolveBodyInput(body, bodyFile)
+		return text, bodyHTML, err
+	}
+	if strings.TrimSpace(body) != "" || strings.TrimSpace(bodyFile) != "" || strings.TrimSpace(bodyHTML) != "" {
+		return "", "", usage("use --body-md/--body-md-file instead of --body, --body-file or --body-html")
+	}
+	md := bodyMD
+	if bodyMDFile != "" {
+		if strings.TrimSpace(bodyMD) != "" {
+			return "", "", usage("use only one of --body-md or --body-md-file")
+		}
+		var err error
+		if md, err = readBodyFile(bodyMDFile); err != nil {
+			return "", "", err
+		}
+	}
+	if strings.TrimSpace(md) == "" {
+		return "", "", usage("empty Markdown body")
+	}
+	text, html := markdownBodies(md)
+	return text, html, nil
+}
 
+func readBodyFile(path string) (string, error) {
 	var (
 		b   []byte
 		err error
 	)
-	if bodyFile == "-" {
+	if path == "-" {
 		b, err = io.ReadAll(os.Stdin)
 	} else {
-		bodyFile, err = config.ExpandPath(bodyFile)
+		path, err = config.ExpandPath(path)
 		if err != nil {
 			return "", err
 		}
-		b, err = os.ReadFile(bodyFile) //nolint:gosec // user-provided path
+		b, err = os.ReadFile(path) //nolint:gosec // user-provided path
 	}
 	if err != nil {
 		return "", err
@@ -251,7 +251,7 @@ func (c *GmailDraftsSendCmd) Run(ctx context.Context, flags *RootFlags) error {
 		return err
 	}
 
-	msg, err := svc.Users.Drafts.Send("me", &gmail.Draft{Id: draftID}).Do()
+	msg, err := sendGmailDraft(ctx, svc, draftID)
 	if err != nil {
 		return err
 	}
@@ -268,6 +268,10 @@ func (c *GmailDraftsSendCmd) Run(ctx context.Context, flags *RootFlags) error {
 	return nil
 }
 
+func sendGmailDraft(ctx context.Context, svc *gmail.Service, draftID string) (*gmail.Message, error) {
+	return svc.Users.Drafts.Send("me", &gmail.Draft{Id: draftID}).Context(ctx).Do()
+}
+
 type GmailDraftsCreateCmd struct {
 	To               string   `name:"to" help:"Recipients (comma-separated)"`
 	Cc               string   `name:"cc" help:"CC recipients (comma-separated)"`
@@ -276,9 +280,13 @@ type GmailDraftsCreateCmd struct {
 	Body             string   `name:"body" help:"Body (plain text; required unless --body-html is set)"`
 	BodyFile         string   `name:"body-file" help:"Body file path (plain text; '-' for stdin)"`
 	BodyHTML         string   `name:"body-html" help:"Body (HTML; optional)"`
+	BodyMD           string   `name:"body-md" help:"Body (Markdown; saved as plain text plus rendered HTML)"`
+	BodyMDFile       string   `name:"body-md-file" help:"Markdown body file path ('-' for stdin)"`
 	ReplyToMessageID string   `name:"reply-to-message-id" help:"Reply to Gmail message ID (sets In-Reply-To/References and thread)"`
 	ReplyTo          string   `name:"reply-to" help:"Reply-To header address"`
 	Attach           []string `name:"attach" help:"Attachment file path (repeatable)"`
+	Inline           []string `name:"inline" help:"Inline image as cid=path, shown where the HTML body uses src=\"cid:<cid>\" (repeatable)"`
+	ICS              string   `name:"ics" help:"Calendar invite (.ics file) sent as text/calendar; method=REQUEST"`
 	From             string   `name:"from" help:"Send from this email address (must be a verified send-as alias)"`
 }
 
@@ -293,6 +301,8 @@ type draftComposeInput struct {
 	ReplyToThreadID  string
 	ReplyTo          string
 	Attach           []string
+	Inline           []string
+	ICS              string
 	From             string
 }
 
@@ -301,7 +311,7 @@ func (c draftComposeInput) validate() error {
 		return usage("required: --subject")
 	}
 	if strings.TrimSpace(c.Body) == "" && strings.TrimSpace(c.BodyHTML) == "" {
-		return usage("required: --body, --body-file, or --body-html")
+		return usage("required: --body, --body-file, --body-html or --body-md")
 	}
 	return nil
 }
@@ -330,13 +340,13 @@ func buildDraftMessage(ctx context.Context, svc *gmail.Service, account string,
 	references := info.References
 	threadID := info.ThreadID
 
-	atts := make([]mailAttachment, 0, len(input.Attach))
-	for _, p := range input.Attach {
-		expanded, expandErr := config.ExpandPath(p)
-		if expandErr != nil {
-			return nil, "", expandErr
-		}
-		atts = append(atts, mailAttachment{Path: expanded})
+	atts, err := gmailComposeAttachments(input.Attach, input.Inline)
+	if err != nil {
+		return nil, "", err
+	}
+	calendar, err := readGmailInvite(input.ICS)
+	if err != nil {
+		return nil, "", err
 	}
 
 	raw, err := buildRFC822(mailOptions{
@@ -351,6 +361,7 @@ func buildDraftMessage(ctx context.Context, svc *gmail.Service, account string,
 		InReplyTo:   inReplyTo,
 		References:  references,
 		Attachments: atts,
+		Calendar:    calendar,
 	}, &rfc822Config{allowMissingTo: true})
 	if err != nil {
 		return nil, "", err
@@ -394,7 +405,7 @@ func (c *GmailDraftsCreateCmd) Run(ctx context.Context, flags *RootFlags) error
 		return err
 	}
 
-	body, err := resolveBodyInput(c.Body, c.BodyFile)
+	body, bodyHTML, err := resolveComposeBody(c.Body, c.BodyFile, c.BodyHTML, c.BodyMD, c.BodyMDFile)
 	if err != nil {
 		return err
 	}
@@ -405,11 +416,13 @@ func (c *GmailDraftsCreateCmd) Run(ctx context.Context, flags *RootFlags) error
 		Bcc:              c.Bcc,
 		Subject:          c.Subject,
 		Body:             body,
-		BodyHTML:         c.BodyHTML,
+		BodyHTML:         bodyHTML,
 		ReplyToMessageID: c.ReplyToMessageID,
 		ReplyToThreadID:  "",
 		ReplyTo:          c.ReplyTo,
 		Attach:           c.Attach,
+		Inline:           c.Inline,
+		ICS:              c.ICS,
 		From:             c.From,
 	}
 	if validateErr := input.validate(); validateErr != nil {
@@ -442,9 +455,13 @@ type GmailDraftsUpdateCmd struct {
 	Body             string   `name:"body" help:"Body (plain text; required unless --body-html is set)"`
 	BodyFile         string   `name:"body-file" help:"Body file path (plain text; '-' for stdin)"`
 	BodyHTML         string   `name:"body-html" help:"Body (HTML; optional)"`
+	BodyMD           string   `name:"body-md" help:"Body (Markdown; saved as plain text plus rendered HTML)"`
+	BodyMDFile       string   `name:"body-md-file" help:"Markdown body file path ('-' for stdin)"`
 	ReplyToMessageID string   `name:"reply-to-message-id" help:"Reply to Gmail message ID (sets In-Reply-To/References and thread)"`
 	ReplyTo          string   `name:"reply-to" help:"Reply-To header address"`
 	Attach           []string `name:"attach" help:"Attachment file path (repeatable)"`
+	Inline           []string `name:"inline" help:"Inline image as cid=path, shown where the HTML body uses src=\"cid:<cid>\" (repeatable)"`
+	ICS              string   `name:"ics" help:"Calendar invite (.ics file) sent as text/calendar; method=REQUEST"`
 	From             string   `name:"from" help:"Send from this email address (must be a verified send-as alias)"`
 }
 
@@ -489,7 +506,7 @@ func (c *GmailDraftsUpdateCmd) Run(ctx context.Context, flags *RootFlags) error
 		to = existingTo
 	}
 
-	body, err := resolveBodyInput(c.Body, c.BodyFile)
+	body, bodyHTML, err := resolveComposeBody(c.Body, c.BodyFile, c.BodyHTML, c.BodyMD, c.BodyMDFile)
 	if err != nil {
 		return err
 	}
@@ -505,11 +522,13 @@ func (c *GmailDraftsUpdateCmd) Run(ctx context.Context, flags *RootFlags) error
 		Bcc:              c.Bcc,
 		Subject:          c.Subject,
 		Body:             body,
-		BodyHTML:         c.BodyHTML,
+		BodyHTML:         bodyHTML,
 		ReplyToMessageID: c.ReplyToMessageID,
 		ReplyToThreadID:  replyToThreadID,
 		ReplyTo:          c.ReplyTo,
 		Attach:           c.Attach,
+		Inline:           c.Inline,
+		ICS:              c.ICS,
 		From:             c.From,
 	}
 	if validateErr := input.validate(); validateErr != nil {
@@ -0,0 +1,481 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+
+	"github.com/steipete/gogcli/internal/config"
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+const (
+	gmailExportMbox    = "mbox"
+	gmailExportMaildir = "maildir"
+	gmailExportEML     = "eml"
+
+	gmailExportProgressEvery = 500
+)
+
+type GmailExportCmd struct {
+	Query            []string `arg:"" name:"query" help:"Gmail search query (e.g. 'label:legal-hold', 'from:x before:2024/01/01')"`
+	Format           string   `name:"format" help:"Output format: mbox|maildir|eml" default:"mbox" enum:"mbox,maildir,eml"`
+	Out              string   `name:"out" aliases:"output" help:"Output path: mbox file, or directory for maildir/eml" required:""`
+	Max              int64    `name:"max" aliases:"limit" help:"Stop after this many matching messages (0 = all)" default:"0"`
+	Concurrency      int      `name:"concurrency" help:"Parallel message downloads" default:"8"`
+	IncludeSpamTrash bool     `name:"include-spam-trash" help:"Include messages in Spam and Trash"`
+}
+
+type gmailExportFailure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+func (c *GmailExportCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	query := strings.TrimSpace(strings.Join(c.Query, " "))
+	if query == "" {
+		return usage("missing query")
+	}
+	out, err := config.ExpandPath(strings.TrimSpace(c.Out))
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		return usage("empty --out")
+	}
+	concurrency := c.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	sink, err := openGmailExportSink(c.Format, out)
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+
+	svc, err := newGmailService(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu       sync.Mutex
+		exported int
+		skipped  int
+		failures = []gmailExportFailure{}
+	)
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				msg, fetchErr := svc.Users.Messages.Get("me", id).Format(gmailFormatRaw).Context(ctx).Do()
+				var raw []byte
+				if fetchErr == nil {
+					raw, fetchErr = decodeGmailRaw(msg.Raw)
+				}
+				mu.Lock()
+				if fetchErr == nil {
+					fetchErr = sink.Write(msg, raw)
+				}
+				if fetchErr != nil {
+					failures = append(failures, gmailExportFailure{ID: id, Error: fetchErr.Error()})
+				} else {
+					exported++
+					if exported%gmailExportProgressEvery == 0 {
+						u.Err().Printf("exported %d messages (%d already present)", exported, skipped)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	listErr := listGmailMessageIDs(ctx, svc, query, c.Max, c.IncludeSpamTrash, func(id string) error {
+		mu.Lock()
+		done := sink.Has(id)
+		if done {
+			skipped++
+		}
+		mu.Unlock()
+		if done {
+			return nil
+		}
+		select {
+		case jobs <- id:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(jobs)
+	wg.Wait()
+	if listErr != nil {
+		return listErr
+	}
+
+	if outfmt.IsJSON(ctx) {
+		if err := outfmt.WriteJSON(os.Stdout, map[string]any{
+			"format":   c.Format,
+			"out":      out,
+			"exported": exported,
+			"skipped":  skipped,
+			"failed":   len(failures),
+			"failures": failures,
+		}); err != nil {
+			return err
+		}
+	} else {
+		u.Out().Printf("format\t%s", c.Format)
+		u.Out().Printf("out\t%s", out)
+		u.Out().Printf("exported\t%d", exported)
+		u.Out().Printf("skipped\t%d", skipped)
+		u.Out().Printf("failed\t%d", len(failures))
+		for _, f := range failures {
+			u.Err().Printf("%s: %s", f.ID, f.Error)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d messages failed to export; rerun to retry them", len(failures))
+	}
+	return nil
+}
+
+// listGmailMessageIDs pages through messages.list for query and calls fn for
+// each message ID, stopping after max IDs when max > 0.
+func listGmailMessageIDs(ctx context.Context, svc *gmail.Service, query string, maxResults int64, includeSpamTrash bool, fn func(id string) error) error {
+	var seen int64
+	pageToken := ""
+	for {
+		call := svc.Users.Messages.List("me").
+			Q(query).
+			MaxResults(500).
+			IncludeSpamTrash(includeSpamTrash).
+			Fields("nextPageToken, messages(id)").
+			Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Do()
+		if err != nil {
+			return err
+		}
+		for _, m := range resp.Messages {
+			if maxResults > 0 && seen >= maxResults {
+				return nil
+			}
+			seen++
+			if err := fn(m.Id); err != nil {
+				return err
+			}
+		}
+		if resp.NextPageToken == "" {
+			return nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+func decodeGmailRaw(raw string) ([]byte, error) {
+	if raw == "" {
+		return nil, errors.New("empty raw message")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(raw, "="))
+	if err != nil {
+		return nil, fmt.Errorf("decode raw message: %w", err)
+	}
+	return data, nil
+}
+
+// gmailExportSink stores raw messages. Has reports messages written by an
+// earlier run so exports can resume. Calls are serialized by the caller.
+type gmailExportSink interface {
+	Has(id string) bool
+	Write(msg *gmail.Message, raw []byte) error
+	Close() error
+}
+
+func openGmailExportSink(format string, out string) (gmailExportSink, error) {
+	switch format {
+	case gmailExportMbox:
+		return openGmailMboxSink(out)
+	case gmailExportMaildir:
+		return openGmailMaildirSink(out)
+	case gmailExportEML:
+		return openGmailEMLSink(out)
+	}
+	return nil, usagef("invalid --format %q (expected mbox|maildir|eml)", format)
+}
+
+// gmailMboxSink appends mboxrd messages to one file and records exported IDs
+// in a sidecar "<out>.ids" file. Each index line is "<id>\t<offset>", the mbox
+// size once that message was fully written; a line with an empty ID records
+// the size the export started from. The index is only written after the
+// message, so on open anything past the last recorded offset is a message
+// cut off by a crash and is truncated away before appending.
+type gmailMboxSink struct {
+	mbox   *os.File
+	index  *os.File
+	ids    map[string]bool
+	offset int64
+}
+
+func openGmailMboxSink(path string) (*gmailMboxSink, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, err
+		}
+	}
+	ids := map[string]bool{}
+	committed := int64(-1)
+	indexPath := path + ".ids"
+	data, err := os.ReadFile(indexPath) //nolint:gosec // user-provided export path
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	// A final line without a newline was cut off mid-write: not committed.
+	complete := data[:bytes.LastIndexByte(data, '\n')+1]
+	for _, line := range strings.Split(string(complete), "\n") {
+		id, off, hasOffset := strings.Cut(strings.TrimSpace(line), "\t")
+		if id != "" {
+			ids[id] = true
+		}
+		if hasOffset {
+			n, parseErr := strconv.ParseInt(off, 10, 64)
+			if parseErr != nil {
+				return nil, fmt.Errorf("%s: invalid offset in %q", indexPath, line)
+			}
+			committed = n
+		}
+	}
+
+	mbox, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600) //nolint:gosec // user-provided export path
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(indexPath, os.O_CREATE|os.O_WRONLY, 0o600) //nolint:gosec // user-provided export path
+	if err != nil {
+		_ = mbox.Close()
+		return nil, err
+	}
+	sink := &gmailMboxSink{mbox: mbox, index: index, ids: ids}
+	if err := sink.recover(path, int64(len(complete)), committed); err != nil {
+		_ = sink.Close()
+		return nil, err
+	}
+	return sink, nil
+}
+
+// recover cuts the index back to its complete lines and the mbox back to the
+// last committed offset. Without one (a new export, or an index written
+// before offsets were recorded) the current mbox size becomes the base.
+func (s *gmailMboxSink) recover(path string, indexSize int64, committed int64) error {
+	if err := s.index.Truncate(indexSize); err != nil {
+		return err
+	}
+	if _, err := s.index.Seek(indexSize, io.SeekStart); err != nil {
+		return err
+	}
+	info, err := s.mbox.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	switch {
+	case committed < 0:
+		if _, err := fmt.Fprintf(s.index, "\t%d\n", size); err != nil {
+			return err
+		}
+		committed = size
+	case size < committed:
+		return fmt.Errorf("%s is shorter than its index records (%d < %d bytes); use a new --out", path, size, committed)
+	case size > committed:
+		if err := s.mbox.Truncate(committed); err != nil {
+			return err
+		}
+	}
+	s.offset = committed
+	_, err = s.mbox.Seek(committed, io.SeekStart)
+	return err
+}
+
+func (s *gmailMboxSink) Has(id string) bool { return s.ids[id] }
+
+func (s *gmailMboxSink) Write(msg *gmail.Message, raw []byte) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From MAILER-DAEMON %s\n", gmailInternalTime(msg).UTC().Format(time.ANSIC))
+	writeMboxrdBody(&buf, raw)
+	n, err := s.mbox.Write(buf.Bytes())
+	if err == nil {
+		_, err = fmt.Fprintf(s.index, "%s\t%d\n", msg.Id, s.offset+int64(n))
+	}
+	if err != nil {
+		// Drop the uncommitted message so the next one starts at a boundary.
+		if truncErr := s.mbox.Truncate(s.offset); truncErr == nil {
+			_, _ = s.mbox.Seek(s.offset, io.SeekStart)
+		}
+		return err
+	}
+	s.offset += int64(n)
+	s.ids[msg.Id] = true
+	return nil
+}
+
+func (s *gmailMboxSink) Close() error {
+	return errors.Join(s.mbox.Close(), s.index.Close())
+}
+
+// writeMboxrdBody writes raw with LF line endings, quoting "From " lines
+// (including already-quoted ones) and ending with a blank line.
+func writeMboxrdBody(buf *bytes.Buffer, raw []byte) {
+	raw = bytes.ReplaceAll(raw, []byte("\r\n"), []byte("\n"))
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	sc.Buffer(make([]byte, 0, 64*1024), len(raw)+1)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From ")) {
+			buf.WriteByte('>')
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+}
+
+// gmailMaildirSink writes one file per message into cur/, named
+// "<unix>.<messageId>.gog:2,<flags>" so the Gmail ID can be recovered.
+type gmailMaildirSink struct {
+	dir string
+	ids map[string]bool
+}
+
+func openGmailMaildirSink(dir string) (*gmailMaildirSink, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, err
+		}
+	}
+	ids := map[string]bool{}
+	for _, sub := range []string{"new", "cur"} {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if id := gmailMaildirID(e.Name()); id != "" {
+				ids[id] = true
+			}
+		}
+	}
+	return &gmailMaildirSink{dir: dir, ids: ids}, nil
+}
+
+func gmailMaildirID(name string) string {
+	name, _, _ = strings.Cut(name, ":")
+	parts := strings.Split(name, ".")
+	if len(parts) != 3 || parts[2] != "gog" {
+		return ""
+	}
+	return parts[1]
+}
+
+func (s *gmailMaildirSink) Has(id string) bool { return s.ids[id] }
+
+func (s *gmailMaildirSink) Write(msg *gmail.Message, raw []byte) error {
+	name := fmt.Sprintf("%d.%s.gog:2,%s", gmailInternalTime(msg).Unix(), msg.Id, gmailMaildirFlags(msg.LabelIds))
+	tmp := filepath.Join(s.dir, "tmp", name)
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, "cur", name)); err != nil {
+		return err
+	}
+	s.ids[msg.Id] = true
+	return nil
+}
+
+func (s *gmailMaildirSink) Close() error { return nil }
+
+// gmailMaildirFlags maps Gmail system labels to Maildir info flags, which must
+// be sorted.
+func gmailMaildirFlags(labels []string) string {
+	starred, draft, unread := false, false, false
+	for _, l := range labels {
+		switch l {
+		case "STARRED":
+			starred = true
+		case "DRAFT":
+			draft = true
+		case "UNREAD":
+			unread = true
+		}
+	}
+	var b strings.Builder
+	if draft {
+		b.WriteByte('D')
+	}
+	if starred {
+		b.WriteByte('F')
+	}
+	if !unread {
+		b.WriteByte('S')
+	}
+	return b.String()
+}
+
+// gmailEMLSink writes "<messageId>.eml" files into a directory.
+type gmailEMLSink struct {
+	dir string
+}
+
+func openGmailEMLSink(dir string) (*gmailEMLSink, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &gmailEMLSink{dir: dir}, nil
+}
+
+func (s *gmailEMLSink) Has(id string) bool {
+	_, err := os.Stat(filepath.Join(s.dir, id+".eml"))
+	return err == nil
+}
+
+func (s *gmailEMLSink) Write(msg *gmail.Message, raw []byte) error {
+	path := filepath.Join(s.dir, msg.Id+".eml")
+	tmp := path + ".part"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *gmailEMLSink) Close() error { return nil }
+
+func gmailInternalTime(msg *gmail.Message) time.Time {
+	if msg.InternalDate > 0 {
+		return time.UnixMilli(msg.InternalDate)
+	}
+	return time.Now()
+}
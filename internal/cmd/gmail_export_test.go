@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+func TestWriteMboxrdBody(t *testing.T) {
+	var buf bytes.Buffer
+	writeMboxrdBody(&buf, []byte("Subject: hi\r\n\r\nFrom here\r\n>From there\r\nok"))
+	want := "Subject: hi\n\n>From here\n>>From there\nok\n\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestGmailMaildirNames(t *testing.T) {
+	if got := gmailMaildirFlags([]string{"INBOX", "STARRED"}); got != "FS" {
+		t.Fatalf("flags = %q", got)
+	}
+	if got := gmailMaildirFlags([]string{"UNREAD"}); got != "" {
+		t.Fatalf("flags = %q", got)
+	}
+	if got := gmailMaildirID("1700000000.18c2f.gog:2,S"); got != "18c2f" {
+		t.Fatalf("id = %q", got)
+	}
+	if got := gmailMaildirID("1700000000.M1P2.host:2,S"); got != "" {
+		t.Fatalf("foreign maildir file parsed as %q", got)
+	}
+}
+
+// newGmailExportTestService serves three messages and counts raw fetches.
+func newGmailExportTestService(t *testing.T) *int32 {
+	t.Helper()
+
+	origNew := newGmailService
+	t.Cleanup(func() { newGmailService = origNew })
+
+	var fetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := r.URL.Path
+		switch {
+		case strings.HasSuffix(path, "/users/me/messages"):
+			if r.URL.Query().Get("q") != "label:hold" {
+				t.Errorf("unexpected q: %q", r.URL.Query().Get("q"))
+			}
+			if r.URL.Query().Get("pageToken") == "" {
+				_ = json.NewEncoder(w).Encode(map[string]any{
+					"messages":      []map[string]any{{"id": "m1"}, {"id": "m2"}},
+					"nextPageToken": "p2",
+				})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"messages": []map[string]any{{"id": "m3"}}})
+		case strings.Contains(path, "/users/me/messages/"):
+			atomic.AddInt32(&fetches, 1)
+			id := path[strings.LastIndex(path, "/")+1:]
+			raw := "Message-ID: <" + id + "@x>\r\nSubject: " + id + "\r\n\r\nFrom the body\r\n"
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id":           id,
+				"internalDate": "1700000000000",
+				"labelIds":     []string{"INBOX"},
+				"raw":          base64.URLEncoding.EncodeToString([]byte(raw)),
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	svc, err := gmail.NewService(context.Background(),
+		option.WithoutAuthentication(),
+		option.WithHTTPClient(srv.Client()),
+		option.WithEndpoint(srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	newGmailService = func(context.Context, string) (*gmail.Service, error) { return svc, nil }
+	return &fetches
+}
+
+func TestGmailExport_MboxResumes(t *testing.T) {
+	fetches := newGmailExportTestService(t)
+	out := filepath.Join(t.TempDir(), "hold.mbox")
+
+	parsed := runDriveCmdJSON(t, &GmailExportCmd{}, []string{"label:hold", "--out", out, "--max", "2"})
+	if parsed["exported"] != float64(2) {
+		t.Fatalf("unexpected first run: %v", parsed)
+	}
+	parsed = runDriveCmdJSON(t, &GmailExportCmd{}, []string{"label:hold", "--out", out})
+	if parsed["exported"] != float64(1) || parsed["skipped"] != float64(2) || atomic.LoadInt32(fetches) != 3 {
+		t.Fatalf("unexpected resume: %v (fetches %d)", parsed, *fetches)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read mbox: %v", err)
+	}
+	if strings.Count(string(data), "\nFrom MAILER-DAEMON ") != 2 || !strings.HasPrefix(string(data), "From MAILER-DAEMON ") {
+		t.Fatalf("expected 3 mbox entries:\n%s", data)
+	}
+	if strings.Count(string(data), ">From the body") != 3 || strings.Contains(string(data), "\r") {
+		t.Fatalf("body lines not quoted/normalized:\n%s", data)
+	}
+}
+
+func TestGmailMboxSink_RecoversFromCrash(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "hold.mbox")
+
+	// An mbox that already has content is appended to, not truncated.
+	if err := os.WriteFile(out, []byte("From old\n\nkeep\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sink, err := openGmailMboxSink(out)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := sink.Write(&gmail.Message{Id: "m1"}, []byte("Subject: one\r\n\r\nbody\r\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	committed, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Crash: m2 half written to the mbox and its index line cut off.
+	appendFile := func(path, data string) {
+		f, openErr := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+		if openErr != nil {
+			t.Fatal(openErr)
+		}
+		_, _ = f.WriteString(data)
+		_ = f.Close()
+	}
+	appendFile(out, "From MAILER-DAEMON Thu Jan  1 00:00:00 1970\nSubject: tw")
+	appendFile(out+".ids", "m2\t99")
+
+	sink, err = openGmailMboxSink(out)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if !sink.Has("m1") || sink.Has("m2") {
+		t.Fatalf("unexpected ids: %v", sink.ids)
+	}
+	if err := sink.Write(&gmail.Message{Id: "m2"}, []byte("Subject: two\r\n\r\nbody\r\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_ = sink.Close()
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), string(committed)) || strings.Contains(string(data), "Subject: tw\n") || strings.Count(string(data), "From MAILER-DAEMON ") != 2 {
+		t.Fatalf("truncated message not removed:\n%s", data)
+	}
+	index, _ := os.ReadFile(out + ".ids")
+	lines := strings.Split(strings.TrimSuffix(string(index), "\n"), "\n")
+	if len(lines) != 3 || lines[0] != "\t16" || lines[2] != "m2\t"+strconv.Itoa(len(data)) {
+		t.Fatalf("unexpected index:\n%s", index)
+	}
+}
+
+func TestGmailExport_MaildirAndEML(t *testing.T) {
+	_ = newGmailExportTestService(t)
+	dir := t.TempDir()
+
+	_ = runDriveCmdJSON(t, &GmailExportCmd{}, []string{"label:hold", "--format", "maildir", "--out", filepath.Join(dir, "md")})
+	entries, err := os.ReadDir(filepath.Join(dir, "md", "cur"))
+	if err != nil || len(entries) != 3 || entries[0].Name() != "1700000000.m1.gog:2,S" {
+		t.Fatalf("unexpected maildir: %v %v", entries, err)
+	}
+
+	_ = runDriveCmdJSON(t, &GmailExportCmd{}, []string{"label:hold", "--format", "eml", "--out", filepath.Join(dir, "eml")})
+	data, err := os.ReadFile(filepath.Join(dir, "eml", "m2.eml"))
+	if err != nil || !strings.HasPrefix(string(data), "Message-ID: <m2@x>\r\n") {
+		t.Fatalf("unexpected eml %q: %v", data, err)
+	}
+}
@@ -0,0 +1,522 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+	"gopkg.in/yaml.v3"
+
+	"github.com/steipete/gogcli/internal/config"
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+// gmailFiltersFile is the declarative filters file used by export/apply.
+// Labels are referenced by name so the file works across accounts.
+type gmailFiltersFile struct {
+	Filters []gmailFilterSpec `yaml:"filters" json:"filters"`
+}
+
+type gmailFilterSpec struct {
+	Criteria gmailFilterSpecCriteria `yaml:"criteria" json:"criteria"`
+	Action   gmailFilterSpecAction   `yaml:"action" json:"action"`
+}
+
+type gmailFilterSpecCriteria struct {
+	From           string `yaml:"from,omitempty" json:"from,omitempty"`
+	To             string `yaml:"to,omitempty" json:"to,omitempty"`
+	Subject        string `yaml:"subject,omitempty" json:"subject,omitempty"`
+	Query          string `yaml:"query,omitempty" json:"query,omitempty"`
+	NegatedQuery   string `yaml:"negatedQuery,omitempty" json:"negatedQuery,omitempty"`
+	HasAttachment  bool   `yaml:"hasAttachment,omitempty" json:"hasAttachment,omitempty"`
+	ExcludeChats   bool   `yaml:"excludeChats,omitempty" json:"excludeChats,omitempty"`
+	Size           int64  `yaml:"size,omitempty" json:"size,omitempty"`
+	SizeComparison string `yaml:"sizeComparison,omitempty" json:"sizeComparison,omitempty"`
+}
+
+type gmailFilterSpecAction struct {
+	AddLabels    []string `yaml:"addLabels,omitempty" json:"addLabels,omitempty"`
+	RemoveLabels []string `yaml:"removeLabels,omitempty" json:"removeLabels,omitempty"`
+	Forward      string   `yaml:"forward,omitempty" json:"forward,omitempty"`
+}
+
+type GmailFiltersExportCmd struct{}
+
+func (c *GmailFiltersExportCmd) Run(ctx context.Context, flags *RootFlags) error {
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+
+	svc, err := newGmailService(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	resp, err := svc.Users.Settings.Filters.List("me").Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+	idToName, err := fetchLabelIDToName(svc)
+	if err != nil {
+		return err
+	}
+
+	file := gmailFiltersFile{Filters: make([]gmailFilterSpec, 0, len(resp.Filter))}
+	for _, f := range resp.Filter {
+		file.Filters = append(file.Filters, gmailFilterSpecFromAPI(f, idToName))
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, file)
+	}
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(file); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+type GmailFiltersApplyCmd struct {
+	File   string `arg:"" name:"file" help:"Filters file: YAML/JSON from 'filters export', or Gmail's mailFilters.xml ('-' for stdin)"`
+	Prune  bool   `name:"prune" help:"Delete live filters that are not in the file"`
+	DryRun bool   `name:"dry-run" help:"Show the changes without applying them"`
+}
+
+func (c *GmailFiltersApplyCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+
+	data, err := readGmailFiltersInput(c.File)
+	if err != nil {
+		return err
+	}
+	specs, err := parseGmailFiltersFile(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.File, err)
+	}
+
+	svc, err := newGmailService(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	resp, err := svc.Users.Settings.Filters.List("me").Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+	nameToID, err := fetchLabelNameToID(svc)
+	if err != nil {
+		return err
+	}
+	idToName, err := fetchLabelIDToName(svc)
+	if err != nil {
+		return err
+	}
+
+	plan := planGmailFilters(specs, resp.Filter, nameToID, idToName)
+	if !c.Prune {
+		plan.deletes = nil
+	}
+
+	if !c.DryRun && len(plan.deletes) > 0 {
+		if err := confirmDestructive(ctx, flags, fmt.Sprintf("delete %d filters not in %s", len(plan.deletes), c.File)); err != nil {
+			return err
+		}
+	}
+
+	created := make([]map[string]any, 0, len(plan.creates))
+	deleted := make([]map[string]any, 0, len(plan.deletes))
+	if !c.DryRun {
+		for _, name := range plan.missingLabels {
+			label, createErr := createLabel(ctx, svc, name)
+			if createErr != nil {
+				return mapLabelCreateError(createErr, name)
+			}
+			nameToID[strings.ToLower(name)] = label.Id
+		}
+		for _, spec := range plan.creates {
+			f, createErr := svc.Users.Settings.Filters.Create("me", spec.toAPI(nameToID)).Context(ctx).Do()
+			if createErr != nil {
+				return fmt.Errorf("create filter %s: %w", spec.summary(), createErr)
+			}
+			created = append(created, map[string]any{"id": f.Id, "filter": spec})
+		}
+		for _, f := range plan.deletes {
+			if deleteErr := svc.Users.Settings.Filters.Delete("me", f.Id).Context(ctx).Do(); deleteErr != nil {
+				return fmt.Errorf("delete filter %s: %w", f.Id, deleteErr)
+			}
+		}
+	} else {
+		for _, spec := range plan.creates {
+			created = append(created, map[string]any{"filter": spec})
+		}
+	}
+	for _, f := range plan.deletes {
+		deleted = append(deleted, map[string]any{"id": f.Id, "filter": gmailFilterSpecFromAPI(f, idToName)})
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{
+			"created":       created,
+			"deleted":       deleted,
+			"unchanged":     plan.unchanged,
+			"extra":         plan.extra,
+			"labelsCreated": plan.missingLabels,
+			"dryRun":        c.DryRun,
+		})
+	}
+
+	for _, name := range plan.missingLabels {
+		u.Out().Printf("+ label\t%s", name)
+	}
+	for _, spec := range plan.creates {
+		u.Out().Printf("+ filter\t%s", spec.summary())
+	}
+	for _, f := range plan.deletes {
+		u.Out().Printf("- filter\t%s\t%s", f.Id, gmailFilterSpecFromAPI(f, idToName).summary())
+	}
+	u.Out().Printf("created\t%d", len(plan.creates))
+	u.Out().Printf("deleted\t%d", len(plan.deletes))
+	u.Out().Printf("unchanged\t%d", plan.unchanged)
+	if !c.Prune && plan.extra > 0 {
+		u.Err().Printf("%d live filters are not in %s (use --prune to delete them)", plan.extra, c.File)
+	}
+	if c.DryRun {
+		u.Err().Println("Dry run: no changes made")
+	}
+	return nil
+}
+
+func readGmailFiltersInput(path string) ([]byte, error) {
+	if strings.TrimSpace(path) == "-" {
+		text, err := readBodyFile("-")
+		return []byte(text), err
+	}
+	expanded, err := config.ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(expanded) //nolint:gosec // user-provided path
+}
+
+// parseGmailFiltersFile accepts the YAML (or JSON) written by export and the
+// mailFilters.xml exported from the Gmail web UI.
+func parseGmailFiltersFile(data []byte) ([]gmailFilterSpec, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("<")) {
+		return parseGmailFiltersXML(trimmed)
+	}
+	var file gmailFiltersFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, err
+	}
+	for i, spec := range file.Filters {
+		if err := spec.validate(); err != nil {
+			return nil, fmt.Errorf("filter %d: %w", i+1, err)
+		}
+	}
+	return file.Filters, nil
+}
+
+func (s gmailFilterSpec) validate() error {
+	c := s.Criteria
+	if c.From == "" && c.To == "" && c.Subject == "" && c.Query == "" && c.NegatedQuery == "" && !c.HasAttachment && c.Size == 0 {
+		return errors.New("no criteria")
+	}
+	a := s.Action
+	if len(a.AddLabels) == 0 && len(a.RemoveLabels) == 0 && a.Forward == "" {
+		return errors.New("no action")
+	}
+	if c.Size != 0 && c.SizeComparison != "larger" && c.SizeComparison != "smaller" {
+		return errors.New("size requires sizeComparison: larger or smaller")
+	}
+	return nil
+}
+
+func gmailFilterSpecFromAPI(f *gmail.Filter, idToName map[string]string) gmailFilterSpec {
+	var spec gmailFilterSpec
+	if c := f.Criteria; c != nil {
+		spec.Criteria = gmailFilterSpecCriteria{
+			From:           c.From,
+			To:             c.To,
+			Subject:        c.Subject,
+			Query:          c.Query,
+			NegatedQuery:   c.NegatedQuery,
+			HasAttachment:  c.HasAttachment,
+			ExcludeChats:   c.ExcludeChats,
+			Size:           c.Size,
+			SizeComparison: c.SizeComparison,
+		}
+	}
+	if a := f.Action; a != nil {
+		names := func(ids []string) []string {
+			out := make([]string, 0, len(ids))
+			for _, id := range ids {
+				if name, ok := idToName[id]; ok {
+					out = append(out, name)
+				} else {
+					out = append(out, id)
+				}
+			}
+			if len(out) == 0 {
+				return nil
+			}
+			return out
+		}
+		spec.Action = gmailFilterSpecAction{
+			AddLabels:    names(a.AddLabelIds),
+			RemoveLabels: names(a.RemoveLabelIds),
+			Forward:      a.Forward,
+		}
+	}
+	return spec
+}
+
+func (s gmailFilterSpec) toAPI(nameToID map[string]string) *gmail.Filter {
+	c := s.Criteria
+	return &gmail.Filter{
+		Criteria: &gmail.FilterCriteria{
+			From:           c.From,
+			To:             c.To,
+			Subject:        c.Subject,
+			Query:          c.Query,
+			NegatedQuery:   c.NegatedQuery,
+			HasAttachment:  c.HasAttachment,
+			ExcludeChats:   c.ExcludeChats,
+			Size:           c.Size,
+			SizeComparison: c.SizeComparison,
+		},
+		Action: &gmail.FilterAction{
+			AddLabelIds:    resolveLabelIDs(s.Action.AddLabels, nameToID),
+			RemoveLabelIds: resolveLabelIDs(s.Action.RemoveLabels, nameToID),
+			Forward:        s.Action.Forward,
+		},
+	}
+}
+
+// key identifies a filter by its content (Gmail filters have no update, so
+// a changed filter is a delete plus a create).
+func (s gmailFilterSpec) key(nameToID map[string]string) string {
+	f := s.toAPI(nameToID)
+	slices.Sort(f.Action.AddLabelIds)
+	slices.Sort(f.Action.RemoveLabelIds)
+	f.Action.AddLabelIds = slices.Compact(f.Action.AddLabelIds)
+	f.Action.RemoveLabelIds = slices.Compact(f.Action.RemoveLabelIds)
+	b, _ := json.Marshal(f)
+	return string(b)
+}
+
+func (s gmailFilterSpec) summary() string {
+	var parts []string
+	add := func(name, value string) {
+		if value != "" {
+			parts = append(parts, name+":"+strconv.Quote(value))
+		}
+	}
+	c := s.Criteria
+	add("from", c.From)
+	add("to", c.To)
+	add("subject", c.Subject)
+	add("query", c.Query)
+	add("negatedQuery", c.NegatedQuery)
+	if c.HasAttachment {
+		parts = append(parts, "hasAttachment")
+	}
+	if c.Size != 0 {
+		parts = append(parts, fmt.Sprintf("size:%s:%d", c.SizeComparison, c.Size))
+	}
+	var actions []string
+	for _, l := range s.Action.AddLabels {
+		actions = append(actions, "+"+l)
+	}
+	for _, l := range s.Action.RemoveLabels {
+		actions = append(actions, "-"+l)
+	}
+	if s.Action.Forward != "" {
+		actions = append(actions, "forward:"+s.Action.Forward)
+	}
+	return strings.Join(parts, " ") + " => " + strings.Join(actions, " ")
+}
+
+type gmailFiltersPlan struct {
+	creates       []gmailFilterSpec
+	deletes       []*gmail.Filter
+	missingLabels []string
+	unchanged     int
+	extra         int
+}
+
+func planGmailFilters(specs []gmailFilterSpec, live []*gmail.Filter, nameToID, idToName map[string]string) gmailFiltersPlan {
+	var plan gmailFiltersPlan
+
+	liveByKey := make(map[string][]*gmail.Filter, len(live))
+	for _, f := range live {
+		k := gmailFilterSpecFromAPI(f, idToName).key(nameToID)
+		liveByKey[k] = append(liveByKey[k], f)
+	}
+
+	wanted := make(map[string]bool, len(specs))
+	missing := map[string]bool{}
+	for _, spec := range specs {
+		k := spec.key(nameToID)
+		if wanted[k] {
+			continue // duplicate in the file
+		}
+		wanted[k] = true
+		if len(liveByKey[k]) > 0 {
+			plan.unchanged++
+			continue
+		}
+		plan.creates = append(plan.creates, spec)
+		for _, name := range append(append([]string{}, spec.Action.AddLabels...), spec.Action.RemoveLabels...) {
+			if _, ok := nameToID[strings.ToLower(strings.TrimSpace(name))]; !ok && !missing[strings.ToLower(name)] {
+				missing[strings.ToLower(name)] = true
+				plan.missingLabels = append(plan.missingLabels, strings.TrimSpace(name))
+			}
+		}
+	}
+
+	for _, f := range live {
+		k := gmailFilterSpecFromAPI(f, idToName).key(nameToID)
+		if !wanted[k] {
+			plan.deletes = append(plan.deletes, f)
+			continue
+		}
+		// Keep one live copy; duplicates of a wanted filter are extra.
+		if liveByKey[k][0] != f {
+			plan.deletes = append(plan.deletes, f)
+		}
+	}
+	plan.extra = len(plan.deletes)
+	return plan
+}
+
+// gmailFiltersXMLFeed is the Atom feed Gmail's web UI exports
+// (Settings → Filters → Export).
+type gmailFiltersXMLFeed struct {
+	Entries []struct {
+		Properties []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:"value,attr"`
+		} `xml:"property"`
+	} `xml:"entry"`
+}
+
+var gmailSmartLabels = map[string]string{
+	"^smartlabel_personal":     "CATEGORY_PERSONAL",
+	"^smartlabel_social":       "CATEGORY_SOCIAL",
+	"^smartlabel_promo":        "CATEGORY_PROMOTIONS",
+	"^smartlabel_notification": "CATEGORY_UPDATES",
+	"^smartlabel_group":        "CATEGORY_FORUMS",
+}
+
+var gmailXMLSizeUnits = map[string]int64{
+	"s_sb":  1,
+	"s_skb": 1 << 10,
+	"s_smb": 1 << 20,
+}
+
+func parseGmailFiltersXML(data []byte) ([]gmailFilterSpec, error) {
+	var feed gmailFiltersXMLFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("parse mailFilters XML: %w", err)
+	}
+
+	specs := make([]gmailFilterSpec, 0, len(feed.Entries))
+	for i, entry := range feed.Entries {
+		var (
+			spec     gmailFilterSpec
+			sizeUnit int64 = 1
+		)
+		for _, p := range entry.Properties {
+			v := p.Value
+			isTrue := v == "true"
+			switch p.Name {
+			case "from":
+				spec.Criteria.From = v
+			case "to":
+				spec.Criteria.To = v
+			case "subject":
+				spec.Criteria.Subject = v
+			case "hasTheWord":
+				spec.Criteria.Query = v
+			case "doesNotHaveTheWord":
+				spec.Criteria.NegatedQuery = v
+			case "hasAttachment":
+				spec.Criteria.HasAttachment = isTrue
+			case "excludeChats":
+				spec.Criteria.ExcludeChats = isTrue
+			case "size":
+				n, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("entry %d: invalid size %q", i+1, v)
+				}
+				spec.Criteria.Size = n
+			case "sizeOperator":
+				spec.Criteria.SizeComparison = map[string]string{"s_sl": "larger", "s_ss": "smaller"}[v]
+			case "sizeUnit":
+				if unit, ok := gmailXMLSizeUnits[v]; ok {
+					sizeUnit = unit
+				}
+			case "label":
+				spec.Action.AddLabels = append(spec.Action.AddLabels, v)
+			case "smartLabelToApply":
+				if id, ok := gmailSmartLabels[v]; ok {
+					spec.Action.AddLabels = append(spec.Action.AddLabels, id)
+				}
+			case "forwardTo":
+				spec.Action.Forward = v
+			case "shouldArchive":
+				if isTrue {
+					spec.Action.RemoveLabels = append(spec.Action.RemoveLabels, "INBOX")
+				}
+			case "shouldMarkAsRead":
+				if isTrue {
+					spec.Action.RemoveLabels = append(spec.Action.RemoveLabels, "UNREAD")
+				}
+			case "shouldStar":
+				if isTrue {
+					spec.Action.AddLabels = append(spec.Action.AddLabels, "STARRED")
+				}
+			case "shouldTrash":
+				if isTrue {
+					spec.Action.AddLabels = append(spec.Action.AddLabels, "TRASH")
+				}
+			case "shouldNeverSpam":
+				if isTrue {
+					spec.Action.RemoveLabels = append(spec.Action.RemoveLabels, "SPAM")
+				}
+			case "shouldAlwaysMarkAsImportant":
+				if isTrue {
+					spec.Action.AddLabels = append(spec.Action.AddLabels, "IMPORTANT")
+				}
+			case "shouldNeverMarkAsImportant":
+				if isTrue {
+					spec.Action.RemoveLabels = append(spec.Action.RemoveLabels, "IMPORTANT")
+				}
+			}
+		}
+		spec.Criteria.Size *= sizeUnit
+		if err := spec.validate(); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+const testGmailMailFiltersXML = `<?xml version='1.0' encoding='UTF-8'?>
+<feed xmlns='http://www.w3.org/2005/Atom' xmlns:apps='http://schemas.google.com/apps/2006'>
+	<title>Mail Filters</title>
+	<entry>
+		<category term='filter'></category>
+		<apps:property name='from' value='news@example.com'/>
+		<apps:property name='label' value='News'/>
+		<apps:property name='shouldArchive' value='true'/>
+		<apps:property name='smartLabelToApply' value='^smartlabel_promo'/>
+	</entry>
+	<entry>
+		<apps:property name='hasTheWord' value='invoice'/>
+		<apps:property name='size' value='2'/>
+		<apps:property name='sizeOperator' value='s_sl'/>
+		<apps:property name='sizeUnit' value='s_smb'/>
+		<apps:property name='shouldStar' value='true'/>
+	</entry>
+</feed>`
+
+func TestParseGmailFiltersXML(t *testing.T) {
+	specs, err := parseGmailFiltersFile([]byte(testGmailMailFiltersXML))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(specs) != 2 {
+		t.Fatalf("expected 2 filters, got %+v", specs)
+	}
+	if got := specs[0].summary(); got != `from:"news@example.com" => +News +CATEGORY_PROMOTIONS -INBOX` {
+		t.Fatalf("unexpected first filter: %s", got)
+	}
+	c := specs[1].Criteria
+	if c.Query != "invoice" || c.Size != 2<<20 || c.SizeComparison != "larger" || specs[1].Action.AddLabels[0] != "STARRED" {
+		t.Fatalf("unexpected second filter: %+v", specs[1])
+	}
+}
+
+func TestParseGmailFiltersFile_Errors(t *testing.T) {
+	for _, bad := range []string{
+		"filters:\n  - action:\n      addLabels: [X]\n",
+		"filters:\n  - criteria:\n      from: a@example.com\n",
+		"filters:\n  - criteria:\n      form: a@example.com\n",
+	} {
+		if _, err := parseGmailFiltersFile([]byte(bad)); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestGmailFiltersExportApply(t *testing.T) {
+	live := []map[string]any{
+		{"id": "f1", "criteria": map[string]any{"from": "boss@example.com"}, "action": map[string]any{"addLabelIds": []string{"Label_1", "STARRED"}}},
+		{"id": "f2", "criteria": map[string]any{"subject": "old"}, "action": map[string]any{"removeLabelIds": []string{"INBOX"}}},
+	}
+	var created []gmail.Filter
+	var deleted, labelsCreated []string
+	setupGmailTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/users/me/labels") && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]any{"labels": []map[string]any{
+				{"id": "INBOX", "name": "INBOX"},
+				{"id": "STARRED", "name": "STARRED"},
+				{"id": "Label_1", "name": "Work"},
+			}})
+		case strings.HasSuffix(r.URL.Path, "/users/me/labels") && r.Method == http.MethodPost:
+			var l gmail.Label
+			_ = json.NewDecoder(r.Body).Decode(&l)
+			labelsCreated = append(labelsCreated, l.Name)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "Label_2", "name": l.Name})
+		case strings.HasSuffix(r.URL.Path, "/settings/filters") && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]any{"filter": live})
+		case strings.HasSuffix(r.URL.Path, "/settings/filters") && r.Method == http.MethodPost:
+			var f gmail.Filter
+			_ = json.NewDecoder(r.Body).Decode(&f)
+			created = append(created, f)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "new1"})
+		case strings.Contains(r.URL.Path, "/settings/filters/") && r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	exported := runDriveCmdJSON(t, &GmailFiltersExportCmd{}, nil)
+	filters, _ := exported["filters"].([]any)
+	if len(filters) != 2 {
+		t.Fatalf("unexpected export: %v", exported)
+	}
+	first, _ := filters[0].(map[string]any)["action"].(map[string]any)
+	if labels, _ := first["addLabels"].([]any); len(labels) != 2 || labels[0] != "Work" {
+		t.Fatalf("expected label names in export: %v", first)
+	}
+
+	// Same boss filter (labels in another order and case), a new one, f2 gone.
+	path := filepath.Join(t.TempDir(), "filters.yaml")
+	yamlFile := `filters:
+  - criteria:
+      from: boss@example.com
+    action:
+      addLabels: [STARRED, work]
+  - criteria:
+      to: lists@example.com
+    action:
+      addLabels: [Lists]
+      removeLabels: [INBOX]
+`
+	if err := os.WriteFile(path, []byte(yamlFile), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	dry := runDriveCmdJSON(t, &GmailFiltersApplyCmd{}, []string{path, "--prune", "--dry-run"})
+	if dry["unchanged"] != float64(1) || len(dry["created"].([]any)) != 1 || len(dry["deleted"].([]any)) != 1 || len(created)+len(deleted) != 0 {
+		t.Fatalf("unexpected dry run: %v", dry)
+	}
+
+	res := runDriveCmdJSON(t, &GmailFiltersApplyCmd{}, []string{path})
+	if len(created) != 1 || len(deleted) != 0 || res["extra"] != float64(1) {
+		t.Fatalf("unexpected apply: %v (created %+v deleted %v)", res, created, deleted)
+	}
+	if strings.Join(labelsCreated, ",") != "Lists" || created[0].Criteria.To != "lists@example.com" ||
+		strings.Join(created[0].Action.AddLabelIds, ",") != "Label_2" || strings.Join(created[0].Action.RemoveLabelIds, ",") != "INBOX" {
+		t.Fatalf("unexpected created filter: %+v labels %v", created[0], labelsCreated)
+	}
+
+	_ = runDriveCmdJSON(t, &GmailFiltersApplyCmd{}, []string{path, "--prune"})
+	if strings.Join(deleted, ",") != "f2" {
+		t.Fatalf("expected f2 pruned, got %v", deleted)
+	}
+}
@@ -0,0 +1,521 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+
+	"github.com/steipete/gogcli/internal/config"
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+const gmailImportProgressEvery = 100
+
+// gmailImportFolderAliases maps common IMAP/Outlook folder names onto Gmail
+// system labels.
+var gmailImportFolderAliases = map[string]string{
+	"inbox":         "INBOX",
+	"sent":          "SENT",
+	"sent items":    "SENT",
+	"sent mail":     "SENT",
+	"sent messages": "SENT",
+	"trash":         "TRASH",
+	"deleted items": "TRASH",
+	"deleted":       "TRASH",
+	"spam":          "SPAM",
+	"junk":          "SPAM",
+	"junk e-mail":   "SPAM",
+	"junk email":    "SPAM",
+}
+
+var mboxFromQuoted = regexp.MustCompile(`^>+From `)
+
+type GmailImportCmd struct {
+	Paths          []string `arg:"" name:"path" help:"mbox files, .eml files, Maildirs or directories containing them"`
+	Label          []string `name:"label" help:"Extra label(s) to add to every imported message (repeatable; created if missing)"`
+	NoFolderLabels bool     `name:"no-folder-labels" help:"Do not turn folder and mbox file names into labels"`
+	Insert         bool     `name:"insert" help:"Use messages.insert (skips spam and inbox classification) instead of messages.import"`
+	NoDedupe       bool     `name:"no-dedupe" help:"Import even if a message with the same Message-ID already exists"`
+	DryRun         bool     `name:"dry-run" help:"Parse and report what would be imported without uploading"`
+	Concurrency    int      `name:"concurrency" help:"Parallel uploads" default:"4"`
+}
+
+// gmailImportItem is one message read from a local source.
+type gmailImportItem struct {
+	Source  string
+	Folder  string
+	Raw     []byte
+	Unread  bool
+	Starred bool
+}
+
+type gmailImportFailure struct {
+	Source    string `json:"source"`
+	MessageID string `json:"messageId,omitempty"`
+	Error     string `json:"error"`
+}
+
+type gmailImportStats struct {
+	DryRun     bool                 `json:"dryRun"`
+	Imported   int                  `json:"imported"`
+	Duplicates int                  `json:"duplicates"`
+	Failed     int                  `json:"failed"`
+	Failures   []gmailImportFailure `json:"failures"`
+	Labels     map[string]string    `json:"labels"`
+}
+
+func (c *GmailImportCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	if len(c.Paths) == 0 {
+		return usage("missing path")
+	}
+	paths := make([]string, 0, len(c.Paths))
+	for _, p := range c.Paths {
+		expanded, expandErr := config.ExpandPath(strings.TrimSpace(p))
+		if expandErr != nil {
+			return expandErr
+		}
+		if _, statErr := os.Stat(expanded); statErr != nil {
+			return statErr
+		}
+		paths = append(paths, expanded)
+	}
+	concurrency := c.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	svc, err := newGmailService(ctx, account)
+	if err != nil {
+		return err
+	}
+	nameToID, err := fetchLabelNameToID(svc)
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu       sync.Mutex
+		seenIDs  = map[string]bool{}
+		inflight = map[string]chan struct{}{}
+		stats    = gmailImportStats{DryRun: c.DryRun, Failures: []gmailImportFailure{}, Labels: map[string]string{}}
+	)
+	fail := func(item gmailImportItem, msgID string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		stats.Failed++
+		stats.Failures = append(stats.Failures, gmailImportFailure{Source: item.Source, MessageID: msgID, Error: err.Error()})
+	}
+	progress := func() {
+		done := stats.Imported + stats.Duplicates + stats.Failed
+		if done%gmailImportProgressEvery != 0 {
+			return
+		}
+		if outfmt.IsJSON(ctx) {
+			line, _ := json.Marshal(map[string]any{"event": "progress", "imported": stats.Imported, "duplicates": stats.Duplicates, "failed": stats.Failed})
+			u.Err().Println(string(line))
+			return
+		}
+		u.Err().Printf("imported %d, duplicates %d, failed %d", stats.Imported, stats.Duplicates, stats.Failed)
+	}
+	labelsFor := func(item gmailImportItem) ([]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		var names []string
+		if !c.NoFolderLabels && item.Folder != "" {
+			names = append(names, gmailImportFolderLabel(item.Folder))
+		}
+		names = append(names, c.Label...)
+		ids := []string{}
+		for _, name := range names {
+			if strings.TrimSpace(name) == "" {
+				continue
+			}
+			if c.DryRun {
+				stats.Labels[name] = nameToID[strings.ToLower(name)]
+				continue
+			}
+			id, ensureErr := ensureLabelID(ctx, svc, nameToID, name)
+			if ensureErr != nil {
+				return nil, fmt.Errorf("label %q: %w", name, ensureErr)
+			}
+			stats.Labels[name] = id
+			ids = append(ids, id)
+		}
+		if item.Unread {
+			ids = append(ids, "UNREAD")
+		}
+		if item.Starred {
+			ids = append(ids, "STARRED")
+		}
+		return ids, nil
+	}
+
+	// claimID reports whether msgID is already in the mailbox (imported by
+	// this run or found there). Otherwise the caller owns msgID until it calls
+	// done with whether the message landed; other copies wait for that, so a
+	// failed import leaves the ID free for the next copy to try.
+	claimID := func(msgID string) (dup bool, done func(landed bool)) {
+		for {
+			mu.Lock()
+			if seenIDs[msgID] {
+				mu.Unlock()
+				return true, nil
+			}
+			wait, busy := inflight[msgID]
+			if !busy {
+				ch := make(chan struct{})
+				inflight[msgID] = ch
+				mu.Unlock()
+				return false, func(landed bool) {
+					mu.Lock()
+					if landed {
+						seenIDs[msgID] = true
+					}
+					delete(inflight, msgID)
+					mu.Unlock()
+					close(ch)
+				}
+			}
+			mu.Unlock()
+			<-wait
+		}
+	}
+	duplicate := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		stats.Duplicates++
+		progress()
+	}
+	importItem := func(item gmailImportItem, msgID string) bool {
+		labelIDs, labelErr := labelsFor(item)
+		if labelErr != nil {
+			fail(item, msgID, labelErr)
+			return false
+		}
+		if !c.DryRun {
+			if uploadErr := c.upload(ctx, svc, item.Raw, labelIDs); uploadErr != nil {
+				fail(item, msgID, uploadErr)
+				return false
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		stats.Imported++
+		progress()
+		return true
+	}
+
+	jobs := make(chan gmailImportItem)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range jobs {
+				msgID := gmailImportMessageID(item.Raw)
+				if msgID == "" || c.NoDedupe {
+					importItem(item, msgID)
+					continue
+				}
+				dup, done := claimID(msgID)
+				if dup {
+					duplicate()
+					continue
+				}
+				if !c.DryRun {
+					exists, checkErr := gmailMessageIDExists(ctx, svc, msgID)
+					if checkErr != nil {
+						done(false)
+						fail(item, msgID, checkErr)
+						continue
+					}
+					if exists {
+						done(true)
+						duplicate()
+						continue
+					}
+				}
+				done(importItem(item, msgID))
+			}
+		}()
+	}
+
+	var readErr error
+	for _, p := range paths {
+		readErr = readGmailImportSources(ctx, p, func(item gmailImportItem) error {
+			select {
+			case jobs <- item:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if readErr != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if readErr != nil {
+		return readErr
+	}
+
+	if outfmt.IsJSON(ctx) {
+		if err := outfmt.WriteJSON(os.Stdout, stats); err != nil {
+			return err
+		}
+	} else {
+		u.Out().Printf("imported\t%d", stats.Imported)
+		u.Out().Printf("duplicates\t%d", stats.Duplicates)
+		u.Out().Printf("failed\t%d", stats.Failed)
+		for _, f := range stats.Failures {
+			u.Err().Printf("%s: %s", f.Source, f.Error)
+		}
+	}
+
+	if stats.Failed > 0 {
+		return fmt.Errorf("%d messages failed to import; rerun to retry (duplicates are skipped)", stats.Failed)
+	}
+	return nil
+}
+
+// upload sends raw as a media upload so large messages are not limited by the
+// JSON request size. Dates come from the Date header.
+func (c *GmailImportCmd) upload(ctx context.Context, svc *gmail.Service, raw []byte, labelIDs []string) error {
+	msg := &gmail.Message{LabelIds: labelIDs}
+	media := googleapi.ContentType("message/rfc822")
+	if c.Insert {
+		_, err := svc.Users.Messages.Insert("me", msg).
+			Media(bytes.NewReader(raw), media).
+			InternalDateSource("dateHeader").
+			Context(ctx).
+			Do()
+		return err
+	}
+	_, err := svc.Users.Messages.Import("me", msg).
+		Media(bytes.NewReader(raw), media).
+		InternalDateSource("dateHeader").
+		NeverMarkSpam(true).
+		Context(ctx).
+		Do()
+	return err
+}
+
+// gmailImportFolderLabel turns a folder path into a label name, mapping
+// well-known folder names to system labels and Maildir++ ".A.B" names to
+// "A/B".
+func gmailImportFolderLabel(folder string) string {
+	folder = filepath.ToSlash(folder)
+	if strings.HasPrefix(folder, ".") && !strings.Contains(folder, "/") {
+		folder = strings.ReplaceAll(strings.TrimPrefix(folder, "."), ".", "/")
+	}
+	if system, ok := gmailImportFolderAliases[strings.ToLower(folder)]; ok {
+		return system
+	}
+	return folder
+}
+
+// gmailImportMessageID returns the Message-ID header without angle brackets.
+func gmailImportMessageID(raw []byte) string {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return ""
+	}
+	return strings.Trim(strings.TrimSpace(msg.Header.Get("Message-ID")), "<>")
+}
+
+func gmailMessageIDExists(ctx context.Context, svc *gmail.Service, msgID string) (bool, error) {
+	resp, err := svc.Users.Messages.List("me").
+		Q("rfc822msgid:" + msgID).
+		IncludeSpamTrash(true).
+		MaxResults(1).
+		Fields("messages(id)").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return false, err
+	}
+	return len(resp.Messages) > 0, nil
+}
+
+// readGmailImportSources walks root and calls fn for every message found.
+// Folder names are relative to root; a single mbox file is labeled after its
+// name.
+func readGmailImportSources(ctx context.Context, root string, fn func(gmailImportItem) error) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		if strings.EqualFold(filepath.Ext(root), ".eml") {
+			return readGmailImportEML(root, "", fn)
+		}
+		return readGmailImportMbox(root, strings.TrimSuffix(filepath.Base(root), filepath.Ext(root)), fn)
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		rel, relErr := filepath.Rel(root, path)
+		if relErr != nil {
+			return relErr
+		}
+		if rel == "." {
+			rel = ""
+		}
+		if d.IsDir() {
+			if isMaildir(path) {
+				if err := readGmailImportMaildir(path, rel, fn); err != nil {
+					return err
+				}
+			}
+			switch d.Name() {
+			case "cur", "new", "tmp":
+				if isMaildir(filepath.Dir(path)) {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".eml":
+			dir := filepath.Dir(rel)
+			if dir == "." {
+				dir = ""
+			}
+			return readGmailImportEML(path, dir, fn)
+		case ".mbox", ".mbx":
+			return readGmailImportMbox(path, strings.TrimSuffix(rel, filepath.Ext(rel)), fn)
+		}
+		return nil
+	})
+}
+
+func isMaildir(dir string) bool {
+	for _, sub := range []string{"cur", "new"} {
+		if info, err := os.Stat(filepath.Join(dir, sub)); err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+func readGmailImportEML(path string, folder string, fn func(gmailImportItem) error) error {
+	raw, err := os.ReadFile(path) //nolint:gosec // user-provided import path
+	if err != nil {
+		return err
+	}
+	return fn(gmailImportItem{Source: path, Folder: folder, Raw: raw})
+}
+
+// readGmailImportMaildir reads cur/ and new/. Messages in new/ or without the
+// S (seen) flag are imported as unread; F (flagged) becomes STARRED.
+func readGmailImportMaildir(dir string, folder string, fn func(gmailImportItem) error) error {
+	for _, sub := range []string{"cur", "new"} {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			path := filepath.Join(dir, sub, e.Name())
+			raw, err := os.ReadFile(path) //nolint:gosec // user-provided import path
+			if err != nil {
+				return err
+			}
+			_, info, _ := strings.Cut(e.Name(), ":2,")
+			if err := fn(gmailImportItem{
+				Source:  path,
+				Folder:  folder,
+				Raw:     raw,
+				Unread:  sub == "new" || !strings.Contains(info, "S"),
+				Starred: strings.Contains(info, "F"),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readGmailImportMbox streams messages out of an mbox file, undoing mboxrd
+// ">From " quoting.
+func readGmailImportMbox(path string, folder string, fn func(gmailImportItem) error) error {
+	f, err := os.Open(path) //nolint:gosec // user-provided import path
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	var (
+		buf     bytes.Buffer
+		index   int
+		started bool
+		prevBlk = true
+	)
+	flush := func() error {
+		if !started {
+			return nil
+		}
+		index++
+		raw := bytes.TrimRight(buf.Bytes(), "\n")
+		msg := append(make([]byte, 0, len(raw)+1), raw...)
+		buf.Reset()
+		if len(msg) == 0 {
+			return nil
+		}
+		return fn(gmailImportItem{Source: fmt.Sprintf("%s#%d", path, index), Folder: folder, Raw: append(msg, '\n')})
+	}
+	for {
+		line, readErr := r.ReadBytes('\n')
+		if len(line) > 0 {
+			trimmed := bytes.TrimRight(line, "\r\n")
+			switch {
+			case prevBlk && bytes.HasPrefix(trimmed, []byte("From ")):
+				if err := flush(); err != nil {
+					return err
+				}
+				started = true
+			case started:
+				if mboxFromQuoted.Match(trimmed) {
+					line = line[1:]
+				}
+				buf.Write(line)
+			}
+			prevBlk = len(trimmed) == 0
+		}
+		if readErr != nil {
+			if errors.Is(readErr, io.EOF) {
+				return flush()
+			}
+			return readErr
+		}
+	}
+}
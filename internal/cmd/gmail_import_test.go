@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+func TestReadGmailImportMbox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Old Project.mbox")
+	mbox := "From a@b Mon Jan  1 00:00:00 2024\nMessage-ID: <1@x>\n\n>From the top\nbody\n\n" +
+		"From c@d Tue Jan  2 00:00:00 2024\nMessage-ID: <2@x>\n\n>>From quoted\n"
+	if err := os.WriteFile(path, []byte(mbox), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []gmailImportItem
+	err := readGmailImportSources(context.Background(), path, func(it gmailImportItem) error {
+		items = append(items, it)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(items) != 2 || items[0].Folder != "Old Project" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+	if string(items[0].Raw) != "Message-ID: <1@x>\n\nFrom the top\nbody\n" || string(items[1].Raw) != "Message-ID: <2@x>\n\n>From quoted\n" {
+		t.Fatalf("unexpected bodies: %q / %q", items[0].Raw, items[1].Raw)
+	}
+	if gmailImportMessageID(items[1].Raw) != "2@x" {
+		t.Fatalf("message id = %q", gmailImportMessageID(items[1].Raw))
+	}
+}
+
+func TestReadGmailImportSources_Tree(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, data string) {
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("Maildir/cur/1.host:2,FS", "Subject: a\n\n")
+	write("Maildir/new/2.host", "Subject: b\n\n")
+	write("Maildir/.Clients.Acme/cur/3.host:2,S", "Subject: c\n\n")
+	_ = os.MkdirAll(filepath.Join(root, "Maildir", ".Clients.Acme", "new"), 0o700)
+	write("Projects/X/note.eml", "Subject: d\n\n")
+
+	var got []string
+	err := readGmailImportSources(context.Background(), root, func(it gmailImportItem) error {
+		got = append(got, gmailImportFolderLabel(it.Folder)+"|"+strings.TrimSpace(string(it.Raw))+"|"+map[bool]string{true: "unread", false: "read"}[it.Unread]+map[bool]string{true: "+star", false: ""}[it.Starred])
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	sort.Strings(got)
+	want := []string{
+		"Maildir/.Clients.Acme|Subject: c|read",
+		"Maildir|Subject: a|read+star",
+		"Maildir|Subject: b|unread",
+		"Projects/X|Subject: d|read",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if gmailImportFolderLabel(".Clients.Acme") != "Clients/Acme" || gmailImportFolderLabel("Sent Items") != "SENT" {
+		t.Fatalf("unexpected folder label mapping")
+	}
+}
+
+func TestGmailImport_LabelsAndDedupe(t *testing.T) {
+	dir := t.TempDir()
+	eml := func(name, msgID string) {
+		data := "Message-ID: <" + msgID + ">\r\nDate: Mon, 1 Jan 2018 10:00:00 +0000\r\nSubject: " + name + "\r\n\r\nhi\r\n"
+		if err := os.MkdirAll(filepath.Join(dir, "Clients", "Acme"), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "Clients", "Acme", name+".eml"), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eml("new", "new@x")
+	eml("existing", "existing@x")
+
+	var (
+		mu       sync.Mutex
+		created  []string
+		imported []string
+	)
+	setupGmailTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/users/me/labels") && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]any{"labels": []map[string]any{{"id": "INBOX", "name": "INBOX"}}})
+		case strings.HasSuffix(r.URL.Path, "/users/me/labels") && r.Method == http.MethodPost:
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			name, _ := body["name"].(string)
+			mu.Lock()
+			created = append(created, name)
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "Label_" + strings.ReplaceAll(name, "/", "_"), "name": name})
+		case strings.HasSuffix(r.URL.Path, "/users/me/messages") && r.Method == http.MethodGet:
+			if r.URL.Query().Get("q") == "rfc822msgid:existing@x" {
+				_ = json.NewEncoder(w).Encode(map[string]any{"messages": []map[string]any{{"id": "old"}}})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{})
+		case strings.HasSuffix(r.URL.Path, "/users/me/messages/import"):
+			if r.URL.Query().Get("internalDateSource") != "dateHeader" {
+				t.Errorf("dates not preserved: %v", r.URL.Query())
+			}
+			meta, raw := readGmailImportUpload(t, r)
+			mu.Lock()
+			imported = append(imported, strings.Join(meta.LabelIds, ",")+"|"+gmailImportMessageID(raw))
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "m1"})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	parsed := runDriveCmdJSON(t, &GmailImportCmd{}, []string{dir, "--label", "Migrated"})
+	if parsed["imported"] != float64(1) || parsed["duplicates"] != float64(1) || parsed["failed"] != float64(0) {
+		t.Fatalf("unexpected result: %v", parsed)
+	}
+	if strings.Join(created, ",") != "Clients,Clients/Acme,Migrated" {
+		t.Fatalf("unexpected labels created: %v", created)
+	}
+	if len(imported) != 1 || imported[0] != "Label_Clients_Acme,Label_Migrated|new@x" {
+		t.Fatalf("unexpected imports: %v", imported)
+	}
+}
+
+func TestGmailImport_FailedCopyDoesNotHideDuplicate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dupes.mbox")
+	msg := "Message-ID: <same@x>\nSubject: hi\n\nbody\n\n"
+	mbox := "From a@b Mon Jan  1 00:00:00 2024\n" + msg + "From a@b Mon Jan  1 00:00:00 2024\n" + msg
+	if err := os.WriteFile(path, []byte(mbox), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu      sync.Mutex
+		uploads int
+	)
+	setupGmailTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/users/me/labels"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"labels": []map[string]any{}})
+		case strings.HasSuffix(r.URL.Path, "/users/me/messages"):
+			_ = json.NewEncoder(w).Encode(map[string]any{})
+		case strings.HasSuffix(r.URL.Path, "/users/me/messages/import"):
+			mu.Lock()
+			uploads++
+			first := uploads == 1
+			mu.Unlock()
+			if first {
+				http.Error(w, `{"error":{"code":400,"message":"bad"}}`, http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "m1"})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := outfmt.WithMode(ui.WithUI(context.Background(), u), outfmt.Mode{JSON: true})
+	out := captureStdout(t, func() {
+		err = runKong(t, &GmailImportCmd{}, []string{path, "--no-folder-labels"}, ctx, &RootFlags{Account: "a@b.com"})
+	})
+	if err == nil {
+		t.Fatalf("expected the failed copy to be reported")
+	}
+	var parsed map[string]any
+	if jsonErr := json.Unmarshal([]byte(out), &parsed); jsonErr != nil {
+		t.Fatalf("json: %v\n%s", jsonErr, out)
+	}
+	// The second copy is uploaded instead of being counted as a duplicate of
+	// the copy that failed.
+	if parsed["imported"] != float64(1) || parsed["duplicates"] != float64(0) || parsed["failed"] != float64(1) || uploads != 2 {
+		t.Fatalf("unexpected result: %v (uploads %d)", parsed, uploads)
+	}
+}
+
+type gmailImportUploadMeta struct {
+	LabelIds []string `json:"labelIds"`
+}
+
+func readGmailImportUpload(t *testing.T, r *http.Request) (gmailImportUploadMeta, []byte) {
+	t.Helper()
+
+	var meta gmailImportUploadMeta
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("content type: %v", err)
+	}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+	part, err := mr.NextPart()
+	if err != nil {
+		t.Fatalf("meta part: %v", err)
+	}
+	if err := json.NewDecoder(part).Decode(&meta); err != nil {
+		t.Fatalf("meta: %v", err)
+	}
+	part, err = mr.NextPart()
+	if err != nil {
+		t.Fatalf("media part: %v", err)
+	}
+	raw, _ := io.ReadAll(part)
+	return meta, raw
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
@@ -41,6 +42,53 @@ func ensureLabelNameAvailable(svc *gmail.Service, name string) error {
 	return nil
 }
 
+// ensureLabelID returns the ID of the label called name, creating it (and any
+// missing parents of a nested "a/b/c" label) if needed. nameToID is the cache
+// from fetchLabelNameToID and is updated in place.
+func ensureLabelID(ctx context.Context, svc *gmail.Service, nameToID map[string]string, name string) (string, error) {
+	name = strings.Trim(strings.TrimSpace(name), "/")
+	if name == "" {
+		return "", errors.New("empty label name")
+	}
+	if id, ok := nameToID[strings.ToLower(name)]; ok {
+		return id, nil
+	}
+	if parent, _, ok := cutLastLabelSegment(name); ok {
+		if _, err := ensureLabelID(ctx, svc, nameToID, parent); err != nil {
+			return "", err
+		}
+	}
+	label, err := createLabel(ctx, svc, name)
+	if err != nil {
+		if !isDuplicateLabelError(err) {
+			return "", err
+		}
+		// Created concurrently or differing only in case; reload.
+		fresh, fetchErr := fetchLabelNameToID(svc)
+		if fetchErr != nil {
+			return "", fetchErr
+		}
+		for k, v := range fresh {
+			nameToID[k] = v
+		}
+		if id, ok := nameToID[strings.ToLower(name)]; ok {
+			return id, nil
+		}
+		return "", err
+	}
+	nameToID[strings.ToLower(label.Name)] = label.Id
+	nameToID[strings.ToLower(label.Id)] = label.Id
+	return label.Id, nil
+}
+
+func cutLastLabelSegment(name string) (string, string, bool) {
+	i := strings.LastIndex(name, "/")
+	if i <= 0 {
+		return "", name, false
+	}
+	return name[:i], name[i+1:], true
+}
+
 func mapLabelCreateError(err error, name string) error {
 	if err == nil {
 		return nil
@@ -0,0 +1,575 @@
+package cmd
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// markdownToHTML renders the Markdown people write in emails (CommonMark
+// blocks and inlines plus GFM tables and strikethrough) to an HTML fragment.
+// Raw HTML in the source is escaped rather than passed through and only
+// http(s), mailto and tel links survive, so the result is safe to send as is.
+func markdownToHTML(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+	var b strings.Builder
+	renderMarkdownBlocks(&b, strings.Split(src, "\n"), false)
+	return b.String()
+}
+
+var (
+	mdHeadingPattern   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ ]+(.*?))?(?:[ ]+#+)?[ ]*$`)
+	mdRulePattern      = regexp.MustCompile(`^ {0,3}([-*_])(?:[ ]*([-*_]))+[ ]*$`)
+	mdListItemPattern  = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])( +|$)(.*)$`)
+	mdFencePattern     = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})(.*)$")
+	mdSetextPattern    = regexp.MustCompile(`^ {0,3}(=+|-+)[ ]*$`)
+	mdTableSepPattern  = regexp.MustCompile(`^ *\|? *:?-+:? *(\| *:?-+:? *)*\|? *$`)
+	mdEntityPattern    = regexp.MustCompile(`^&(#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+	mdAutolinkPattern  = regexp.MustCompile(`^<((?:https?|mailto|tel):[^\s<>]+|[^\s<>@]+@[^\s<>@]+\.[^\s<>@]+)>`)
+	mdBareURLPattern   = regexp.MustCompile(`^https?://[^\s<]+`)
+	mdTableCellPattern = regexp.MustCompile(`^:?-+:?$`)
+)
+
+func isMarkdownRule(line string) bool {
+	m := mdRulePattern.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	c := m[1]
+	return strings.Count(line, c) >= 3 && strings.Trim(strings.ReplaceAll(line, " ", ""), c) == ""
+}
+
+// startsMarkdownBlock reports whether line interrupts a paragraph.
+func startsMarkdownBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" ||
+		mdHeadingPattern.MatchString(line) ||
+		mdFencePattern.MatchString(line) ||
+		strings.HasPrefix(strings.TrimLeft(line, " "), ">") ||
+		isMarkdownRule(line) ||
+		mdListItemPattern.MatchString(line) && strings.TrimSpace(mdListItemPattern.FindStringSubmatch(line)[4]) != ""
+}
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// renderMarkdownBlocks renders lines as block content. In tight mode (items
+// of a tight list) paragraphs are not wrapped in <p>.
+func renderMarkdownBlocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+
+		case mdFencePattern.MatchString(line):
+			m := mdFencePattern.FindStringSubmatch(line)
+			fence := m[1]
+			indent := leadingSpaces(line)
+			i++
+			var code []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == "" {
+					i++
+					break
+				}
+				code = append(code, strings.TrimPrefix(lines[i], strings.Repeat(" ", min(indent, leadingSpaces(lines[i])))))
+			}
+			b.WriteString(`<pre style="background:#f6f8fa;padding:8px;overflow:auto"><code>`)
+			b.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			b.WriteString("</code></pre>\n")
+
+		case mdHeadingPattern.MatchString(line):
+			m := mdHeadingPattern.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + renderMarkdownInline(strings.TrimSpace(m[2])) + "</h" + level + ">\n")
+			i++
+
+		case isMarkdownRule(line):
+			b.WriteString("<hr>\n")
+			i++
+
+		case strings.HasPrefix(strings.TrimLeft(line, " "), ">"):
+			var inner []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimLeft(lines[i], " ")
+				if strings.HasPrefix(t, ">") {
+					t = strings.TrimPrefix(t, ">")
+					inner = append(inner, strings.TrimPrefix(t, " "))
+					continue
+				}
+				// Lazy continuation of a quoted paragraph.
+				if strings.TrimSpace(lines[i]) != "" && len(inner) > 0 && strings.TrimSpace(inner[len(inner)-1]) != "" && !startsMarkdownBlock(lines[i]) {
+					inner = append(inner, lines[i])
+					continue
+				}
+				break
+			}
+			b.WriteString(`<blockquote style="margin:0 0 0 .8ex;border-left:3px solid #ccc;padding-left:1ex;color:#555">` + "\n")
+			renderMarkdownBlocks(b, inner, false)
+			b.WriteString("</blockquote>\n")
+
+		case mdListItemPattern.MatchString(line):
+			i = renderMarkdownList(b, lines, i)
+
+		case i+1 < len(lines) && strings.Contains(line, "|") && mdTableSepPattern.MatchString(lines[i+1]) &&
+			len(splitMarkdownTableRow(line)) == len(splitMarkdownTableRow(lines[i+1])):
+			i = renderMarkdownTable(b, lines, i)
+
+		default:
+			para := []string{line}
+			i++
+			level := 0
+			for ; i < len(lines); i++ {
+				if m := mdSetextPattern.FindStringSubmatch(lines[i]); m != nil {
+					level = 2
+					if m[1][0] == '=' {
+						level = 1
+					}
+					i++
+					break
+				}
+				if startsMarkdownBlock(lines[i]) {
+					break
+				}
+				para = append(para, lines[i])
+			}
+			for j := range para {
+				para[j] = strings.TrimLeft(para[j], " ")
+			}
+			text := renderMarkdownInline(strings.TrimRight(strings.Join(para, "\n"), " "))
+			switch {
+			case level > 0:
+				tag := "h" + strconv.Itoa(level)
+				b.WriteString("<" + tag + ">" + text + "</" + tag + ">\n")
+			case tight:
+				b.WriteString(text + "\n")
+			default:
+				b.WriteString("<p>" + text + "</p>\n")
+			}
+		}
+	}
+}
+
+// renderMarkdownList renders the list starting at lines[start] and returns
+// the index of the first line after it.
+func renderMarkdownList(b *strings.Builder, lines []string, start int) int {
+	first := mdListItemPattern.FindStringSubmatch(lines[start])
+	ordered := first[2][0] >= '0' && first[2][0] <= '9'
+	marker := first[2][len(first[2])-1:]
+
+	type item struct{ lines []string }
+	var (
+		items  []item
+		loose  bool
+		offset int
+		i      = start
+	)
+	for i < len(lines) {
+		line := lines[i]
+		if m := mdListItemPattern.FindStringSubmatch(line); m != nil && (len(items) == 0 || leadingSpaces(line) < offset) {
+			isOrdered := m[2][0] >= '0' && m[2][0] <= '9'
+			if isOrdered != ordered || m[2][len(m[2])-1:] != marker || isMarkdownRule(line) {
+				break
+			}
+			pad := len(m[3])
+			if pad > 4 || m[4] == "" {
+				pad = 1
+			}
+			offset = len(m[1]) + len(m[2]) + pad
+			items = append(items, item{lines: []string{m[4]}})
+			i++
+			continue
+		}
+
+		cur := &items[len(items)-1]
+		if strings.TrimSpace(line) == "" {
+			next := i + 1
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next >= len(lines) {
+				i = next
+				break
+			}
+			if leadingSpaces(lines[next]) >= offset {
+				cur.lines = append(cur.lines, "")
+				loose = true
+				i = next
+				continue
+			}
+			if m := mdListItemPattern.FindStringSubmatch(lines[next]); m != nil && leadingSpaces(lines[next]) < offset {
+				loose = true
+				i = next
+				continue
+			}
+			break
+		}
+		if leadingSpaces(line) >= offset {
+			cur.lines = append(cur.lines, line[offset:])
+			i++
+			continue
+		}
+		// Lazy paragraph continuation.
+		if last := cur.lines[len(cur.lines)-1]; strings.TrimSpace(last) != "" && !startsMarkdownBlock(line) {
+			cur.lines = append(cur.lines, strings.TrimLeft(line, " "))
+			i++
+			continue
+		}
+		break
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+		if n, err := strconv.Atoi(strings.TrimRight(first[2], ".)")); err == nil && n != 1 {
+			tag = `ol start="` + strconv.Itoa(n) + `"`
+		}
+	}
+	b.WriteString("<" + tag + ">\n")
+	for _, it := range items {
+		b.WriteString("<li>")
+		renderMarkdownBlocks(b, it.lines, !loose)
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + strings.Fields(tag)[0] + ">\n")
+	return i
+}
+
+func splitMarkdownTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var (
+		cells []string
+		cur   strings.Builder
+	)
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cur.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cur.String()))
+}
+
+func renderMarkdownTable(b *strings.Builder, lines []string, start int) int {
+	header := splitMarkdownTableRow(lines[start])
+	aligns := make([]string, len(header))
+	for i, cell := range splitMarkdownTableRow(lines[start+1]) {
+		if !mdTableCellPattern.MatchString(cell) {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			aligns[i] = "center"
+		case strings.HasSuffix(cell, ":"):
+			aligns[i] = "right"
+		case strings.HasPrefix(cell, ":"):
+			aligns[i] = "left"
+		}
+	}
+
+	cell := func(tag string, i int, text string) string {
+		style := "border:1px solid #ddd;padding:4px 8px"
+		if aligns[i] != "" {
+			style += ";text-align:" + aligns[i]
+		}
+		return "<" + tag + ` style="` + style + `">` + renderMarkdownInline(text) + "</" + tag + ">"
+	}
+
+	b.WriteString(`<table style="border-collapse:collapse">` + "\n<thead><tr>")
+	for i, h := range header {
+		b.WriteString(cell("th", i, h))
+	}
+	b.WriteString("</tr></thead>\n<tbody>\n")
+	i := start + 2
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|"); i++ {
+		row := splitMarkdownTableRow(lines[i])
+		b.WriteString("<tr>")
+		for j := range header {
+			text := ""
+			if j < len(row) {
+				text = row[j]
+			}
+			b.WriteString(cell("td", j, text))
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	return i
+}
+
+func safeMarkdownURL(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	lower := strings.ToLower(raw)
+	for _, scheme := range []string{"http://", "https://", "mailto:", "tel:"} {
+		if strings.HasPrefix(lower, scheme) {
+			return raw, true
+		}
+	}
+	return "", false
+}
+
+func isMarkdownPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isMarkdownWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// renderMarkdownInline renders inline Markdown; all literal text is escaped.
+func renderMarkdownInline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			b.WriteString("<br>\n")
+			i += 2
+
+		case c == '\\' && i+1 < len(s) && isMarkdownPunct(s[i+1]):
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+
+		case c == ' ' && strings.HasPrefix(strings.TrimLeft(s[i:], " "), "\n") && len(s[i:])-len(strings.TrimLeft(s[i:], " ")) >= 2:
+			b.WriteString("<br>")
+			i += len(s[i:]) - len(strings.TrimLeft(s[i:], " "))
+
+		case c == '`':
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			fence := s[i : i+n]
+			end := -1
+			for j := i + n; j < len(s); {
+				k := strings.Index(s[j:], fence)
+				if k < 0 {
+					break
+				}
+				k += j
+				run := len(s[k:]) - len(strings.TrimLeft(s[k:], "`"))
+				if run == n {
+					end = k
+					break
+				}
+				j = k + run
+			}
+			if end < 0 {
+				b.WriteString(fence)
+				i += n
+				continue
+			}
+			code := strings.ReplaceAll(s[i+n:end], "\n", " ")
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+				code = code[1 : len(code)-1]
+			}
+			b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+			i = end + n
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if text, dest, n, ok := parseMarkdownLink(s[i+1:]); ok {
+				u, safe := safeMarkdownURL(dest)
+				if !safe && strings.HasPrefix(strings.ToLower(strings.TrimSpace(dest)), "cid:") {
+					u, safe = strings.TrimSpace(dest), true // an --inline image
+				}
+				if safe {
+					b.WriteString(`<img src="` + html.EscapeString(u) + `" alt="` + html.EscapeString(text) + `">`)
+				} else {
+					b.WriteString(html.EscapeString(text))
+				}
+				i += 1 + n
+				continue
+			}
+			b.WriteString("!")
+			i++
+
+		case c == '[':
+			if text, dest, n, ok := parseMarkdownLink(s[i:]); ok {
+				if u, safe := safeMarkdownURL(dest); safe {
+					b.WriteString(`<a href="` + html.EscapeString(u) + `">` + renderMarkdownInline(text) + "</a>")
+				} else {
+					b.WriteString(renderMarkdownInline(text))
+				}
+				i += n
+				continue
+			}
+			b.WriteString("[")
+			i++
+
+		case c == '<':
+			if m := mdAutolinkPattern.FindStringSubmatch(s[i:]); m != nil {
+				href := m[1]
+				if !strings.Contains(href, ":") {
+					href = "mailto:" + href
+				}
+				b.WriteString(`<a href="` + html.EscapeString(href) + `">` + html.EscapeString(m[1]) + "</a>")
+				i += len(m[0])
+				continue
+			}
+			b.WriteString("&lt;")
+			i++
+
+		case c == 'h' && (i == 0 || !isMarkdownWordByte(s[i-1])) && mdBareURLPattern.MatchString(s[i:]):
+			u := strings.TrimRight(mdBareURLPattern.FindString(s[i:]), ".,;:!?*_~'\"")
+			if strings.HasSuffix(u, ")") && strings.Count(u, "(") < strings.Count(u, ")") {
+				u = u[:len(u)-1]
+			}
+			b.WriteString(`<a href="` + html.EscapeString(u) + `">` + html.EscapeString(u) + "</a>")
+			i += len(u)
+
+		case c == '&':
+			if m := mdEntityPattern.FindString(s[i:]); m != "" && html.UnescapeString(m) != m {
+				b.WriteString(html.EscapeString(html.UnescapeString(m)))
+				i += len(m)
+				continue
+			}
+			b.WriteString("&amp;")
+			i++
+
+		case c == '*' || c == '_' || c == '~':
+			if out, n, ok := renderMarkdownEmphasis(s, i); ok {
+				b.WriteString(out)
+				i += n
+				continue
+			}
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], string(c)))
+			b.WriteString(s[i : i+n])
+			i += n
+
+		default:
+			j := i + 1
+			for j < len(s) && strings.IndexByte("\\ `![<h&*_~", s[j]) < 0 {
+				j++
+			}
+			b.WriteString(html.EscapeString(s[i:j]))
+			i = j
+		}
+	}
+	return b.String()
+}
+
+// parseMarkdownLink parses `[text](dest "title")` at the start of s and
+// returns the text, destination and consumed length.
+func parseMarkdownLink(s string) (string, string, int, bool) {
+	depth := 0
+	closeText := -1
+	for i := 0; i < len(s) && closeText < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closeText = i
+			}
+		}
+	}
+	if closeText < 0 || closeText+1 >= len(s) || s[closeText+1] != '(' {
+		return "", "", 0, false
+	}
+	rest := s[closeText+2:]
+	end := -1
+	parens := 0
+	for i := 0; i < len(rest); i++ {
+		if rest[i] == '(' {
+			parens++
+		} else if rest[i] == ')' {
+			if parens == 0 {
+				end = i
+				break
+			}
+			parens--
+		} else if rest[i] == '\n' {
+			break
+		}
+	}
+	if end < 0 {
+		return "", "", 0, false
+	}
+	dest := strings.TrimSpace(rest[:end])
+	if strings.HasPrefix(dest, "<") {
+		if k := strings.Index(dest, ">"); k > 0 {
+			dest = dest[1:k]
+		}
+	} else if k := strings.IndexAny(dest, " \n"); k >= 0 {
+		dest = dest[:k] // drop the title
+	}
+	return s[1:closeText], dest, closeText + 2 + end + 1, true
+}
+
+// renderMarkdownEmphasis handles *em*, **strong**, ***both***, the same with
+// underscores (not inside words), and ~~strikethrough~~ starting at s[i].
+func renderMarkdownEmphasis(s string, i int) (string, int, bool) {
+	c := s[i]
+	run := len(s[i:]) - len(strings.TrimLeft(s[i:], string(c)))
+	if c == '~' && run != 2 {
+		return "", 0, false
+	}
+	if run > 3 {
+		return "", 0, false
+	}
+	start := i + run
+	if start >= len(s) || s[start] == ' ' || s[start] == '\n' {
+		return "", 0, false
+	}
+	if c == '_' && i > 0 && isMarkdownWordByte(s[i-1]) {
+		return "", 0, false
+	}
+
+	for j := start; j < len(s); {
+		switch s[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			n := len(s[j:]) - len(strings.TrimLeft(s[j:], "`"))
+			if k := strings.Index(s[j+n:], s[j:j+n]); k >= 0 {
+				j += n + k + n
+				continue
+			}
+			j += n
+			continue
+		case c:
+			n := len(s[j:]) - len(strings.TrimLeft(s[j:], string(c)))
+			if n == run && s[j-1] != ' ' && s[j-1] != '\n' && (c != '_' || j+n >= len(s) || !isMarkdownWordByte(s[j+n])) {
+				inner := renderMarkdownInline(s[start:j])
+				var out string
+				switch {
+				case c == '~':
+					out = "<del>" + inner + "</del>"
+				case run == 1:
+					out = "<em>" + inner + "</em>"
+				case run == 2:
+					out = "<strong>" + inner + "</strong>"
+				default:
+					out = "<strong><em>" + inner + "</em></strong>"
+				}
+				return out, j + n - i, true
+			}
+			j += n
+			continue
+		}
+		j++
+	}
+	return "", 0, false
+}
+
+// markdownBodies returns the plain-text and HTML parts for a Markdown body.
+// The Markdown source is already readable, so it doubles as the text part.
+func markdownBodies(md string) (string, string) {
+	return md, markdownToHTML(md)
+}
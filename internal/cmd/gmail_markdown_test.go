@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	cases := []struct{ name, in, want string }{
+		{"heading", "# Hi *there*", "<h1>Hi <em>there</em></h1>\n"},
+		{"setext", "Title\n=====", "<h1>Title</h1>\n"},
+		{"paragraph", "one\ntwo  \nthree", "<p>one\ntwo<br>\nthree</p>\n"},
+		{"strong and code", "**bold** and `a<b>`", "<p><strong>bold</strong> and <code>a&lt;b&gt;</code></p>\n"},
+		{"snake case", "use snake_case_names", "<p>use snake_case_names</p>\n"},
+		{"strike", "~~old~~ new", "<p><del>old</del> new</p>\n"},
+		{"link", "[docs](https://example.com/a?b=1&c=2 \"t\")", `<p><a href="https://example.com/a?b=1&amp;c=2">docs</a></p>` + "\n"},
+		{"inline image", "![logo](cid:logo)", `<p><img src="cid:logo" alt="logo"></p>` + "\n"},
+		{"unsafe link", "[x](javascript:alert(1))", "<p>x</p>\n"},
+		{"bare url", "see https://example.com/x.", `<p>see <a href="https://example.com/x">https://example.com/x</a>.</p>` + "\n"},
+		{"raw html", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"entity", "&copy; &nope", "<p>© &amp;nope</p>\n"},
+		{"rule", "a\n\n---\n\nb", "<p>a</p>\n<hr>\n<p>b</p>\n"},
+		{"fence", "```go\nx := 1 < 2\n```", `<pre style="background:#f6f8fa;padding:8px;overflow:auto"><code>x := 1 &lt; 2</code></pre>` + "\n"},
+		{"tight list", "- a\n- b\n  - c", "<ul>\n<li>a\n</li>\n<li>b\n<ul>\n<li>c\n</li>\n</ul>\n</li>\n</ul>\n"},
+		{"ordered start", "3. x\n4. y", "<ol start=\"3\">\n<li>x\n</li>\n<li>y\n</li>\n</ol>\n"},
+		{"loose list", "- a\n\n- b", "<ul>\n<li><p>a</p>\n</li>\n<li><p>b</p>\n</li>\n</ul>\n"},
+	}
+	for _, tc := range cases {
+		if got := markdownToHTML(tc.in); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMarkdownToHTML_QuoteAndTable(t *testing.T) {
+	got := markdownToHTML("> quoted **text**\nlazy\n\n| Name | Qty |\n|:-----|----:|\n| a\\|b | 2 |\n")
+	for _, want := range []string{
+		"<blockquote",
+		"<p>quoted <strong>text</strong>\nlazy</p>\n</blockquote>",
+		`<th style="border:1px solid #ddd;padding:4px 8px;text-align:left">Name</th>`,
+		`<td style="border:1px solid #ddd;padding:4px 8px;text-align:left">a|b</td>`,
+		`<td style="border:1px solid #ddd;padding:4px 8px;text-align:right">2</td>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestResolveComposeBody_Markdown(t *testing.T) {
+	text, html, err := resolveComposeBody("", "", "", "Hi **Bob**", "")
+	if err != nil || text != "Hi **Bob**" || html != "<p>Hi <strong>Bob</strong></p>\n" {
+		t.Fatalf("unexpected: %q %q %v", text, html, err)
+	}
+	if _, _, err := resolveComposeBody("plain", "", "", "md", ""); err == nil {
+		t.Fatalf("expected error combining --body with --body-md")
+	}
+	if _, _, err := resolveComposeBody("", "", "", "md", "file.md"); err == nil {
+		t.Fatalf("expected error combining --body-md with --body-md-file")
+	}
+}
+
+func TestGmailSend_BodyMarkdown(t *testing.T) {
+	sent := newGmailReplyTestServer(t)
+
+	_ = runDriveCmdJSON(t, &GmailSendCmd{}, []string{"--to", "bob@example.com", "--subject", "Notes", "--body-md", "# Notes\n\n- one\n- two"})
+	raw := decodeSentRaw(t, (*sent)[0])
+	for _, want := range []string{
+		"Content-Type: multipart/alternative;",
+		"Content-Type: text/plain; charset=\"utf-8\"",
+		"# Notes\r\n\r\n- one\r\n- two",
+		"Content-Type: text/html; charset=\"utf-8\"",
+		"<h1>Notes</h1>",
+	} {
+		if !strings.Contains(raw, want) {
+			t.Fatalf("missing %q in:\n%s", want, raw)
+		}
+	}
+}
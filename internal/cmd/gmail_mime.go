@@ -20,6 +20,9 @@ type mailAttachment struct {
 	Filename string
 	MIMEType string
 	Data     []byte
+	// ContentID makes the attachment an inline part (multipart/related)
+	// that the HTML body references as src="cid:<ContentID>".
+	ContentID string
 }
 
 type rfc822Config struct {
@@ -39,6 +42,9 @@ type mailOptions struct {
 	References        string
 	AdditionalHeaders map[string]string
 	Attachments       []mailAttachment
+	// Calendar is an iCalendar object sent as a text/calendar alternative
+	// (an invite clients can accept) and as an invite.ics attachment.
+	Calendar []byte
 }
 
 func buildRFC822(opts mailOptions, cfg *rfc822Config) ([]byte, error) {
@@ -115,99 +121,178 @@ func buildRFC822(opts mailOptions, cfg *rfc822Config) ([]byte, error) {
 		}
 	}
 
+	body, err := buildMailBody(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := body(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// mimePart writes one MIME entity: its Content-* headers, a blank line and
+// the content. The top-level part's headers continue the message headers.
+type mimePart func(b *bytes.Buffer) error
+
+// buildMailBody lays out the body as
+//
+//	multipart/mixed            (when there are regular attachments)
+//	  multipart/related        (when there are inline images)
+//	    multipart/alternative  (text, HTML and calendar invite)
+//	    inline images
+//	  attachments
+//
+// collapsing every level that has a single child.
+func buildMailBody(opts mailOptions) (mimePart, error) {
 	plainBody := normalizeCRLF(opts.Body)
 	htmlBody := normalizeCRLF(opts.BodyHTML)
 	hasPlain := strings.TrimSpace(plainBody) != ""
 	hasHTML := strings.TrimSpace(htmlBody) != ""
 
-	if len(opts.Attachments) == 0 {
-		switch {
-		case hasPlain && hasHTML:
-			altBoundary, err := randomBoundary()
+	var alternatives []mimePart
+	if hasPlain || !hasHTML {
+		alternatives = append(alternatives, textMIMEPart("text/plain; charset=\"utf-8\"", plainBody))
+	}
+	if hasHTML {
+		alternatives = append(alternatives, textMIMEPart("text/html; charset=\"utf-8\"", htmlBody))
+	}
+
+	var inline, attachments []mailAttachment
+	for _, a := range opts.Attachments {
+		if strings.TrimSpace(a.ContentID) != "" {
+			inline = append(inline, a)
+		} else {
+			attachments = append(attachments, a)
+		}
+	}
+	if len(inline) > 0 && !hasHTML {
+		return nil, errors.New("inline images require an HTML body")
+	}
+	for _, a := range inline {
+		if cid := strings.Trim(strings.TrimSpace(a.ContentID), "<>"); !strings.Contains(htmlBody, "cid:"+cid) {
+			return nil, fmt.Errorf("inline image %q is not referenced from the HTML body (use src=\"cid:%s\")", cid, cid)
+		}
+	}
+
+	if len(opts.Calendar) > 0 {
+		method := calendarMethod(opts.Calendar)
+		ics := normalizeCRLF(string(opts.Calendar))
+		alternatives = append(alternatives, textMIMEPart(fmt.Sprintf("text/calendar; charset=\"utf-8\"; method=%s", method), ics))
+		// Clients that ignore the inline part still get an importable file.
+		attachments = append(attachments, mailAttachment{Filename: "invite.ics", MIMEType: "application/ics", Data: []byte(ics)})
+	}
+
+	body := alternatives[0]
+	if len(alternatives) > 1 {
+		body = multipartMIMEPart("alternative", alternatives)
+	}
+
+	if len(inline) > 0 {
+		related := []mimePart{body}
+		for _, a := range inline {
+			part, err := attachmentMIMEPart(a)
 			if err != nil {
 				return nil, err
 			}
-			writeHeader(&b, "Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", altBoundary))
-			b.WriteString("\r\n")
-
-			writeTextPart(&b, altBoundary, "text/plain; charset=\"utf-8\"", plainBody)
-			writeTextPart(&b, altBoundary, "text/html; charset=\"utf-8\"", htmlBody)
-			b.WriteString(fmt.Sprintf("--%s--\r\n", altBoundary))
-			return b.Bytes(), nil
-		case hasHTML && !hasPlain:
-			writeHeader(&b, "Content-Type", "text/html; charset=\"utf-8\"")
-			writeHeader(&b, "Content-Transfer-Encoding", "7bit")
-			b.WriteString("\r\n")
-			writeBodyWithTrailingCRLF(&b, htmlBody)
-			return b.Bytes(), nil
-		default:
-			writeHeader(&b, "Content-Type", "text/plain; charset=\"utf-8\"")
-			writeHeader(&b, "Content-Transfer-Encoding", "7bit")
-			b.WriteString("\r\n")
-			writeBodyWithTrailingCRLF(&b, plainBody)
-			return b.Bytes(), nil
+			related = append(related, part)
 		}
+		body = multipartMIMEPart("related", related)
 	}
 
-	mixedBoundary, err := randomBoundary()
-	if err != nil {
-		return nil, err
+	if len(attachments) > 0 {
+		mixed := []mimePart{body}
+		for _, a := range attachments {
+			part, err := attachmentMIMEPart(a)
+			if err != nil {
+				return nil, err
+			}
+			mixed = append(mixed, part)
+		}
+		body = multipartMIMEPart("mixed", mixed)
 	}
+	return body, nil
+}
 
-	writeHeader(&b, "Content-Type", fmt.Sprintf("multipart/mixed; boundary=%q", mixedBoundary))
-	b.WriteString("\r\n")
+func textMIMEPart(contentType string, body string) mimePart {
+	return func(b *bytes.Buffer) error {
+		writeHeader(b, "Content-Type", contentType)
+		writeHeader(b, "Content-Transfer-Encoding", "7bit")
+		b.WriteString("\r\n")
+		writeBodyWithTrailingCRLF(b, body)
+		return nil
+	}
+}
 
-	// Body part
-	b.WriteString(fmt.Sprintf("--%s\r\n", mixedBoundary))
-	switch {
-	case hasPlain && hasHTML:
-		altBoundary, err := randomBoundary()
+func multipartMIMEPart(subtype string, parts []mimePart) mimePart {
+	return func(b *bytes.Buffer) error {
+		boundary, err := randomBoundary()
 		if err != nil {
-			return nil, err
+			return err
 		}
-		b.WriteString(fmt.Sprintf("Content-Type: multipart/alternative; boundary=%q\r\n\r\n", altBoundary))
-		writeTextPart(&b, altBoundary, "text/plain; charset=\"utf-8\"", plainBody)
-		writeTextPart(&b, altBoundary, "text/html; charset=\"utf-8\"", htmlBody)
-		b.WriteString(fmt.Sprintf("--%s--\r\n", altBoundary))
-	case hasHTML && !hasPlain:
-		b.WriteString("Content-Type: text/html; charset=\"utf-8\"\r\n")
-		b.WriteString("Content-Transfer-Encoding: 7bit\r\n\r\n")
-		writeBodyWithTrailingCRLF(&b, htmlBody)
-	default:
-		b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
-		b.WriteString("Content-Transfer-Encoding: 7bit\r\n\r\n")
-		writeBodyWithTrailingCRLF(&b, plainBody)
-	}
-
-	// Attachments
-	for _, a := range opts.Attachments {
-		if a.Filename == "" {
-			a.Filename = filepath.Base(a.Path)
+		writeHeader(b, "Content-Type", fmt.Sprintf("multipart/%s; boundary=%q", subtype, boundary))
+		b.WriteString("\r\n")
+		for _, part := range parts {
+			_, _ = fmt.Fprintf(b, "--%s\r\n", boundary)
+			if err := part(b); err != nil {
+				return err
+			}
 		}
+		_, _ = fmt.Fprintf(b, "--%s--\r\n", boundary)
+		return nil
+	}
+}
+
+// attachmentMIMEPart reads the attachment (unless Data is set) and returns
+// it as a base64 part; a ContentID makes it an inline part.
+func attachmentMIMEPart(a mailAttachment) (mimePart, error) {
+	if a.Filename == "" {
+		a.Filename = filepath.Base(a.Path)
+	}
+	if a.MIMEType == "" {
+		a.MIMEType = mime.TypeByExtension(strings.ToLower(filepath.Ext(a.Filename)))
 		if a.MIMEType == "" {
-			a.MIMEType = mime.TypeByExtension(strings.ToLower(filepath.Ext(a.Filename)))
-			if a.MIMEType == "" {
-				a.MIMEType = "application/octet-stream"
-			}
+			a.MIMEType = "application/octet-stream"
 		}
-		if len(a.Data) == 0 {
-			data, err := os.ReadFile(a.Path)
-			if err != nil {
-				return nil, err
-			}
-			a.Data = data
+	}
+	if len(a.Data) == 0 {
+		data, err := os.ReadFile(a.Path)
+		if err != nil {
+			return nil, err
 		}
+		a.Data = data
+	}
+	contentID := strings.Trim(strings.TrimSpace(a.ContentID), "<>")
+	if err := validateHeaderValue(contentID); err != nil {
+		return nil, fmt.Errorf("invalid Content-ID: %w", err)
+	}
 
-		b.WriteString(fmt.Sprintf("\r\n--%s\r\n", mixedBoundary))
-		b.WriteString(fmt.Sprintf("Content-Type: %s\r\n", a.MIMEType))
-		b.WriteString("Content-Transfer-Encoding: base64\r\n")
-		b.WriteString(fmt.Sprintf("Content-Disposition: attachment; %s\r\n\r\n", contentDispositionFilename(a.Filename)))
+	return func(b *bytes.Buffer) error {
+		writeHeader(b, "Content-Type", a.MIMEType)
+		writeHeader(b, "Content-Transfer-Encoding", "base64")
+		if contentID != "" {
+			writeHeader(b, "Content-ID", "<"+contentID+">")
+			writeHeader(b, "Content-Disposition", "inline; "+contentDispositionFilename(a.Filename))
+		} else {
+			writeHeader(b, "Content-Disposition", "attachment; "+contentDispositionFilename(a.Filename))
+		}
+		b.WriteString("\r\n")
 		b.WriteString(wrapBase64(a.Data))
 		b.WriteString("\r\n")
-	}
+		return nil
+	}, nil
+}
 
-	b.WriteString(fmt.Sprintf("--%s--\r\n", mixedBoundary))
-	return b.Bytes(), nil
+// calendarMethod returns the iTIP METHOD of an iCalendar object, defaulting
+// to REQUEST (an invitation).
+func calendarMethod(ics []byte) string {
+	for _, line := range strings.Split(string(ics), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) > len("METHOD:") && strings.EqualFold(line[:len("METHOD:")], "METHOD:") {
+			return strings.ToUpper(strings.TrimSpace(line[len("METHOD:"):]))
+		}
+	}
+	return "REQUEST"
 }
 
 func writeHeader(b *bytes.Buffer, name, value string) {
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/steipete/gogcli/internal/config"
+)
+
+var gmailContentIDPattern = regexp.MustCompile(`^[A-Za-z0-9._@+-]+$`)
+
+// gmailComposeAttachments resolves --attach paths and --inline cid=path
+// specs into attachments for buildRFC822.
+func gmailComposeAttachments(attach []string, inline []string) ([]mailAttachment, error) {
+	atts := make([]mailAttachment, 0, len(attach)+len(inline))
+	for _, p := range attach {
+		expanded, err := config.ExpandPath(p)
+		if err != nil {
+			return nil, err
+		}
+		atts = append(atts, mailAttachment{Path: expanded})
+	}
+	seen := make(map[string]bool, len(inline))
+	for _, spec := range inline {
+		cid, path, ok := strings.Cut(spec, "=")
+		cid = strings.TrimPrefix(strings.Trim(strings.TrimSpace(cid), "<>"), "cid:")
+		path = strings.TrimSpace(path)
+		if !ok || cid == "" || path == "" {
+			return nil, usagef("invalid --inline %q (expected cid=path, e.g. logo=./logo.png)", spec)
+		}
+		if !gmailContentIDPattern.MatchString(cid) {
+			return nil, usagef("invalid --inline content ID %q (use letters, digits and ._@+-)", cid)
+		}
+		if seen[cid] {
+			return nil, usagef("duplicate --inline content ID %q", cid)
+		}
+		seen[cid] = true
+		expanded, err := config.ExpandPath(path)
+		if err != nil {
+			return nil, err
+		}
+		atts = append(atts, mailAttachment{Path: expanded, ContentID: cid})
+	}
+	return atts, nil
+}
+
+// readGmailInvite reads an iCalendar file for --ics. A missing METHOD is set
+// to REQUEST so clients treat the event as an invitation.
+func readGmailInvite(path string) ([]byte, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, nil
+	}
+	expanded, err := config.ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(expanded) //nolint:gosec // user-provided path
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.Contains(data, []byte("BEGIN:VCALENDAR")) || !bytes.Contains(data, []byte("BEGIN:VEVENT")) {
+		return nil, fmt.Errorf("%s: not an iCalendar event (missing BEGIN:VCALENDAR/BEGIN:VEVENT)", path)
+	}
+	if !bytes.Contains(data, []byte("\nMETHOD:")) {
+		data = bytes.Replace(data, []byte("BEGIN:VCALENDAR\n"), []byte("BEGIN:VCALENDAR\nMETHOD:REQUEST\n"), 1)
+	}
+	return data, nil
+}
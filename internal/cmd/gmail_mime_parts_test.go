@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGmailComposeAttachments(t *testing.T) {
+	atts, err := gmailComposeAttachments([]string{"/tmp/a.pdf"}, []string{"cid:logo=/tmp/logo.png"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(atts) != 2 || atts[0].ContentID != "" || atts[1].ContentID != "logo" || atts[1].Path != "/tmp/logo.png" {
+		t.Fatalf("unexpected attachments: %+v", atts)
+	}
+	for _, bad := range [][]string{{"logo"}, {"=x.png"}, {"a b=x.png"}, {"l=x.png", "l=y.png"}} {
+		if _, err := gmailComposeAttachments(nil, bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestReadGmailInvite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "event.ics")
+	if err := os.WriteFile(path, []byte("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	data, err := readGmailInvite(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "BEGIN:VCALENDAR\nMETHOD:REQUEST\nVERSION:2.0\n") {
+		t.Fatalf("expected METHOD:REQUEST to be added: %q", data)
+	}
+
+	if err := os.WriteFile(path, []byte("not a calendar"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := readGmailInvite(path); err == nil {
+		t.Fatalf("expected error for a non-calendar file")
+	}
+}
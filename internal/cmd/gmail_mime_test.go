@@ -1,6 +1,11 @@
 package cmd
 
 import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
 	"regexp"
 	"strings"
 	"testing"
@@ -255,3 +260,95 @@ func TestRandomMessageID(t *testing.T) {
 		t.Fatalf("unexpected: %q", id)
 	}
 }
+
+// mimeTree renders the MIME structure of raw as e.g.
+// "multipart/mixed(text/plain,application/pdf)".
+func mimeTree(t *testing.T, raw []byte) string {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	var walk func(contentType string, body io.Reader) string
+	walk = func(contentType string, body io.Reader) string {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			t.Fatalf("parse %q: %v", contentType, err)
+		}
+		if !strings.HasPrefix(mediaType, "multipart/") {
+			return mediaType
+		}
+		var children []string
+		r := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := r.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("next part: %v", err)
+			}
+			children = append(children, walk(part.Header.Get("Content-Type"), part))
+		}
+		return mediaType + "(" + strings.Join(children, ",") + ")"
+	}
+	return walk(msg.Header.Get("Content-Type"), msg.Body)
+}
+
+func TestBuildRFC822InlineImagesAndInvite(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\nMETHOD:REQUEST\nBEGIN:VEVENT\nSUMMARY:Sync\nEND:VEVENT\nEND:VCALENDAR\n"
+	raw, err := buildRFC822(mailOptions{
+		From:     "a@b.com",
+		To:       []string{"c@d.com"},
+		Subject:  "Hi",
+		Body:     "Plain",
+		BodyHTML: `<p><img src="cid:logo"></p>`,
+		Attachments: []mailAttachment{
+			{Filename: "logo.png", Data: []byte("PNG"), ContentID: "logo"},
+			{Filename: "x.txt", MIMEType: "text/plain", Data: []byte("abc")},
+		},
+		Calendar: []byte(ics),
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := "multipart/mixed(multipart/related(multipart/alternative(text/plain,text/html,text/calendar),image/png),text/plain,application/ics)"
+	if got := mimeTree(t, raw); got != want {
+		t.Fatalf("structure:\n got %s\nwant %s", got, want)
+	}
+	s := string(raw)
+	for _, part := range []string{
+		"Content-Type: text/calendar; charset=\"utf-8\"; method=REQUEST\r\n",
+		"Content-ID: <logo>\r\nContent-Disposition: inline; filename=\"logo.png\"\r\n",
+		"Content-Disposition: attachment; filename=\"invite.ics\"\r\n",
+	} {
+		if !strings.Contains(s, part) {
+			t.Fatalf("missing %q in:\n%s", part, s)
+		}
+	}
+
+	// Without regular attachments the related part is the top level.
+	raw, err = buildRFC822(mailOptions{
+		From:        "a@b.com",
+		To:          []string{"c@d.com"},
+		Subject:     "Hi",
+		BodyHTML:    `<img src="cid:logo">`,
+		Attachments: []mailAttachment{{Filename: "logo.png", Data: []byte("PNG"), ContentID: "logo"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got := mimeTree(t, raw); got != "multipart/related(text/html,image/png)" {
+		t.Fatalf("unexpected structure: %s", got)
+	}
+}
+
+func TestBuildRFC822InlineImageErrors(t *testing.T) {
+	img := []mailAttachment{{Filename: "logo.png", Data: []byte("PNG"), ContentID: "logo"}}
+	if _, err := buildRFC822(mailOptions{From: "a@b.com", To: []string{"c@d.com"}, Subject: "Hi", Body: "x", Attachments: img}, nil); err == nil {
+		t.Fatalf("expected error for inline image without HTML")
+	}
+	if _, err := buildRFC822(mailOptions{From: "a@b.com", To: []string{"c@d.com"}, Subject: "Hi", BodyHTML: "<p>x</p>", Attachments: img}, nil); err == nil {
+		t.Fatalf("expected error for unreferenced inline image")
+	}
+}
@@ -0,0 +1,512 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+
+	"github.com/steipete/gogcli/internal/config"
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+const (
+	gmailQueuePending = "pending"
+	gmailQueueSending = "sending"
+	gmailQueueSent    = "sent"
+	gmailQueueFailed  = "failed"
+
+	// gmailQueueClaimTimeout releases a "sending" claim left by a run that
+	// died mid-send. Retrying is safe: a draft that was sent no longer exists.
+	gmailQueueClaimTimeout = 10 * time.Minute
+
+	// gmailQueueMaxAttempts bounds retries of a due draft before it is marked
+	// failed and left for the user to inspect.
+	gmailQueueMaxAttempts = 5
+)
+
+type GmailQueueCmd struct {
+	List   GmailQueueListCmd   `cmd:"" name:"list" default:"withargs" help:"List scheduled messages"`
+	Cancel GmailQueueCancelCmd `cmd:"" name:"cancel" help:"Cancel scheduled messages"`
+	Run    GmailQueueRunCmd    `cmd:"" name:"run" help:"Send scheduled messages that are due"`
+}
+
+// gmailQueueEntry is a draft waiting to be sent at SendAt. The queue lives in
+// the config dir, so it survives restarts; nothing is sent unless
+// `gmail queue run` is invoked (from cron, or with --daemon).
+type gmailQueueEntry struct {
+	DraftID   string     `json:"draftId"`
+	SendAt    time.Time  `json:"sendAt"`
+	To        string     `json:"to,omitempty"`
+	Subject   string     `json:"subject,omitempty"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Attempts  int        `json:"attempts,omitempty"`
+	LastError string     `json:"lastError,omitempty"`
+	MessageID string     `json:"messageId,omitempty"`
+	ThreadID  string     `json:"threadId,omitempty"`
+	SentAt    *time.Time `json:"sentAt,omitempty"`
+	ClaimedAt *time.Time `json:"claimedAt,omitempty"`
+}
+
+type gmailQueueFile struct {
+	Entries []gmailQueueEntry `json:"entries"`
+}
+
+func gmailQueuePath(account string) (string, error) {
+	dir, err := config.EnsureGmailQueueDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, sanitizeAccountForPath(account)+".json"), nil
+}
+
+func loadGmailQueue(account string) (*gmailQueueFile, error) {
+	path, err := gmailQueuePath(account)
+	if err != nil {
+		return nil, err
+	}
+	q := &gmailQueueFile{}
+	data, err := os.ReadFile(path) //nolint:gosec // path is derived from the config dir
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return q, nil
+		}
+		return nil, fmt.Errorf("read gmail queue: %w", err)
+	}
+	if err := json.Unmarshal(data, q); err != nil {
+		return nil, fmt.Errorf("decode gmail queue: %w", err)
+	}
+	return q, nil
+}
+
+// updateGmailQueue re-reads the queue, applies fn and writes it back under
+// a file lock, so entries added by another process (e.g. `send --at` while a
+// daemon runs) are kept.
+func updateGmailQueue(account string, fn func(q *gmailQueueFile) error) error {
+	path, err := gmailQueuePath(account)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockStateFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	q, err := loadGmailQueue(account)
+	if err != nil {
+		return err
+	}
+	if err := fn(q); err != nil {
+		return err
+	}
+	sort.SliceStable(q.Entries, func(i, j int) bool { return q.Entries[i].SendAt.Before(q.Entries[j].SendAt) })
+
+	payload, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(payload, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// parseGmailSendAt accepts the time expressions of parseTimeExpr plus
+// relative offsets like "+90m" or "in 2h". The result must be in the future.
+func parseGmailSendAt(expr string, now time.Time) (time.Time, error) {
+	expr = strings.TrimSpace(expr)
+	var t time.Time
+	if rel := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(expr), "in "), "+"); rel != strings.ToLower(expr) {
+		d, err := time.ParseDuration(strings.TrimSpace(rel))
+		if err != nil {
+			return time.Time{}, usagef("invalid --at %q: %v", expr, err)
+		}
+		t = now.Add(d)
+	} else {
+		parsed, err := parseTimeExpr(expr, now, time.Local)
+		if err != nil {
+			return time.Time{}, usagef("invalid --at: %v", err)
+		}
+		t = parsed
+	}
+	if !t.After(now) {
+		return time.Time{}, usagef("--at %q is in the past", expr)
+	}
+	return t, nil
+}
+
+// scheduleGmailSend stores the message as a draft and queues it for sendAt.
+func scheduleGmailSend(ctx context.Context, u *ui.UI, svc *gmail.Service, account string, sendAt time.Time, opts sendMessageOptions, to, cc, bcc []string) error {
+	reply := replyInfo{}
+	if opts.ReplyInfo != nil {
+		reply = *opts.ReplyInfo
+	}
+	raw, err := buildRFC822(mailOptions{
+		From:        opts.FromAddr,
+		To:          to,
+		Cc:          cc,
+		Bcc:         bcc,
+		ReplyTo:     opts.ReplyTo,
+		Subject:     opts.Subject,
+		Body:        opts.Body,
+		BodyHTML:    opts.BodyHTML,
+		InReplyTo:   reply.InReplyTo,
+		References:  reply.References,
+		Attachments: opts.Attachments,
+		Calendar:    opts.Calendar,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	msg := &gmail.Message{Raw: base64.RawURLEncoding.EncodeToString(raw)}
+	if reply.ThreadID != "" {
+		msg.ThreadId = reply.ThreadID
+	}
+	draft, err := svc.Users.Drafts.Create("me", &gmail.Draft{Message: msg}).Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+
+	entry := gmailQueueEntry{
+		DraftID:   draft.Id,
+		SendAt:    sendAt,
+		To:        strings.Join(append(append(append([]string{}, to...), cc...), bcc...), ", "),
+		Subject:   opts.Subject,
+		Status:    gmailQueuePending,
+		CreatedAt: time.Now(),
+	}
+	if err := updateGmailQueue(account, func(q *gmailQueueFile) error {
+		q.Entries = append(q.Entries, entry)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("draft %s created but not queued: %w", draft.Id, err)
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"queued": entry})
+	}
+	u.Out().Printf("draft_id\t%s", entry.DraftID)
+	u.Out().Printf("send_at\t%s", entry.SendAt.Local().Format(time.RFC3339))
+	u.Err().Println("Queued; run `gog gmail queue run` (e.g. from cron) or `gog gmail queue run --daemon` to send it")
+	return nil
+}
+
+type GmailQueueListCmd struct {
+	All bool `name:"all" help:"Include sent and failed entries"`
+}
+
+func (c *GmailQueueListCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	q, err := loadGmailQueue(account)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]gmailQueueEntry, 0, len(q.Entries))
+	for _, e := range q.Entries {
+		if c.All || e.Status == gmailQueuePending || e.Status == gmailQueueSending {
+			entries = append(entries, e)
+		}
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"entries": entries})
+	}
+	if len(entries) == 0 {
+		u.Err().Println("No scheduled messages")
+		return nil
+	}
+
+	w, flush := tableWriter(ctx)
+	defer flush()
+	fmt.Fprintln(w, "DRAFT ID\tSEND AT\tSTATUS\tTO\tSUBJECT")
+	for _, e := range entries {
+		status := e.Status
+		if e.Status == gmailQueuePending && e.LastError != "" {
+			status = fmt.Sprintf("%s (retry %d)", status, e.Attempts)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.DraftID, e.SendAt.Local().Format("2006-01-02 15:04"), status, sanitizeTab(e.To), sanitizeTab(e.Subject))
+	}
+	return nil
+}
+
+type GmailQueueCancelCmd struct {
+	DraftIDs  []string `arg:"" name:"draftId" help:"Draft IDs of queued messages"`
+	KeepDraft bool     `name:"keep-draft" help:"Only remove from the queue; keep the draft in Gmail"`
+}
+
+func (c *GmailQueueCancelCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+
+	q, err := loadGmailQueue(account)
+	if err != nil {
+		return err
+	}
+	pending := map[string]bool{}
+	sending := map[string]bool{}
+	for _, e := range q.Entries {
+		pending[e.DraftID] = e.Status == gmailQueuePending
+		sending[e.DraftID] = e.Status == gmailQueueSending
+	}
+	for _, id := range c.DraftIDs {
+		if _, ok := pending[id]; !ok {
+			return usagef("draft %s is not queued", id)
+		}
+		if sending[id] {
+			return usagef("draft %s is being sent", id)
+		}
+	}
+
+	var svc *gmail.Service
+	if !c.KeepDraft {
+		if confirmErr := confirmDestructive(ctx, flags, fmt.Sprintf("cancel %d scheduled messages and delete their drafts", len(c.DraftIDs))); confirmErr != nil {
+			return confirmErr
+		}
+		if svc, err = newGmailService(ctx, account); err != nil {
+			return err
+		}
+	}
+
+	cancelled := make([]string, 0, len(c.DraftIDs))
+	for _, id := range c.DraftIDs {
+		// Drafts of sent entries are gone already; only pending ones are deleted.
+		if svc != nil && pending[id] {
+			if delErr := svc.Users.Drafts.Delete("me", id).Context(ctx).Do(); delErr != nil && !isNotFoundAPIError(delErr) {
+				return fmt.Errorf("delete draft %s: %w", id, delErr)
+			}
+		}
+		cancelled = append(cancelled, id)
+	}
+
+	drop := map[string]bool{}
+	for _, id := range cancelled {
+		drop[id] = true
+	}
+	if err := updateGmailQueue(account, func(q *gmailQueueFile) error {
+		kept := q.Entries[:0]
+		for _, e := range q.Entries {
+			if !drop[e.DraftID] {
+				kept = append(kept, e)
+			}
+		}
+		q.Entries = kept
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"cancelled": cancelled, "draftsDeleted": !c.KeepDraft})
+	}
+	for _, id := range cancelled {
+		u.Out().Printf("cancelled\t%s", id)
+	}
+	return nil
+}
+
+type GmailQueueRunCmd struct {
+	Daemon   bool          `name:"daemon" help:"Keep running and send messages as they become due"`
+	Interval time.Duration `name:"interval" help:"Check interval in --daemon mode" default:"1m"`
+}
+
+type gmailQueueRunResult struct {
+	Sent    []gmailQueueEntry `json:"sent"`
+	Failed  []gmailQueueEntry `json:"failed"`
+	Pending int               `json:"pending"`
+}
+
+func (c *GmailQueueRunCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	if c.Daemon && c.Interval < time.Second {
+		return usage("--interval must be at least 1s")
+	}
+
+	svc, err := newGmailService(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	if !c.Daemon {
+		res, runErr := runGmailQueue(ctx, svc, account, time.Now())
+		if runErr != nil {
+			return runErr
+		}
+		if outfmt.IsJSON(ctx) {
+			return outfmt.WriteJSON(os.Stdout, res)
+		}
+		writeGmailQueueRunText(u, res)
+		if len(res.Sent)+len(res.Failed) == 0 {
+			u.Err().Printf("Nothing due (%d pending)", res.Pending)
+		}
+		return nil
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		res, runErr := runGmailQueue(ctx, svc, account, time.Now())
+		switch {
+		case runErr != nil:
+			u.Err().Printf("queue: %v (retrying in %s)", runErr, c.Interval)
+		case len(res.Sent)+len(res.Failed) == 0:
+		case outfmt.IsJSON(ctx):
+			if err := enc.Encode(res); err != nil {
+				return err
+			}
+		default:
+			writeGmailQueueRunText(u, res)
+		}
+
+		timer := time.NewTimer(c.Interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+func writeGmailQueueRunText(u *ui.UI, res gmailQueueRunResult) {
+	for _, e := range res.Sent {
+		u.Out().Printf("sent\t%s\t%s", e.DraftID, e.MessageID)
+	}
+	for _, e := range res.Failed {
+		u.Out().Printf("failed\t%s\t%s", e.DraftID, e.LastError)
+	}
+}
+
+// runGmailQueue sends every pending draft whose time has come. Due entries
+// are claimed ("sending") under the queue lock first, so overlapping runs
+// (cron plus a manual run) never send the same draft. Errors are recorded on
+// the entry and retried on the next run, up to gmailQueueMaxAttempts; a draft
+// that no longer exists fails immediately.
+func runGmailQueue(ctx context.Context, svc *gmail.Service, account string, now time.Time) (gmailQueueRunResult, error) {
+	res := gmailQueueRunResult{Sent: []gmailQueueEntry{}, Failed: []gmailQueueEntry{}}
+	claimedAt := now
+	var claimed []gmailQueueEntry
+	if err := updateGmailQueue(account, func(q *gmailQueueFile) error {
+		for i := range q.Entries {
+			e := &q.Entries[i]
+			if e.Status == gmailQueueSending && e.ClaimedAt != nil && now.Sub(*e.ClaimedAt) > gmailQueueClaimTimeout {
+				e.Status = gmailQueuePending
+			}
+			if e.Status != gmailQueuePending {
+				continue
+			}
+			if e.SendAt.After(now) {
+				res.Pending++
+				continue
+			}
+			e.Status = gmailQueueSending
+			e.ClaimedAt = &claimedAt
+			claimed = append(claimed, *e)
+		}
+		return nil
+	}); err != nil {
+		return res, err
+	}
+
+	for i, e := range claimed {
+		if ctx.Err() != nil {
+			return res, releaseGmailQueueClaims(account, claimed[i:], claimedAt, ctx.Err())
+		}
+
+		msg, sendErr := sendGmailDraft(ctx, svc, e.DraftID)
+		e.Attempts++
+		e.ClaimedAt = nil
+		switch {
+		case sendErr == nil:
+			sentAt := time.Now()
+			e.Status = gmailQueueSent
+			e.MessageID = msg.Id
+			e.ThreadID = msg.ThreadId
+			e.LastError = ""
+			e.SentAt = &sentAt
+		case isNotFoundAPIError(sendErr):
+			e.Status = gmailQueueFailed
+			e.LastError = "draft no longer exists (deleted or sent elsewhere)"
+		default:
+			e.Status = gmailQueuePending
+			e.LastError = sendErr.Error()
+			if e.Attempts >= gmailQueueMaxAttempts {
+				e.Status = gmailQueueFailed
+			}
+		}
+
+		// Only write back over our own claim; an entry cancelled or reclaimed
+		// meanwhile belongs to someone else now.
+		stored := false
+		if err := updateGmailQueue(account, func(q *gmailQueueFile) error {
+			for j := range q.Entries {
+				if q.Entries[j].DraftID == e.DraftID && ownsGmailQueueClaim(q.Entries[j], claimedAt) {
+					q.Entries[j] = e
+					stored = true
+				}
+			}
+			return nil
+		}); err != nil {
+			return res, err
+		}
+		if !stored {
+			continue
+		}
+		switch e.Status {
+		case gmailQueueSent:
+			res.Sent = append(res.Sent, e)
+		case gmailQueueFailed:
+			res.Failed = append(res.Failed, e)
+		default:
+			res.Pending++
+		}
+	}
+	return res, nil
+}
+
+func ownsGmailQueueClaim(e gmailQueueEntry, claimedAt time.Time) bool {
+	return e.Status == gmailQueueSending && e.ClaimedAt != nil && e.ClaimedAt.Equal(claimedAt)
+}
+
+// releaseGmailQueueClaims returns unsent claimed entries to pending and
+// passes cause through.
+func releaseGmailQueueClaims(account string, entries []gmailQueueEntry, claimedAt time.Time, cause error) error {
+	release := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		release[e.DraftID] = true
+	}
+	if err := updateGmailQueue(account, func(q *gmailQueueFile) error {
+		for i := range q.Entries {
+			if release[q.Entries[i].DraftID] && ownsGmailQueueClaim(q.Entries[i], claimedAt) {
+				q.Entries[i].Status = gmailQueuePending
+				q.Entries[i].ClaimedAt = nil
+			}
+		}
+		return nil
+	}); err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGmailSendAt(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+	if got, err := parseGmailSendAt("+90m", now); err != nil || !got.Equal(now.Add(90*time.Minute)) {
+		t.Fatalf("relative: %v %v", got, err)
+	}
+	if got, err := parseGmailSendAt("in 2h", now); err != nil || !got.Equal(now.Add(2*time.Hour)) {
+		t.Fatalf("in: %v %v", got, err)
+	}
+	if got, err := parseGmailSendAt("2026-10-20 09:00", now); err != nil || got.Day() != 20 || got.Hour() != 9 {
+		t.Fatalf("absolute: %v %v", got, err)
+	}
+	for _, bad := range []string{"2026-10-01 09:00", "+-1h", "someday"} {
+		if _, err := parseGmailSendAt(bad, now); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestGmailQueue_ScheduleRunCancel(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var sent, deleted []string
+	drafts := 0
+	setupGmailTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/users/me/drafts"):
+			drafts++
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": fmt.Sprintf("d%d", drafts)})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/users/me/drafts/send"):
+			var body struct {
+				ID string `json:"id"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			sent = append(sent, body.ID)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "m-" + body.ID, "threadId": "t"})
+		case r.Method == http.MethodDelete && strings.Contains(r.URL.Path, "/users/me/drafts/"):
+			deleted = append(deleted, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	queued := runDriveCmdJSON(t, &GmailSendCmd{}, []string{"--to", "a@example.com", "--subject", "Later", "--body", "hi", "--at", "+1h"})
+	entry, _ := queued["queued"].(map[string]any)
+	if entry["draftId"] != "d1" || entry["status"] != gmailQueuePending || len(sent) != 0 {
+		t.Fatalf("unexpected queue result: %v", queued)
+	}
+	_ = runDriveCmdJSON(t, &GmailSendCmd{}, []string{"--to", "b@example.com", "--subject", "Much later", "--body", "hi", "--at", "+48h"})
+
+	// Make the first entry due.
+	if err := updateGmailQueue("a@b.com", func(q *gmailQueueFile) error {
+		for i := range q.Entries {
+			if q.Entries[i].DraftID == "d1" {
+				q.Entries[i].SendAt = time.Now().Add(-time.Minute)
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("update queue: %v", err)
+	}
+
+	res := runDriveCmdJSON(t, &GmailQueueCmd{}, []string{"run"})
+	if strings.Join(sent, ",") != "d1" || res["pending"] != float64(1) {
+		t.Fatalf("unexpected run: %v (sent %v)", res, sent)
+	}
+	list := runDriveCmdJSON(t, &GmailQueueCmd{}, []string{})
+	if entries, _ := list["entries"].([]any); len(entries) != 1 || entries[0].(map[string]any)["draftId"] != "d2" {
+		t.Fatalf("unexpected pending list: %v", list)
+	}
+	all := runDriveCmdJSON(t, &GmailQueueCmd{}, []string{"list", "--all"})
+	if entries, _ := all["entries"].([]any); len(entries) != 2 || entries[0].(map[string]any)["messageId"] != "m-d1" {
+		t.Fatalf("unexpected full list: %v", all)
+	}
+
+	_ = runDriveCmdJSON(t, &GmailQueueCmd{}, []string{"cancel", "d2"})
+	if strings.Join(deleted, ",") != "d2" {
+		t.Fatalf("expected draft d2 deleted, got %v", deleted)
+	}
+	q, err := loadGmailQueue("a@b.com")
+	if err != nil || len(q.Entries) != 1 || q.Entries[0].DraftID != "d1" {
+		t.Fatalf("unexpected queue after cancel: %+v %v", q, err)
+	}
+}
+
+func TestRunGmailQueue_ClaimsDueEntries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	now := time.Now()
+	var (
+		sent   []string
+		nested gmailQueueRunResult
+	)
+	setupGmailTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			ID string `json:"id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		sent = append(sent, body.ID)
+		if body.ID == "d1" {
+			// A second run overlapping this send must find nothing to claim.
+			svc, _ := newGmailService(r.Context(), "a@b.com")
+			var err error
+			if nested, err = runGmailQueue(r.Context(), svc, "a@b.com", now); err != nil {
+				t.Errorf("nested run: %v", err)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "m-" + body.ID, "threadId": "t"})
+	})
+
+	live := now.Add(-time.Minute)
+	stale := now.Add(-time.Hour)
+	if err := updateGmailQueue("a@b.com", func(q *gmailQueueFile) error {
+		q.Entries = []gmailQueueEntry{
+			{DraftID: "d1", SendAt: now.Add(-3 * time.Minute), Status: gmailQueuePending},
+			{DraftID: "d2", SendAt: now.Add(-2 * time.Minute), Status: gmailQueueSending, ClaimedAt: &live},
+			{DraftID: "d3", SendAt: now.Add(-time.Minute), Status: gmailQueueSending, ClaimedAt: &stale},
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	svc, err := newGmailService(context.Background(), "a@b.com")
+	if err != nil {
+		t.Fatalf("service: %v", err)
+	}
+	res, err := runGmailQueue(context.Background(), svc, "a@b.com", now)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	// d2 is claimed by a live run; d3's claim is stale and taken over.
+	if strings.Join(sent, ",") != "d1,d3" || len(res.Sent) != 2 || len(nested.Sent)+len(nested.Failed) != 0 {
+		t.Fatalf("unexpected sends: %v res=%+v nested=%+v", sent, res, nested)
+	}
+	q, err := loadGmailQueue("a@b.com")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	statuses := make([]string, 0, len(q.Entries))
+	for _, e := range q.Entries {
+		statuses = append(statuses, e.DraftID+"="+e.Status)
+	}
+	if got := strings.Join(statuses, ","); got != "d1=sent,d2=sending,d3=sent" {
+		t.Fatalf("unexpected queue: %s", got)
+	}
+}
@@ -0,0 +1,421 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+
+	"github.com/steipete/gogcli/internal/config"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+type GmailReplyCmd struct {
+	MessageID string   `arg:"" name:"messageId" help:"Message ID to reply to"`
+	All       bool     `name:"all" help:"Reply to all recipients of the original message"`
+	To        string   `name:"to" help:"Override recipients (comma-separated)"`
+	Cc        string   `name:"cc" help:"CC recipients (comma-separated)"`
+	Bcc       string   `name:"bcc" help:"BCC recipients (comma-separated)"`
+	Body      string   `name:"body" help:"Reply text (plain text)"`
+	BodyFile  string   `name:"body-file" help:"Reply text file path (plain text; '-' for stdin)"`
+	BodyHTML  string   `name:"body-html" help:"Reply text (HTML)"`
+	NoQuote   bool     `name:"no-quote" help:"Do not quote the original message"`
+	Attach    []string `name:"attach" help:"Attachment file path (repeatable)"`
+	From      string   `name:"from" help:"Send from this email address (must be a verified send-as alias)"`
+}
+
+type GmailForwardCmd struct {
+	MessageID     string   `arg:"" name:"messageId" help:"Message ID to forward"`
+	To            string   `name:"to" help:"Recipients (comma-separated)" required:""`
+	Cc            string   `name:"cc" help:"CC recipients (comma-separated)"`
+	Bcc           string   `name:"bcc" help:"BCC recipients (comma-separated)"`
+	Body          string   `name:"body" help:"Note above the forwarded message (plain text)"`
+	BodyFile      string   `name:"body-file" help:"Note file path (plain text; '-' for stdin)"`
+	BodyHTML      string   `name:"body-html" help:"Note above the forwarded message (HTML)"`
+	AsAttachment  bool     `name:"as-attachment" help:"Attach the original as a .eml (message/rfc822) instead of quoting it inline"`
+	NoAttachments bool     `name:"no-attachments" help:"Do not carry over the original's attachments"`
+	Attach        []string `name:"attach" help:"Additional attachment file path (repeatable)"`
+	From          string   `name:"from" help:"Send from this email address (must be a verified send-as alias)"`
+}
+
+// gmailOriginal is the part of a message that gets quoted or forwarded.
+type gmailOriginal struct {
+	msg      *gmail.Message
+	from     string
+	to       string
+	cc       string
+	date     string
+	subject  string
+	text     string
+	html     string
+	hasHTML  bool
+	threadID string
+}
+
+func fetchGmailOriginal(ctx context.Context, svc *gmail.Service, messageID string) (*gmailOriginal, error) {
+	msg, err := svc.Users.Messages.Get("me", messageID).Format("full").Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	o := &gmailOriginal{
+		msg:      msg,
+		from:     headerValue(msg.Payload, "From"),
+		to:       headerValue(msg.Payload, "To"),
+		cc:       headerValue(msg.Payload, "Cc"),
+		date:     headerValue(msg.Payload, "Date"),
+		subject:  headerValue(msg.Payload, "Subject"),
+		text:     findPartBody(msg.Payload, "text/plain"),
+		html:     findPartBody(msg.Payload, "text/html"),
+		threadID: msg.ThreadId,
+	}
+	o.hasHTML = strings.TrimSpace(o.html) != ""
+	if strings.TrimSpace(o.text) == "" && o.hasHTML {
+		o.text = gmailHTMLToText(o.html)
+	}
+	return o, nil
+}
+
+func (c *GmailReplyCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	messageID := strings.TrimSpace(c.MessageID)
+	if messageID == "" {
+		return usage("empty messageId")
+	}
+	body, err := resolveBodyInput(c.Body, c.BodyFile)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(body) == "" && strings.TrimSpace(c.BodyHTML) == "" {
+		return usage("required: --body, --body-file, or --body-html")
+	}
+
+	svc, err := newGmailService(ctx, account)
+	if err != nil {
+		return err
+	}
+	fromAddr, sendingEmail, err := resolveSendFrom(ctx, svc, account, c.From)
+	if err != nil {
+		return err
+	}
+	info, err := fetchReplyInfo(ctx, svc, messageID, "")
+	if err != nil {
+		return err
+	}
+	orig, err := fetchGmailOriginal(ctx, svc, messageID)
+	if err != nil {
+		return err
+	}
+
+	var to, cc []string
+	if c.All {
+		to, cc = buildReplyAllRecipients(info, sendingEmail)
+	} else {
+		replyAddr := info.ReplyToAddr
+		if replyAddr == "" {
+			replyAddr = info.FromAddr
+		}
+		to = parseEmailAddresses(replyAddr)
+	}
+	if strings.TrimSpace(c.To) != "" {
+		to = splitCSV(c.To)
+	}
+	if strings.TrimSpace(c.Cc) != "" {
+		cc = splitCSV(c.Cc)
+	}
+	if len(to) == 0 {
+		return usage("no recipients: the original has no From/Reply-To; use --to")
+	}
+
+	text, htmlBody := body, c.BodyHTML
+	if !c.NoQuote {
+		attribution := gmailQuoteAttribution(orig)
+		text = joinQuoted(body, attribution+"\n"+quoteGmailText(orig.text))
+		if orig.hasHTML || strings.TrimSpace(c.BodyHTML) != "" {
+			htmlBody = gmailUserHTML(body, c.BodyHTML) +
+				`<br><div class="gmail_quote"><div class="gmail_attr">` + html.EscapeString(attribution) + `<br></div>` +
+				`<blockquote class="gmail_quote" style="margin:0px 0px 0px 0.8ex;border-left:1px solid rgb(204,204,204);padding-left:1ex">` +
+				gmailOriginalHTML(orig) + `</blockquote></div>`
+		}
+	}
+
+	atts, err := gmailAttachmentsFromPaths(c.Attach)
+	if err != nil {
+		return err
+	}
+
+	results, err := sendGmailBatches(ctx, svc, sendMessageOptions{
+		FromAddr:    fromAddr,
+		Subject:     gmailPrefixedSubject("Re:", orig.subject),
+		Body:        text,
+		BodyHTML:    htmlBody,
+		ReplyInfo:   info,
+		Attachments: atts,
+	}, []sendBatch{{To: to, Cc: cc, Bcc: splitCSV(c.Bcc)}})
+	if err != nil {
+		return err
+	}
+	return writeSendResults(ctx, u, fromAddr, results)
+}
+
+func (c *GmailForwardCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	messageID := strings.TrimSpace(c.MessageID)
+	if messageID == "" {
+		return usage("empty messageId")
+	}
+	to := splitCSV(c.To)
+	if len(to) == 0 {
+		return usage("required: --to")
+	}
+	body, err := resolveBodyInput(c.Body, c.BodyFile)
+	if err != nil {
+		return err
+	}
+
+	svc, err := newGmailService(ctx, account)
+	if err != nil {
+		return err
+	}
+	fromAddr, _, err := resolveSendFrom(ctx, svc, account, c.From)
+	if err != nil {
+		return err
+	}
+	// Forwards stay in the original thread, like in the Gmail UI.
+	info, err := fetchReplyInfo(ctx, svc, messageID, "")
+	if err != nil {
+		return err
+	}
+	orig, err := fetchGmailOriginal(ctx, svc, messageID)
+	if err != nil {
+		return err
+	}
+
+	atts := []mailAttachment{}
+	text, htmlBody := body, c.BodyHTML
+	if c.AsAttachment {
+		raw, rawErr := svc.Users.Messages.Get("me", messageID).Format(gmailFormatRaw).Context(ctx).Do()
+		if rawErr != nil {
+			return rawErr
+		}
+		data, decodeErr := decodeGmailRaw(raw.Raw)
+		if decodeErr != nil {
+			return decodeErr
+		}
+		atts = append(atts, mailAttachment{Filename: gmailForwardFilename(orig.subject), MIMEType: "message/rfc822", Data: data})
+		if strings.TrimSpace(text) == "" && strings.TrimSpace(htmlBody) == "" {
+			text = "Forwarded message attached."
+		}
+	} else {
+		header := gmailForwardHeader(orig)
+		text = joinQuoted(body, header+"\n"+orig.text)
+		if orig.hasHTML || strings.TrimSpace(c.BodyHTML) != "" {
+			htmlBody = gmailUserHTML(body, c.BodyHTML) +
+				`<br><div class="gmail_quote"><div class="gmail_attr">` +
+				strings.ReplaceAll(html.EscapeString(header), "\n", "<br>") + `<br></div><br>` +
+				gmailOriginalHTML(orig) + `</div>`
+		}
+		if !c.NoAttachments {
+			carried, carryErr := fetchGmailMessageAttachments(ctx, svc, orig.msg)
+			if carryErr != nil {
+				return carryErr
+			}
+			atts = append(atts, carried...)
+		}
+	}
+
+	extra, err := gmailAttachmentsFromPaths(c.Attach)
+	if err != nil {
+		return err
+	}
+	atts = append(atts, extra...)
+
+	results, err := sendGmailBatches(ctx, svc, sendMessageOptions{
+		FromAddr:    fromAddr,
+		Subject:     gmailPrefixedSubject("Fwd:", orig.subject),
+		Body:        text,
+		BodyHTML:    htmlBody,
+		ReplyInfo:   info,
+		Attachments: atts,
+	}, []sendBatch{{To: to, Cc: splitCSV(c.Cc), Bcc: splitCSV(c.Bcc)}})
+	if err != nil {
+		return err
+	}
+	return writeSendResults(ctx, u, fromAddr, results)
+}
+
+func gmailAttachmentsFromPaths(paths []string) ([]mailAttachment, error) {
+	atts := make([]mailAttachment, 0, len(paths))
+	for _, p := range paths {
+		expanded, err := config.ExpandPath(p)
+		if err != nil {
+			return nil, err
+		}
+		atts = append(atts, mailAttachment{Path: expanded})
+	}
+	return atts, nil
+}
+
+// fetchGmailMessageAttachments downloads every attachment part of msg so it
+// can be re-attached to a forward. Empty parts are skipped.
+func fetchGmailMessageAttachments(ctx context.Context, svc *gmail.Service, msg *gmail.Message) ([]mailAttachment, error) {
+	infos := collectAttachments(msg.Payload)
+	atts := make([]mailAttachment, 0, len(infos))
+	for _, a := range infos {
+		body, err := svc.Users.Messages.Attachments.Get("me", msg.Id, a.AttachmentID).Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("attachment %s: %w", a.Filename, err)
+		}
+		data, err := decodeBase64URL(body.Data)
+		if err != nil {
+			return nil, fmt.Errorf("decode attachment %s: %w", a.Filename, err)
+		}
+		if data == "" {
+			continue
+		}
+		atts = append(atts, mailAttachment{Filename: a.Filename, MIMEType: normalizeMimeType(a.MimeType), Data: []byte(data)})
+	}
+	return atts, nil
+}
+
+var gmailSubjectPrefixPattern = regexp.MustCompile(`(?i)^\s*(re|fwd?|aw|wg|sv|vs)\s*:\s*`)
+
+// gmailPrefixedSubject adds "Re:"/"Fwd:" unless the subject already starts
+// with that kind of prefix.
+func gmailPrefixedSubject(prefix string, subject string) string {
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		return prefix + " (no subject)"
+	}
+	if m := gmailSubjectPrefixPattern.FindStringSubmatch(subject); m != nil {
+		isReply := strings.EqualFold(m[1], "re") || strings.EqualFold(m[1], "aw") || strings.EqualFold(m[1], "sv") || strings.EqualFold(m[1], "vs")
+		if isReply == (prefix == "Re:") {
+			return subject
+		}
+	}
+	return prefix + " " + subject
+}
+
+func gmailQuoteAttribution(o *gmailOriginal) string {
+	from := strings.TrimSpace(o.from)
+	if from == "" {
+		from = "unknown sender"
+	}
+	if date := strings.TrimSpace(o.date); date != "" {
+		if t, err := mailParseDate(date); err == nil {
+			date = t.Format("Mon, Jan 2, 2006 at 3:04 PM")
+		}
+		return fmt.Sprintf("On %s, %s wrote:", date, from)
+	}
+	return from + " wrote:"
+}
+
+func gmailForwardHeader(o *gmailOriginal) string {
+	lines := []string{"---------- Forwarded message ---------", "From: " + o.from}
+	if o.date != "" {
+		lines = append(lines, "Date: "+o.date)
+	}
+	lines = append(lines, "Subject: "+o.subject)
+	if o.to != "" {
+		lines = append(lines, "To: "+o.to)
+	}
+	if o.cc != "" {
+		lines = append(lines, "Cc: "+o.cc)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func gmailForwardFilename(subject string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(subject))
+	if name == "" {
+		name = "message"
+	}
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	return name + ".eml"
+}
+
+// quoteGmailText prefixes every line with "> ".
+func quoteGmailText(text string) string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ">") {
+			lines[i] = ">" + line
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func joinQuoted(body string, quoted string) string {
+	body = strings.TrimRight(body, "\n")
+	if body == "" {
+		return quoted
+	}
+	return body + "\n\n" + quoted
+}
+
+// gmailUserHTML is the new part of an HTML reply/forward: the given HTML, or
+// the plain text escaped with line breaks kept.
+func gmailUserHTML(text string, htmlBody string) string {
+	if strings.TrimSpace(htmlBody) != "" {
+		return "<div>" + htmlBody + "</div>"
+	}
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+	return "<div>" + strings.ReplaceAll(html.EscapeString(strings.TrimRight(text, "\n")), "\n", "<br>") + "</div>"
+}
+
+var gmailHTMLBodyPattern = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+
+// gmailOriginalHTML is the original message as embeddable HTML: the inner
+// <body> of its HTML part, or its text escaped.
+func gmailOriginalHTML(o *gmailOriginal) string {
+	if o.hasHTML {
+		if m := gmailHTMLBodyPattern.FindStringSubmatch(o.html); m != nil {
+			return m[1]
+		}
+		return o.html
+	}
+	return strings.ReplaceAll(html.EscapeString(strings.TrimRight(o.text, "\n")), "\n", "<br>")
+}
+
+var (
+	gmailHTMLBreakPattern  = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|tr|h[1-6]|blockquote)>`)
+	gmailBlankLinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// gmailHTMLToText is a plain-text rendering of an HTML-only message for
+// quoting; unlike stripHTMLTags it keeps line structure.
+func gmailHTMLToText(s string) string {
+	s = scriptPattern.ReplaceAllString(s, "")
+	s = stylePattern.ReplaceAllString(s, "")
+	s = gmailHTMLBreakPattern.ReplaceAllString(s, "\n")
+	s = htmlTagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	s = gmailBlankLinesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestGmailPrefixedSubject(t *testing.T) {
+	cases := []struct{ prefix, in, want string }{
+		{"Re:", "Lunch", "Re: Lunch"},
+		{"Re:", "RE: Lunch", "RE: Lunch"},
+		{"Re:", "Fwd: Lunch", "Re: Fwd: Lunch"},
+		{"Fwd:", "Re: Lunch", "Fwd: Re: Lunch"},
+		{"Fwd:", "fw: Lunch", "fw: Lunch"},
+		{"Fwd:", "", "Fwd: (no subject)"},
+	}
+	for _, tc := range cases {
+		if got := gmailPrefixedSubject(tc.prefix, tc.in); got != tc.want {
+			t.Fatalf("%s %q: got %q, want %q", tc.prefix, tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestGmailHTMLToText(t *testing.T) {
+	got := gmailHTMLToText("<html><style>p{}</style><body><p>Hi &amp; welcome</p><div>line<br>two</div></body></html>")
+	if got != "Hi & welcome\nline\ntwo" {
+		t.Fatalf("unexpected text: %q", got)
+	}
+}
+
+// newGmailReplyTestServer serves message m1 (plain + HTML, one attachment)
+// and returns the raw RFC 822 of every sent message.
+func newGmailReplyTestServer(t *testing.T) *[]gmail.Message {
+	t.Helper()
+
+	b64 := func(s string) string { return base64.URLEncoding.EncodeToString([]byte(s)) }
+	headers := []map[string]any{
+		{"name": "From", "value": "Ada <ada@example.com>"},
+		{"name": "To", "value": "a@b.com, bob@example.com"},
+		{"name": "Date", "value": "Mon, 12 Oct 2026 09:30:00 +0000"},
+		{"name": "Subject", "value": "Plans"},
+		{"name": "Message-ID", "value": "<orig@example.com>"},
+	}
+	var sent []gmail.Message
+	setupGmailTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/users/me/messages/send"):
+			var msg gmail.Message
+			_ = json.NewDecoder(r.Body).Decode(&msg)
+			sent = append(sent, msg)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "sent1", "threadId": msg.ThreadId})
+		case strings.HasSuffix(r.URL.Path, "/users/me/messages/m1/attachments/att1"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": b64("PDFDATA"), "size": 7})
+		case strings.HasSuffix(r.URL.Path, "/users/me/messages/m1"):
+			if r.URL.Query().Get("format") == "raw" {
+				_ = json.NewEncoder(w).Encode(map[string]any{"id": "m1", "raw": b64("Subject: Plans\r\n\r\nHello\r\n")})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id":       "m1",
+				"threadId": "t1",
+				"payload": map[string]any{
+					"mimeType": "multipart/mixed",
+					"headers":  headers,
+					"parts": []map[string]any{
+						{"mimeType": "multipart/alternative", "parts": []map[string]any{
+							{"mimeType": "text/plain", "body": map[string]any{"data": b64("Let's meet.\n> earlier\n")}},
+							{"mimeType": "text/html", "body": map[string]any{"data": b64("<html><body><p>Let's meet.</p></body></html>")}},
+						}},
+						{"mimeType": "application/pdf", "filename": "agenda.pdf", "body": map[string]any{"attachmentId": "att1", "size": 7}},
+					},
+				},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	return &sent
+}
+
+func decodeSentRaw(t *testing.T, msg gmail.Message) string {
+	t.Helper()
+	raw, err := base64.RawURLEncoding.DecodeString(msg.Raw)
+	if err != nil {
+		t.Fatalf("decode raw: %v", err)
+	}
+	return string(raw)
+}
+
+func TestGmailReply_QuotesAndThreads(t *testing.T) {
+	sent := newGmailReplyTestServer(t)
+
+	_ = runDriveCmdJSON(t, &GmailReplyCmd{}, []string{"m1", "--all", "--body", "Works for me"})
+	if len(*sent) != 1 || (*sent)[0].ThreadId != "t1" {
+		t.Fatalf("unexpected sends: %+v", *sent)
+	}
+	raw := decodeSentRaw(t, (*sent)[0])
+	for _, want := range []string{
+		"To: ada@example.com, bob@example.com\r\n",
+		"Subject: Re: Plans\r\n",
+		"In-Reply-To: <orig@example.com>\r\n",
+		"Works for me\r\n\r\nOn Mon, Oct 12, 2026 at 9:30 AM, Ada <ada@example.com> wrote:\r\n> Let's meet.\r\n>> earlier\r\n",
+		`<blockquote class="gmail_quote"`,
+		"<p>Let's meet.</p></blockquote>",
+	} {
+		if !strings.Contains(raw, want) {
+			t.Fatalf("missing %q in:\n%s", want, raw)
+		}
+	}
+	if strings.Contains(raw, "<html>") {
+		t.Fatalf("expected the original <html> wrapper to be dropped:\n%s", raw)
+	}
+}
+
+func TestGmailForward_CarriesAttachments(t *testing.T) {
+	sent := newGmailReplyTestServer(t)
+
+	_ = runDriveCmdJSON(t, &GmailForwardCmd{}, []string{"m1", "--to", "carol@example.com", "--body", "FYI"})
+	raw := decodeSentRaw(t, (*sent)[0])
+	for _, want := range []string{
+		"To: carol@example.com\r\n",
+		"Subject: Fwd: Plans\r\n",
+		"FYI\r\n\r\n---------- Forwarded message ---------\r\nFrom: Ada <ada@example.com>\r\n",
+		"Content-Type: application/pdf\r\n",
+		`filename="agenda.pdf"`,
+		base64.StdEncoding.EncodeToString([]byte("PDFDATA")),
+	} {
+		if !strings.Contains(raw, want) {
+			t.Fatalf("missing %q in:\n%s", want, raw)
+		}
+	}
+
+	_ = runDriveCmdJSON(t, &GmailForwardCmd{}, []string{"m1", "--to", "carol@example.com", "--as-attachment"})
+	raw = decodeSentRaw(t, (*sent)[1])
+	if !strings.Contains(raw, "Content-Type: message/rfc822\r\n") || !strings.Contains(raw, `filename="Plans.eml"`) || strings.Contains(raw, "agenda.pdf") {
+		t.Fatalf("unexpected as-attachment forward:\n%s", raw)
+	}
+}
@@ -7,31 +7,42 @@ import (
 	"net/mail"
 	"os"
 	"strings"
+	"time"
 
 	"google.golang.org/api/gmail/v1"
 
-	"github.com/steipete/gogcli/internal/config"
 	"github.com/steipete/gogcli/internal/outfmt"
 	"github.com/steipete/gogcli/internal/tracking"
 	"github.com/steipete/gogcli/internal/ui"
 )
 
 type GmailSendCmd struct {
-	To               string   `name:"to" help:"Recipients (comma-separated; required unless --reply-all is used)"`
-	Cc               string   `name:"cc" help:"CC recipients (comma-separated)"`
-	Bcc              string   `name:"bcc" help:"BCC recipients (comma-separated)"`
-	Subject          string   `name:"subject" help:"Subject (required)"`
-	Body             string   `name:"body" help:"Body (plain text; required unless --body-html is set)"`
-	BodyFile         string   `name:"body-file" help:"Body file path (plain text; '-' for stdin)"`
-	BodyHTML         string   `name:"body-html" help:"Body (HTML; optional)"`
-	ReplyToMessageID string   `name:"reply-to-message-id" aliases:"in-reply-to" help:"Reply to Gmail message ID (sets In-Reply-To/References and thread)"`
-	ThreadID         string   `name:"thread-id" help:"Reply within a Gmail thread (uses latest message for headers)"`
-	ReplyAll         bool     `name:"reply-all" help:"Auto-populate recipients from original message (requires --reply-to-message-id or --thread-id)"`
-	ReplyTo          string   `name:"reply-to" help:"Reply-To header address"`
-	Attach           []string `name:"attach" help:"Attachment file path (repeatable)"`
-	From             string   `name:"from" help:"Send from this email address (must be a verified send-as alias)"`
-	Track            bool     `name:"track" help:"Enable open tracking (requires tracking setup)"`
-	TrackSplit       bool     `name:"track-split" help:"Send tracked messages separately per recipient"`
+	To               string        `name:"to" help:"Recipients (comma-separated; required unless --reply-all is used)"`
+	Cc               string        `name:"cc" help:"CC recipients (comma-separated)"`
+	Bcc              string        `name:"bcc" help:"BCC recipients (comma-separated)"`
+	Subject          string        `name:"subject" help:"Subject (required)"`
+	Body             string        `name:"body" help:"Body (plain text; required unless --body-html is set)"`
+	BodyFile         string        `name:"body-file" help:"Body file path (plain text; '-' for stdin)"`
+	BodyHTML         string        `name:"body-html" help:"Body (HTML; optional)"`
+	BodyMD           string        `name:"body-md" help:"Body (Markdown; sent as plain text plus rendered HTML)"`
+	BodyMDFile       string        `name:"body-md-file" help:"Markdown body file path ('-' for stdin)"`
+	ReplyToMessageID string        `name:"reply-to-message-id" aliases:"in-reply-to" help:"Reply to Gmail message ID (sets In-Reply-To/References and thread)"`
+	ThreadID         string        `name:"thread-id" help:"Reply within a Gmail thread (uses latest message for headers)"`
+	ReplyAll         bool          `name:"reply-all" help:"Auto-populate recipients from original message (requires --reply-to-message-id or --thread-id)"`
+	ReplyTo          string        `name:"reply-to" help:"Reply-To header address"`
+	Attach           []string      `name:"attach" help:"Attachment file path (repeatable)"`
+	Inline           []string      `name:"inline" help:"Inline image as cid=path, shown where the HTML body uses src=\"cid:<cid>\" (repeatable)"`
+	ICS              string        `name:"ics" help:"Calendar invite (.ics file) sent as text/calendar; method=REQUEST"`
+	From             string        `name:"from" help:"Send from this email address (must be a verified send-as alias)"`
+	Track            bool          `name:"track" help:"Enable open tracking (requires tracking setup)"`
+	TrackSplit       bool          `name:"track-split" help:"Send tracked messages separately per recipient"`
+	Merge            string        `name:"merge" help:"Mail merge: CSV or JSON data file; sends one message per row with --to/--cc/--subject/body rendered as templates"`
+	Template         string        `name:"template" help:"Mail merge: plain-text body template file (text/template)"`
+	TemplateHTML     string        `name:"template-html" help:"Mail merge: HTML body template file (html/template)"`
+	MergeResults     string        `name:"merge-results" help:"Mail merge: results file mapping rows to message IDs (default: <data>.results.json)"`
+	MergeDelay       time.Duration `name:"merge-delay" help:"Mail merge: pause between messages" default:"1s"`
+	DryRun           bool          `name:"dry-run" help:"Mail merge: render every row to stdout without sending"`
+	At               string        `name:"at" help:"Schedule: save as a draft and queue it for this time (e.g. '2026-10-20 09:00', tomorrow, +2h); sent by 'gmail queue run'"`
 }
 
 type sendBatch struct {
@@ -56,6 +67,7 @@ type sendMessageOptions struct {
 	BodyHTML    string
 	ReplyInfo   *replyInfo
 	Attachments []mailAttachment
+	Calendar    []byte
 	Track       bool
 	TrackingCfg *tracking.Config
 }
@@ -67,10 +79,20 @@ func (c *GmailSendCmd) Run(ctx context.Context, flags *RootFlags) error {
 		return err
 	}
 
+	if strings.TrimSpace(c.Merge) != "" {
+		if c.BodyMD != "" || c.BodyMDFile != "" {
+			return usage("--body-md/--body-md-file cannot be combined with --merge")
+		}
+		return c.runMerge(ctx, u, account)
+	}
+	if c.Template != "" || c.TemplateHTML != "" || c.MergeResults != "" || c.DryRun {
+		return usage("--template, --template-html, --merge-results and --dry-run require --merge")
+	}
+
 	replyToMessageID := strings.TrimSpace(c.ReplyToMessageID)
 	threadID := strings.TrimSpace(c.ThreadID)
 
-	body, err := resolveBodyInput(c.Body, c.BodyFile)
+	body, bodyHTML, err := resolveComposeBody(c.Body, c.BodyFile, c.BodyHTML, c.BodyMD, c.BodyMDFile)
 	if err != nil {
 		return err
 	}
@@ -91,37 +113,30 @@ func (c *GmailSendCmd) Run(ctx context.Context, flags *RootFlags) error {
 	if strings.TrimSpace(c.Subject) == "" {
 		return usage("required: --subject")
 	}
-	if strings.TrimSpace(body) == "" && strings.TrimSpace(c.BodyHTML) == "" {
-		return usage("required: --body, --body-file, or --body-html")
+	if strings.TrimSpace(body) == "" && strings.TrimSpace(bodyHTML) == "" {
+		return usage("required: --body, --body-file, --body-html or --body-md")
 	}
 	if c.TrackSplit && !c.Track {
 		return usage("--track-split requires --track")
 	}
+	var sendAt time.Time
+	if strings.TrimSpace(c.At) != "" {
+		if c.Track {
+			return usage("--at cannot be combined with --track")
+		}
+		if sendAt, err = parseGmailSendAt(c.At, time.Now()); err != nil {
+			return err
+		}
+	}
 
 	svc, err := newGmailService(ctx, account)
 	if err != nil {
 		return err
 	}
 
-	// Determine the From address
-	fromAddr := account
-	sendingEmail := account // The email we're sending from (without display name)
-	if strings.TrimSpace(c.From) != "" {
-		// Validate that this is a configured send-as alias
-		var sa *gmail.SendAs
-		sa, err = svc.Users.Settings.SendAs.Get("me", c.From).Context(ctx).Do()
-		if err != nil {
-			return fmt.Errorf("invalid --from address %q: %w", c.From, err)
-		}
-		if sa.VerificationStatus != gmailVerificationAccepted {
-			return fmt.Errorf("--from address %q is not verified (status: %s)", c.From, sa.VerificationStatus)
-		}
-		sendingEmail = c.From
-		fromAddr = c.From
-		// Include display name if set
-		if sa.DisplayName != "" {
-			fromAddr = sa.DisplayName + " <" + c.From + ">"
-		}
+	fromAddr, sendingEmail, err := resolveSendFrom(ctx, svc, account, c.From)
+	if err != nil {
+		return err
 	}
 
 	// Fetch reply info (includes recipient headers for reply-all)
@@ -152,13 +167,13 @@ func (c *GmailSendCmd) Run(ctx context.Context, flags *RootFlags) error {
 
 	bccRecipients := splitCSV(c.Bcc)
 
-	atts := make([]mailAttachment, 0, len(c.Attach))
-	for _, p := range c.Attach {
-		expanded, expandErr := config.ExpandPath(p)
-		if expandErr != nil {
-			return expandErr
-		}
-		atts = append(atts, mailAttachment{Path: expanded})
+	atts, err := gmailComposeAttachments(c.Attach, c.Inline)
+	if err != nil {
+		return err
+	}
+	calendar, err := readGmailInvite(c.ICS)
+	if err != nil {
+		return err
 	}
 
 	var trackingCfg *tracking.Config
@@ -169,18 +184,24 @@ func (c *GmailSendCmd) Run(ctx context.Context, flags *RootFlags) error {
 		}
 	}
 
-	batches := buildSendBatches(toRecipients, ccRecipients, bccRecipients, c.Track, c.TrackSplit)
-	results, err := sendGmailBatches(ctx, svc, sendMessageOptions{
+	opts := sendMessageOptions{
 		FromAddr:    fromAddr,
 		ReplyTo:     c.ReplyTo,
 		Subject:     c.Subject,
 		Body:        body,
-		BodyHTML:    c.BodyHTML,
+		BodyHTML:    bodyHTML,
 		ReplyInfo:   replyInfo,
 		Attachments: atts,
+		Calendar:    calendar,
 		Track:       c.Track,
 		TrackingCfg: trackingCfg,
-	}, batches)
+	}
+	if !sendAt.IsZero() {
+		return scheduleGmailSend(ctx, u, svc, account, sendAt, opts, toRecipients, ccRecipients, bccRecipients)
+	}
+
+	batches := buildSendBatches(toRecipients, ccRecipients, bccRecipients, c.Track, c.TrackSplit)
+	results, err := sendGmailBatches(ctx, svc, opts, batches)
 	if err != nil {
 		return err
 	}
@@ -188,16 +209,42 @@ func (c *GmailSendCmd) Run(ctx context.Context, flags *RootFlags) error {
 	return writeSendResults(ctx, u, fromAddr, results)
 }
 
+// resolveSendFrom returns the From header value and the bare sending address.
+// A non-empty from must be a verified send-as alias.
+func resolveSendFrom(ctx context.Context, svc *gmail.Service, account string, from string) (fromAddr string, sendingEmail string, err error) {
+	if strings.TrimSpace(from) == "" {
+		return account, account, nil
+	}
+	// Validate that this is a configured send-as alias
+	sa, err := svc.Users.Settings.SendAs.Get("me", from).Context(ctx).Do()
+	if err != nil {
+		return "", "", fmt.Errorf("invalid --from address %q: %w", from, err)
+	}
+	if sa.VerificationStatus != gmailVerificationAccepted {
+		return "", "", fmt.Errorf("--from address %q is not verified (status: %s)", from, sa.VerificationStatus)
+	}
+	fromAddr = from
+	// Include display name if set
+	if sa.DisplayName != "" {
+		fromAddr = sa.DisplayName + " <" + from + ">"
+	}
+	return fromAddr, from, nil
+}
+
 func (c *GmailSendCmd) resolveTrackingConfig(account string, toRecipients, ccRecipients, bccRecipients []string) (*tracking.Config, error) {
 	totalRecipients := len(toRecipients) + len(ccRecipients) + len(bccRecipients)
 	if totalRecipients != 1 && !c.TrackSplit {
 		return nil, usage("--track requires exactly 1 recipient (no cc/bcc); use --track-split for per-recipient sends")
 	}
 
-	if strings.TrimSpace(c.BodyHTML) == "" {
-		return nil, fmt.Errorf("--track requires --body-html (pixel must be in HTML)")
+	if strings.TrimSpace(c.BodyHTML) == "" && c.BodyMD == "" && c.BodyMDFile == "" {
+		return nil, fmt.Errorf("--track requires --body-html or --body-md (pixel must be in HTML)")
 	}
 
+	return loadSendTrackingConfig(account)
+}
+
+func loadSendTrackingConfig(account string) (*tracking.Config, error) {
 	trackingCfg, err := tracking.LoadConfig(account)
 	if err != nil {
 		return nil, fmt.Errorf("load tracking config: %w", err)
@@ -235,6 +282,8 @@ func buildSendBatches(toRecipients, ccRecipients, bccRecipients []string, track,
 	}}
 }
 
+// sendGmailBatches sends one message per batch. On error it returns the
+// results of the batches already sent along with the error.
 func sendGmailBatches(ctx context.Context, svc *gmail.Service, opts sendMessageOptions, batches []sendBatch) ([]sendResult, error) {
 	reply := replyInfo{}
 	if opts.ReplyInfo != nil {
@@ -252,7 +301,7 @@ func sendGmailBatches(ctx context.Context, svc *gmail.Service, opts sendMessageO
 			}
 			pixelURL, blob, pixelErr := tracking.GeneratePixelURL(opts.TrackingCfg, recipient, opts.Subject)
 			if pixelErr != nil {
-				return nil, fmt.Errorf("generate tracking pixel: %w", pixelErr)
+				return results, fmt.Errorf("generate tracking pixel: %w", pixelErr)
 			}
 			trackingID = blob
 
@@ -273,9 +322,10 @@ func sendGmailBatches(ctx context.Context, svc *gmail.Service, opts sendMessageO
 			InReplyTo:   reply.InReplyTo,
 			References:  reply.References,
 			Attachments: opts.Attachments,
+			Calendar:    opts.Calendar,
 		}, nil)
 		if err != nil {
-			return nil, err
+			return results, err
 		}
 
 		msg := &gmail.Message{
@@ -287,7 +337,7 @@ func sendGmailBatches(ctx context.Context, svc *gmail.Service, opts sendMessageO
 
 		sent, err := svc.Users.Messages.Send("me", msg).Context(ctx).Do()
 		if err != nil {
-			return nil, err
+			return results, err
 		}
 
 		resultRecipient := strings.TrimSpace(batch.TrackingRecipient)
@@ -0,0 +1,566 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/steipete/gogcli/internal/config"
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/tracking"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+// gmailMergeTemplates holds the per-row templates of a mail merge. Every row
+// is a map of column name to value, so templates use {{.first_name}} or
+// {{index . "First Name"}}.
+type gmailMergeTemplates struct {
+	to       *template.Template
+	cc       *template.Template
+	subject  *template.Template
+	body     *template.Template
+	bodyHTML *htmltemplate.Template
+}
+
+type gmailMergeMessage struct {
+	Row      int      `json:"row"`
+	To       []string `json:"to"`
+	Cc       []string `json:"cc,omitempty"`
+	Subject  string   `json:"subject"`
+	Body     string   `json:"body,omitempty"`
+	BodyHTML string   `json:"bodyHtml,omitempty"`
+}
+
+// gmailMergeResult is one row of the results file. Rows with a MessageID are
+// skipped when the merge is rerun, so failed rows can simply be retried.
+// With --track-split a row is sent as one message per recipient; Recipients
+// records each one, and a rerun of a partly sent row only sends the rest.
+type gmailMergeResult struct {
+	Row        int                   `json:"row"`
+	To         string                `json:"to"`
+	MessageID  string                `json:"messageId,omitempty"`
+	ThreadID   string                `json:"threadId,omitempty"`
+	TrackingID string                `json:"trackingId,omitempty"`
+	Error      string                `json:"error,omitempty"`
+	SentAt     string                `json:"sentAt,omitempty"`
+	Recipients []gmailMergeRecipient `json:"recipients,omitempty"`
+}
+
+type gmailMergeRecipient struct {
+	To         string `json:"to"`
+	MessageID  string `json:"messageId"`
+	ThreadID   string `json:"threadId,omitempty"`
+	TrackingID string `json:"trackingId,omitempty"`
+	SentAt     string `json:"sentAt"`
+}
+
+type gmailMergeResultsFile struct {
+	Data string             `json:"data"`
+	Rows []gmailMergeResult `json:"rows"`
+}
+
+func (c *GmailSendCmd) runMerge(ctx context.Context, u *ui.UI, account string) error {
+	if strings.TrimSpace(c.ReplyToMessageID) != "" || strings.TrimSpace(c.ThreadID) != "" || c.ReplyAll {
+		return usage("--merge cannot be combined with replies")
+	}
+	if strings.TrimSpace(c.At) != "" {
+		return usage("--at cannot be combined with --merge")
+	}
+	if c.TrackSplit && !c.Track {
+		return usage("--track-split requires --track")
+	}
+	if c.MergeDelay < 0 {
+		return usage("--merge-delay must be >= 0")
+	}
+
+	dataPath, err := config.ExpandPath(strings.TrimSpace(c.Merge))
+	if err != nil {
+		return err
+	}
+	rows, err := readGmailMergeRows(dataPath)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("%s has no rows", dataPath)
+	}
+
+	tmpl, err := c.parseMergeTemplates(rows[0])
+	if err != nil {
+		return err
+	}
+	if c.Track && tmpl.bodyHTML == nil {
+		return usage("--track requires an HTML body (--template-html or --body-html)")
+	}
+
+	messages := make([]gmailMergeMessage, 0, len(rows))
+	for i, row := range rows {
+		msg, renderErr := tmpl.render(i+1, row)
+		if renderErr != nil {
+			return renderErr
+		}
+		if c.Track && !c.TrackSplit && len(msg.To)+len(msg.Cc)+len(splitCSV(c.Bcc)) != 1 {
+			return usagef("row %d: --track requires exactly 1 recipient per row; use --track-split", msg.Row)
+		}
+		messages = append(messages, msg)
+	}
+
+	if c.DryRun {
+		return writeGmailMergeDryRun(ctx, u, messages)
+	}
+
+	resultsPath := strings.TrimSpace(c.MergeResults)
+	if resultsPath == "" {
+		resultsPath = dataPath + ".results.json"
+	}
+	if resultsPath, err = config.ExpandPath(resultsPath); err != nil {
+		return err
+	}
+	results, err := loadGmailMergeResults(resultsPath)
+	if err != nil {
+		return err
+	}
+	results.Data = dataPath
+
+	svc, err := newGmailService(ctx, account)
+	if err != nil {
+		return err
+	}
+	fromAddr, _, err := resolveSendFrom(ctx, svc, account, c.From)
+	if err != nil {
+		return err
+	}
+
+	var trackingCfg *tracking.Config
+	if c.Track {
+		if trackingCfg, err = loadSendTrackingConfig(account); err != nil {
+			return err
+		}
+	}
+
+	atts, err := gmailComposeAttachments(c.Attach, c.Inline)
+	if err != nil {
+		return err
+	}
+	calendar, err := readGmailInvite(c.ICS)
+	if err != nil {
+		return err
+	}
+
+	byRow := make(map[int]int, len(results.Rows))
+	for i, r := range results.Rows {
+		byRow[r.Row] = i
+	}
+
+	bcc := splitCSV(c.Bcc)
+	sent, skipped, failed := 0, 0, 0
+	for _, msg := range messages {
+		to := strings.Join(msg.To, ", ")
+		idx, known := byRow[msg.Row]
+		var prev gmailMergeResult
+		if known {
+			prev = results.Rows[idx]
+		}
+		if (prev.MessageID != "" || len(prev.Recipients) > 0) && !strings.EqualFold(prev.To, to) {
+			return fmt.Errorf("%s: row %d was sent to %s but the data now says %s; use a new --merge-results file", resultsPath, msg.Row, prev.To, to)
+		}
+		if prev.MessageID != "" {
+			skipped++
+			continue
+		}
+
+		if sent+failed > 0 && c.MergeDelay > 0 {
+			timer := time.NewTimer(c.MergeDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		res := gmailMergeResult{Row: msg.Row, To: to}
+		batches := buildSendBatches(msg.To, msg.Cc, bcc, c.Track, c.TrackSplit)
+		split := len(batches) > 1
+		if split {
+			res.Recipients = prev.Recipients
+			batches = unsentGmailMergeBatches(batches, prev.Recipients)
+		}
+		batchResults, sendErr := sendGmailBatches(ctx, svc, sendMessageOptions{
+			FromAddr:    fromAddr,
+			ReplyTo:     c.ReplyTo,
+			Subject:     msg.Subject,
+			Body:        msg.Body,
+			BodyHTML:    msg.BodyHTML,
+			Attachments: atts,
+			Calendar:    calendar,
+			Track:       c.Track,
+			TrackingCfg: trackingCfg,
+		}, batches)
+		sentAt := time.Now().UTC().Format(time.RFC3339)
+		if split {
+			// Keep the recipients that did get a message, even if a later one
+			// failed, so a rerun doesn't send them a second copy.
+			for _, br := range batchResults {
+				res.Recipients = append(res.Recipients, gmailMergeRecipient{
+					To:         br.To,
+					MessageID:  br.MessageID,
+					ThreadID:   br.ThreadID,
+					TrackingID: br.TrackingID,
+					SentAt:     sentAt,
+				})
+			}
+		}
+		switch {
+		case sendErr != nil:
+			res.Error = sendErr.Error()
+			failed++
+			if split && len(res.Recipients) > 0 {
+				u.Err().Printf("row %d\t%s\terror: %v (sent to %d of %d recipients)", msg.Row, to, sendErr, len(res.Recipients), len(res.Recipients)+len(batches)-len(batchResults))
+			} else {
+				u.Err().Printf("row %d\t%s\terror: %v", msg.Row, to, sendErr)
+			}
+		case split:
+			// The first recipient's message identifies the row.
+			first := res.Recipients[0]
+			res.MessageID, res.ThreadID, res.TrackingID, res.SentAt = first.MessageID, first.ThreadID, first.TrackingID, sentAt
+			sent++
+			u.Err().Printf("row %d\t%s\t%s", msg.Row, to, res.MessageID)
+		default:
+			res.MessageID = batchResults[0].MessageID
+			res.ThreadID = batchResults[0].ThreadID
+			res.TrackingID = batchResults[0].TrackingID
+			res.SentAt = sentAt
+			sent++
+			u.Err().Printf("row %d\t%s\t%s", msg.Row, to, res.MessageID)
+		}
+
+		if known {
+			results.Rows[idx] = res
+		} else {
+			byRow[msg.Row] = len(results.Rows)
+			results.Rows = append(results.Rows, res)
+		}
+		// Persist after every row so an interrupted merge never resends.
+		if err := saveGmailMergeResults(resultsPath, results); err != nil {
+			return err
+		}
+	}
+
+	if outfmt.IsJSON(ctx) {
+		if err := outfmt.WriteJSON(os.Stdout, map[string]any{
+			"sent":    sent,
+			"skipped": skipped,
+			"failed":  failed,
+			"results": resultsPath,
+			"rows":    results.Rows,
+		}); err != nil {
+			return err
+		}
+	} else {
+		u.Out().Printf("sent\t%d", sent)
+		u.Out().Printf("skipped\t%d", skipped)
+		u.Out().Printf("failed\t%d", failed)
+		u.Out().Printf("results\t%s", resultsPath)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rows failed; rerun the same command to retry them", failed, len(messages))
+	}
+	return nil
+}
+
+// unsentGmailMergeBatches drops the --track-split batches whose recipient
+// already got a message in an earlier run.
+func unsentGmailMergeBatches(batches []sendBatch, done []gmailMergeRecipient) []sendBatch {
+	if len(done) == 0 {
+		return batches
+	}
+	out := make([]sendBatch, 0, len(batches))
+	for _, b := range batches {
+		sentBefore := false
+		for _, r := range done {
+			if strings.EqualFold(r.To, b.TrackingRecipient) {
+				sentBefore = true
+				break
+			}
+		}
+		if !sentBefore {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+func (c *GmailSendCmd) parseMergeTemplates(sample map[string]string) (*gmailMergeTemplates, error) {
+	body, err := resolveBodyInput(c.Body, c.BodyFile)
+	if err != nil {
+		return nil, err
+	}
+	if c.Template != "" {
+		if strings.TrimSpace(body) != "" {
+			return nil, usage("use only one of --template or --body/--body-file")
+		}
+		if body, err = readGmailMergeTemplateFile(c.Template); err != nil {
+			return nil, err
+		}
+	}
+	bodyHTML := c.BodyHTML
+	if c.TemplateHTML != "" {
+		if strings.TrimSpace(bodyHTML) != "" {
+			return nil, usage("use only one of --template-html or --body-html")
+		}
+		if bodyHTML, err = readGmailMergeTemplateFile(c.TemplateHTML); err != nil {
+			return nil, err
+		}
+	}
+	if strings.TrimSpace(c.Subject) == "" {
+		return nil, usage("required: --subject")
+	}
+	if strings.TrimSpace(body) == "" && strings.TrimSpace(bodyHTML) == "" {
+		return nil, usage("required: --template, --template-html, --body, --body-file, or --body-html")
+	}
+
+	// Recipients default to the email/to and cc columns.
+	to := c.To
+	if strings.TrimSpace(to) == "" {
+		col := gmailMergeColumn(sample, "email", "to")
+		if col == "" {
+			return nil, usage("--merge needs --to or an \"email\"/\"to\" column")
+		}
+		to = "{{index . " + strconv.Quote(col) + "}}"
+	}
+	cc := c.Cc
+	if strings.TrimSpace(cc) == "" {
+		if col := gmailMergeColumn(sample, "cc"); col != "" {
+			cc = "{{index . " + strconv.Quote(col) + "}}"
+		}
+	}
+
+	t := &gmailMergeTemplates{}
+	parse := func(name, text string) (*template.Template, error) {
+		if strings.TrimSpace(text) == "" {
+			return nil, nil
+		}
+		parsed, parseErr := template.New(name).Option("missingkey=error").Parse(text)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse %s template: %w", name, parseErr)
+		}
+		return parsed, nil
+	}
+	if t.to, err = parse("to", to); err != nil {
+		return nil, err
+	}
+	if t.cc, err = parse("cc", cc); err != nil {
+		return nil, err
+	}
+	if t.subject, err = parse("subject", c.Subject); err != nil {
+		return nil, err
+	}
+	if t.body, err = parse("body", body); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(bodyHTML) != "" {
+		t.bodyHTML, err = htmltemplate.New("html").Option("missingkey=error").Parse(bodyHTML)
+		if err != nil {
+			return nil, fmt.Errorf("parse html template: %w", err)
+		}
+	}
+	return t, nil
+}
+
+func (t *gmailMergeTemplates) render(rowNum int, row map[string]string) (gmailMergeMessage, error) {
+	msg := gmailMergeMessage{Row: rowNum}
+	exec := func(tpl interface {
+		Execute(io.Writer, any) error
+	}, name string,
+	) (string, error) {
+		var buf bytes.Buffer
+		if err := tpl.Execute(&buf, row); err != nil {
+			return "", fmt.Errorf("row %d: render %s: %w", rowNum, name, err)
+		}
+		return buf.String(), nil
+	}
+
+	to, err := exec(t.to, "to")
+	if err != nil {
+		return msg, err
+	}
+	msg.To = splitCSV(to)
+	if len(msg.To) == 0 {
+		return msg, fmt.Errorf("row %d: no recipient", rowNum)
+	}
+	if t.cc != nil {
+		cc, ccErr := exec(t.cc, "cc")
+		if ccErr != nil {
+			return msg, ccErr
+		}
+		msg.Cc = splitCSV(cc)
+	}
+	subject, err := exec(t.subject, "subject")
+	if err != nil {
+		return msg, err
+	}
+	msg.Subject = strings.TrimSpace(strings.ReplaceAll(subject, "\n", " "))
+	if t.body != nil {
+		if msg.Body, err = exec(t.body, "body"); err != nil {
+			return msg, err
+		}
+	}
+	if t.bodyHTML != nil {
+		if msg.BodyHTML, err = exec(t.bodyHTML, "html"); err != nil {
+			return msg, err
+		}
+	}
+	return msg, nil
+}
+
+func writeGmailMergeDryRun(ctx context.Context, u *ui.UI, messages []gmailMergeMessage) error {
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"dryRun": true, "messages": messages})
+	}
+	for i, m := range messages {
+		if i > 0 {
+			u.Out().Println("")
+		}
+		u.Out().Printf("row\t%d", m.Row)
+		u.Out().Printf("to\t%s", strings.Join(m.To, ", "))
+		if len(m.Cc) > 0 {
+			u.Out().Printf("cc\t%s", strings.Join(m.Cc, ", "))
+		}
+		u.Out().Printf("subject\t%s", m.Subject)
+		if m.Body != "" {
+			u.Out().Println("")
+			u.Out().Println(strings.TrimRight(m.Body, "\n"))
+		}
+		if m.BodyHTML != "" {
+			u.Out().Println("")
+			u.Out().Println(strings.TrimRight(m.BodyHTML, "\n"))
+		}
+	}
+	return nil
+}
+
+// gmailMergeColumn returns the first column (case-insensitive) among names.
+func gmailMergeColumn(row map[string]string, names ...string) string {
+	for _, name := range names {
+		for col := range row {
+			if strings.EqualFold(strings.TrimSpace(col), name) {
+				return col
+			}
+		}
+	}
+	return ""
+}
+
+func readGmailMergeTemplateFile(path string) (string, error) {
+	expanded, err := config.ExpandPath(path)
+	if err != nil {
+		return "", err
+	}
+	b, err := os.ReadFile(expanded) //nolint:gosec // user-provided path
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// readGmailMergeRows reads a CSV file with a header row, or a JSON array of
+// objects (.json). Non-string JSON values are formatted with their JSON text.
+func readGmailMergeRows(path string) ([]map[string]string, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // user-provided path
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var objs []map[string]any
+		if err := json.Unmarshal(data, &objs); err != nil {
+			return nil, fmt.Errorf("decode %s: expected an array of objects: %w", path, err)
+		}
+		rows := make([]map[string]string, 0, len(objs))
+		for _, obj := range objs {
+			row := make(map[string]string, len(obj))
+			for k, v := range obj {
+				switch val := v.(type) {
+				case nil:
+					row[k] = ""
+				case string:
+					row[k] = val
+				default:
+					b, _ := json.Marshal(val)
+					row[k] = string(b)
+				}
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}
+
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	header := records[0]
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	rows := make([]map[string]string, 0, len(records)-1)
+	for n, rec := range records[1:] {
+		if len(rec) > len(header) {
+			return nil, fmt.Errorf("%s: row %d has %d fields, header has %d", path, n+1, len(rec), len(header))
+		}
+		row := make(map[string]string, len(header))
+		for i, col := range header {
+			if i < len(rec) {
+				row[col] = rec[i]
+			} else {
+				row[col] = ""
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func loadGmailMergeResults(path string) (*gmailMergeResultsFile, error) {
+	results := &gmailMergeResultsFile{}
+	data, err := os.ReadFile(path) //nolint:gosec // user-provided path
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return results, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, results); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return results, nil
+}
+
+func saveGmailMergeResults(path string, results *gmailMergeResultsFile) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/tracking"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+func writeMergeTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestReadGmailMergeRows(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := writeMergeTestFile(t, dir, "d.csv", "\xef\xbb\xbfEmail, First Name\nada@example.com,Ada\nbob@example.com\n")
+	rows, err := readGmailMergeRows(csvPath)
+	if err != nil || len(rows) != 2 || rows[0]["First Name"] != "Ada" || rows[1]["First Name"] != "" {
+		t.Fatalf("unexpected csv rows %v: %v", rows, err)
+	}
+	if col := gmailMergeColumn(rows[0], "email", "to"); col != "Email" {
+		t.Fatalf("unexpected email column %q", col)
+	}
+
+	jsonPath := writeMergeTestFile(t, dir, "d.json", `[{"to":"ada@example.com","n":3,"vip":true}]`)
+	rows, err = readGmailMergeRows(jsonPath)
+	if err != nil || len(rows) != 1 || rows[0]["n"] != "3" || rows[0]["vip"] != "true" {
+		t.Fatalf("unexpected json rows %v: %v", rows, err)
+	}
+}
+
+func TestGmailSendMerge_DryRunRendersTemplates(t *testing.T) {
+	dir := t.TempDir()
+	data := writeMergeTestFile(t, dir, "d.csv", "email,name,cc\nada@example.com,Ada <3,boss@example.com\n")
+	tmpl := writeMergeTestFile(t, dir, "body.tmpl", "Hi {{.name}},\nsee you.\n")
+	html := writeMergeTestFile(t, dir, "body.html", "<p>Hi {{.name}}</p>")
+
+	parsed := runDriveCmdJSON(t, &GmailSendCmd{}, []string{
+		"--merge", data, "--subject", "Hello {{.name}}", "--template", tmpl, "--template-html", html, "--dry-run",
+	})
+	msgs, _ := parsed["messages"].([]any)
+	if len(msgs) != 1 {
+		t.Fatalf("unexpected dry-run: %v", parsed)
+	}
+	m := msgs[0].(map[string]any)
+	if m["subject"] != "Hello Ada <3" || m["body"] != "Hi Ada <3,\nsee you.\n" || m["bodyHtml"] != "<p>Hi Ada &lt;3</p>" {
+		t.Fatalf("unexpected rendering: %v", m)
+	}
+	if to, _ := m["to"].([]any); len(to) != 1 || to[0] != "ada@example.com" {
+		t.Fatalf("unexpected to: %v", m["to"])
+	}
+	if cc, _ := m["cc"].([]any); len(cc) != 1 || cc[0] != "boss@example.com" {
+		t.Fatalf("unexpected cc: %v", m["cc"])
+	}
+
+	err := runKong(t, &GmailSendCmd{}, []string{"--merge", data, "--subject", "Hi {{.nmae}}", "--body", "x", "--dry-run"},
+		context.Background(), &RootFlags{Account: "a@b.com"})
+	if err == nil || !strings.Contains(err.Error(), "row 1") {
+		t.Fatalf("expected missing key error, got %v", err)
+	}
+}
+
+func TestGmailSendMerge_ResultsFileRetriesFailedRows(t *testing.T) {
+	failBob := true
+	var sentTo []string
+	setupGmailTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/users/me/messages/send") {
+			http.NotFound(w, r)
+			return
+		}
+		var msg gmail.Message
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		raw, _ := base64.RawURLEncoding.DecodeString(msg.Raw)
+		to := ""
+		for _, line := range strings.Split(string(raw), "\r\n") {
+			if strings.HasPrefix(line, "To: ") {
+				to = strings.TrimPrefix(line, "To: ")
+			}
+		}
+		if failBob && strings.Contains(to, "bob@") {
+			http.Error(w, "backend error", http.StatusInternalServerError)
+			return
+		}
+		sentTo = append(sentTo, to)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "id-" + to[:3], "threadId": "t"})
+	})
+
+	dir := t.TempDir()
+	data := writeMergeTestFile(t, dir, "d.csv", "email,name\nada@example.com,Ada\nbob@example.com,Bob\n")
+	args := []string{"--merge", data, "--subject", "Hi {{.name}}", "--body", "Hello {{.name}}", "--merge-delay", "0s"}
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := outfmt.WithMode(ui.WithUI(context.Background(), u), outfmt.Mode{JSON: true})
+	_ = captureStdout(t, func() {
+		err = runKong(t, &GmailSendCmd{}, args, ctx, &RootFlags{Account: "a@b.com"})
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 rows failed") {
+		t.Fatalf("expected row failure, got %v", err)
+	}
+
+	results, err := loadGmailMergeResults(data + ".results.json")
+	if err != nil || len(results.Rows) != 2 || results.Rows[0].MessageID != "id-ada" || results.Rows[1].Error == "" {
+		t.Fatalf("unexpected results %+v: %v", results, err)
+	}
+
+	failBob = false
+	parsed := runDriveCmdJSON(t, &GmailSendCmd{}, args)
+	if parsed["sent"] != float64(1) || parsed["skipped"] != float64(1) || strings.Join(sentTo, ";") != "ada@example.com;bob@example.com" {
+		t.Fatalf("unexpected retry: %v (sent %v)", parsed, sentTo)
+	}
+}
+
+func TestGmailSendMerge_TrackSplitKeepsPartialRows(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := tracking.SaveConfig("a@b.com", &tracking.Config{
+		Enabled:     true,
+		WorkerURL:   "https://example.com",
+		TrackingKey: mustTrackingKey(t),
+		AdminKey:    "admin",
+	}); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+
+	failBob := true
+	var sentTo []string
+	setupGmailTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg gmail.Message
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		raw, _ := base64.RawURLEncoding.DecodeString(msg.Raw)
+		to := ""
+		for _, line := range strings.Split(string(raw), "\r\n") {
+			if strings.HasPrefix(line, "To: ") {
+				to = strings.TrimPrefix(line, "To: ")
+			}
+		}
+		if failBob && strings.Contains(to, "bob@") {
+			http.Error(w, "backend error", http.StatusInternalServerError)
+			return
+		}
+		sentTo = append(sentTo, to)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "id-" + to[:3], "threadId": "t"})
+	})
+
+	dir := t.TempDir()
+	data := writeMergeTestFile(t, dir, "d.csv", "email,cc\nada@example.com,\"bob@example.com, carl@example.com\"\n")
+	args := []string{"--merge", data, "--subject", "Hi", "--body-html", "<p>Hi</p>", "--track", "--track-split", "--merge-delay", "0s"}
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := outfmt.WithMode(ui.WithUI(context.Background(), u), outfmt.Mode{JSON: true})
+	_ = captureStdout(t, func() {
+		err = runKong(t, &GmailSendCmd{}, args, ctx, &RootFlags{Account: "a@b.com"})
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 of 1 rows failed") {
+		t.Fatalf("expected row failure, got %v", err)
+	}
+
+	results, err := loadGmailMergeResults(data + ".results.json")
+	if err != nil || len(results.Rows) != 1 {
+		t.Fatalf("unexpected results %+v: %v", results, err)
+	}
+	row := results.Rows[0]
+	if row.MessageID != "" || row.Error == "" || len(row.Recipients) != 1 || row.Recipients[0].To != "ada@example.com" || row.Recipients[0].TrackingID == "" {
+		t.Fatalf("expected ada recorded on the failed row, got %+v", row)
+	}
+
+	// The rerun only sends to the recipients that didn't get a message.
+	failBob = false
+	sentTo = nil
+	parsed := runDriveCmdJSON(t, &GmailSendCmd{}, args)
+	if parsed["sent"] != float64(1) || strings.Join(sentTo, ";") != "bob@example.com;carl@example.com" {
+		t.Fatalf("unexpected retry: %v (sent %v)", parsed, sentTo)
+	}
+	results, err = loadGmailMergeResults(data + ".results.json")
+	if err != nil || len(results.Rows) != 1 || results.Rows[0].MessageID != "id-ada" || len(results.Rows[0].Recipients) != 3 || results.Rows[0].Error != "" {
+		t.Fatalf("unexpected results after retry %+v: %v", results, err)
+	}
+}
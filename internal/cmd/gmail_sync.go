@@ -0,0 +1,495 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"google.golang.org/api/gmail/v1"
+
+	"github.com/steipete/gogcli/internal/config"
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+const (
+	gmailSyncStateFile = "state.json"
+	gmailSyncIndexFile = "index.json"
+	gmailSyncSaveEvery = 500
+)
+
+type GmailSyncCmd struct {
+	Dir              string `name:"dir" help:"Local mirror directory" required:""`
+	Full             bool   `name:"full" help:"Re-list the whole mailbox instead of applying history (also removes local messages deleted remotely)"`
+	Concurrency      int    `name:"concurrency" help:"Parallel message downloads" default:"8"`
+	IncludeSpamTrash bool   `name:"include-spam-trash" negatable:"" help:"Mirror Spam and Trash too (remembered; changing it runs a full sync)"`
+}
+
+// gmailSyncState is the mirror's state.json. HistoryID is only advanced once
+// everything up to it has been written locally.
+type gmailSyncState struct {
+	Account          string `json:"account"`
+	HistoryID        string `json:"historyId,omitempty"`
+	IncludeSpamTrash bool   `json:"includeSpamTrash,omitempty"`
+	UpdatedAtMs      int64  `json:"updatedAtMs,omitempty"`
+}
+
+// gmailSyncEntry is one message in index.json.
+type gmailSyncEntry struct {
+	ThreadID     string   `json:"threadId,omitempty"`
+	Labels       []string `json:"labels,omitempty"`
+	InternalDate int64    `json:"internalDate,omitempty"`
+}
+
+type gmailSyncStore struct {
+	dir   string
+	state gmailSyncState
+	index map[string]*gmailSyncEntry
+}
+
+type gmailSyncResult struct {
+	Mode          string `json:"mode"`
+	Dir           string `json:"dir"`
+	HistoryID     string `json:"historyId"`
+	Added         int    `json:"added"`
+	Deleted       int    `json:"deleted"`
+	LabelsChanged int    `json:"labelsChanged"`
+	Total         int    `json:"total"`
+}
+
+func (c *GmailSyncCmd) Run(ctx context.Context, kctx *kong.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	dir, err := config.ExpandPath(strings.TrimSpace(c.Dir))
+	if err != nil {
+		return err
+	}
+	if dir == "" {
+		return usage("empty --dir")
+	}
+
+	store, err := openGmailSyncStore(dir)
+	if err != nil {
+		return err
+	}
+	if store.state.Account != "" && !strings.EqualFold(store.state.Account, account) {
+		return usagef("%s is a mirror of %s, not %s", dir, store.state.Account, account)
+	}
+	store.state.Account = account
+	// What the mirror holds must not depend on when a message arrived, so
+	// the Spam/Trash choice is kept in state.json and changing it re-lists.
+	full := c.Full || store.state.HistoryID == ""
+	if flagProvided(kctx, "include-spam-trash") && c.IncludeSpamTrash != store.state.IncludeSpamTrash {
+		store.state.IncludeSpamTrash = c.IncludeSpamTrash
+		full = true
+	}
+
+	svc, err := newGmailService(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	s := &gmailSyncer{svc: svc, store: store, concurrency: max(c.Concurrency, 1), includeSpamTrash: store.state.IncludeSpamTrash, u: u}
+	var res gmailSyncResult
+	if full {
+		res, err = s.full(ctx)
+	} else {
+		res, err = s.incremental(ctx)
+		if err != nil && isStaleHistoryError(err) {
+			u.Err().Printf("sync: stored historyId %s expired; doing a full sync", store.state.HistoryID)
+			res, err = s.full(ctx)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	res.Dir = dir
+	res.HistoryID = store.state.HistoryID
+	res.Total = len(store.index)
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, res)
+	}
+	u.Out().Printf("mode\t%s", res.Mode)
+	u.Out().Printf("added\t%d", res.Added)
+	u.Out().Printf("deleted\t%d", res.Deleted)
+	u.Out().Printf("labels_changed\t%d", res.LabelsChanged)
+	u.Out().Printf("total\t%d", res.Total)
+	u.Out().Printf("history_id\t%s", res.HistoryID)
+	return nil
+}
+
+type gmailSyncer struct {
+	svc              *gmail.Service
+	store            *gmailSyncStore
+	concurrency      int
+	includeSpamTrash bool
+	u                *ui.UI
+}
+
+// full downloads every message missing locally and drops local messages that
+// no longer exist. The profile historyId is read first, so anything that
+// changes during the download is picked up by the next incremental run.
+func (s *gmailSyncer) full(ctx context.Context) (gmailSyncResult, error) {
+	res := gmailSyncResult{Mode: "full"}
+	profile, err := s.svc.Users.GetProfile("me").Context(ctx).Do()
+	if err != nil {
+		return res, err
+	}
+
+	remote := map[string]bool{}
+	var missing []string
+	err = listGmailMessageIDs(ctx, s.svc, "", 0, s.includeSpamTrash, func(id string) error {
+		remote[id] = true
+		if _, ok := s.store.index[id]; !ok {
+			missing = append(missing, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+
+	for id := range s.store.index {
+		if !remote[id] {
+			if err := s.store.remove(id); err != nil {
+				return res, err
+			}
+			res.Deleted++
+		}
+	}
+
+	added, err := s.fetch(ctx, missing)
+	res.Added = added
+	if err != nil {
+		return res, err
+	}
+	return res, s.store.advance(formatHistoryID(profile.HistoryId))
+}
+
+// incremental applies history since the stored historyId: new messages are
+// downloaded, deleted ones removed and label changes written to the index.
+// Unless Spam and Trash are mirrored, a message moved there is removed like
+// a deleted one and one moved out of them is downloaded.
+func (s *gmailSyncer) incremental(ctx context.Context) (gmailSyncResult, error) {
+	res := gmailSyncResult{Mode: "incremental"}
+	startID, err := parseHistoryID(s.store.state.HistoryID)
+	if err != nil {
+		return res, err
+	}
+
+	pending := map[string]bool{}
+	deleted := map[string]bool{}
+	labelled := map[string]bool{}
+	var latest uint64
+	pageToken := ""
+	for {
+		call := s.svc.Users.History.List("me").
+			StartHistoryId(startID).
+			MaxResults(500).
+			HistoryTypes("messageAdded", "messageDeleted", "labelAdded", "labelRemoved").
+			Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Do()
+		if err != nil {
+			return res, err
+		}
+		for _, h := range resp.History {
+			if h == nil {
+				continue
+			}
+			for _, a := range h.MessagesAdded {
+				if a != nil && a.Message != nil && a.Message.Id != "" && s.wants(a.Message.LabelIds) {
+					pending[a.Message.Id] = true
+					delete(deleted, a.Message.Id)
+				}
+			}
+			for _, d := range h.MessagesDeleted {
+				if d != nil && d.Message != nil && d.Message.Id != "" {
+					delete(pending, d.Message.Id)
+					deleted[d.Message.Id] = true
+				}
+			}
+			for _, l := range h.LabelsAdded {
+				if l == nil || l.Message == nil {
+					continue
+				}
+				if !s.wants(l.LabelIds) {
+					delete(pending, l.Message.Id)
+					deleted[l.Message.Id] = true
+					continue
+				}
+				if s.store.applyLabels(l.Message.Id, l.LabelIds, nil) {
+					labelled[l.Message.Id] = true
+				}
+			}
+			for _, l := range h.LabelsRemoved {
+				if l == nil || l.Message == nil {
+					continue
+				}
+				if !s.wants(l.LabelIds) && s.wants(l.Message.LabelIds) {
+					pending[l.Message.Id] = true
+					delete(deleted, l.Message.Id)
+				}
+				if s.store.applyLabels(l.Message.Id, nil, l.LabelIds) {
+					labelled[l.Message.Id] = true
+				}
+			}
+		}
+		latest = max(latest, resp.HistoryId)
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+
+	for id := range deleted {
+		if _, ok := s.store.index[id]; !ok {
+			continue
+		}
+		if err := s.store.remove(id); err != nil {
+			return res, err
+		}
+		delete(labelled, id)
+		res.Deleted++
+	}
+	ids := make([]string, 0, len(pending))
+	for id := range pending {
+		if _, ok := s.store.index[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	added, err := s.fetch(ctx, ids)
+	res.Added = added
+	res.LabelsChanged = len(labelled)
+	if err != nil {
+		return res, err
+	}
+	return res, s.store.advance(formatHistoryID(latest))
+}
+
+// fetch downloads ids in raw format with bounded concurrency, saving the
+// index periodically so an interrupted initial sync resumes. Messages that
+// were deleted in the meantime are skipped.
+func (s *gmailSyncer) fetch(ctx context.Context, ids []string) (int, error) {
+	var (
+		mu       sync.Mutex
+		added    int
+		firstErr error
+	)
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for range s.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				msg, err := s.svc.Users.Messages.Get("me", id).Format(gmailFormatRaw).Context(ctx).Do()
+				var raw []byte
+				if err == nil {
+					raw, err = decodeGmailRaw(msg.Raw)
+				}
+				mu.Lock()
+				switch {
+				case err != nil && isNotFoundAPIError(err):
+				case err == nil && !s.wants(msg.LabelIds):
+				case err != nil:
+					if firstErr == nil {
+						firstErr = fmt.Errorf("message %s: %w", id, err)
+					}
+				default:
+					if writeErr := s.store.put(msg, raw); writeErr != nil {
+						if firstErr == nil {
+							firstErr = writeErr
+						}
+						break
+					}
+					added++
+					if added%gmailSyncSaveEvery == 0 {
+						if saveErr := s.store.saveIndex(); saveErr != nil && firstErr == nil {
+							firstErr = saveErr
+						}
+						s.u.Err().Printf("sync: downloaded %d/%d messages", added, len(ids))
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range ids {
+		mu.Lock()
+		stop := firstErr != nil
+		mu.Unlock()
+		if stop {
+			break
+		}
+		select {
+		case jobs <- id:
+		case <-ctx.Done():
+			close(jobs)
+			wg.Wait()
+			return added, ctx.Err()
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := s.store.saveIndex(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return added, firstErr
+}
+
+// wants reports whether a message with labels belongs in the mirror: Spam
+// and Trash only do with --include-spam-trash.
+func (s *gmailSyncer) wants(labels []string) bool {
+	if s.includeSpamTrash {
+		return true
+	}
+	for _, l := range labels {
+		if l == "SPAM" || l == "TRASH" {
+			return false
+		}
+	}
+	return true
+}
+
+func openGmailSyncStore(dir string) (*gmailSyncStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "messages"), 0o700); err != nil {
+		return nil, err
+	}
+	store := &gmailSyncStore{dir: dir, index: map[string]*gmailSyncEntry{}}
+	if err := readGmailSyncJSON(filepath.Join(dir, gmailSyncStateFile), &store.state); err != nil {
+		return nil, err
+	}
+	if err := readGmailSyncJSON(filepath.Join(dir, gmailSyncIndexFile), &store.index); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func readGmailSyncJSON(path string, v any) error {
+	data, err := os.ReadFile(path) //nolint:gosec // path is inside the user-provided mirror dir
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeGmailSyncJSON replaces path atomically so a crash never leaves a
+// truncated index behind.
+func writeGmailSyncJSON(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// messagePath shards messages by the last two characters of the ID, which
+// vary more than the time-based prefix.
+func (s *gmailSyncStore) messagePath(id string) string {
+	shard := id
+	if len(id) > 2 {
+		shard = id[len(id)-2:]
+	}
+	return filepath.Join(s.dir, "messages", shard, id+".eml")
+}
+
+func (s *gmailSyncStore) put(msg *gmail.Message, raw []byte) error {
+	path := s.messagePath(msg.Id)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		return err
+	}
+	s.index[msg.Id] = &gmailSyncEntry{ThreadID: msg.ThreadId, Labels: msg.LabelIds, InternalDate: msg.InternalDate}
+	return nil
+}
+
+func (s *gmailSyncStore) remove(id string) error {
+	if err := os.Remove(s.messagePath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	delete(s.index, id)
+	return nil
+}
+
+// applyLabels updates a known message's labels and reports whether anything
+// changed.
+func (s *gmailSyncStore) applyLabels(id string, add []string, remove []string) bool {
+	entry, ok := s.index[id]
+	if !ok {
+		return false
+	}
+	set := make(map[string]bool, len(entry.Labels)+len(add))
+	for _, l := range entry.Labels {
+		set[l] = true
+	}
+	changed := false
+	for _, l := range add {
+		if !set[l] {
+			set[l] = true
+			changed = true
+		}
+	}
+	for _, l := range remove {
+		if set[l] {
+			delete(set, l)
+			changed = true
+		}
+	}
+	if changed {
+		entry.Labels = entry.Labels[:0]
+		for l := range set {
+			entry.Labels = append(entry.Labels, l)
+		}
+		sort.Strings(entry.Labels)
+	}
+	return changed
+}
+
+func (s *gmailSyncStore) saveIndex() error {
+	return writeGmailSyncJSON(filepath.Join(s.dir, gmailSyncIndexFile), s.index)
+}
+
+// advance saves the index, then moves the stored historyId forward.
+func (s *gmailSyncStore) advance(historyID string) error {
+	if err := s.saveIndex(); err != nil {
+		return err
+	}
+	update, err := shouldUpdateHistoryID(s.state.HistoryID, historyID)
+	if err != nil {
+		return err
+	}
+	if update {
+		s.state.HistoryID = historyID
+	}
+	s.state.UpdatedAtMs = time.Now().UnixMilli()
+	return writeGmailSyncJSON(filepath.Join(s.dir, gmailSyncStateFile), s.state)
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGmailSyncStore_ApplyLabels(t *testing.T) {
+	store := &gmailSyncStore{index: map[string]*gmailSyncEntry{"m1": {Labels: []string{"INBOX", "UNREAD"}}}}
+	if !store.applyLabels("m1", []string{"STARRED"}, []string{"UNREAD"}) {
+		t.Fatalf("expected change")
+	}
+	if got := strings.Join(store.index["m1"].Labels, ","); got != "INBOX,STARRED" {
+		t.Fatalf("unexpected labels: %s", got)
+	}
+	if store.applyLabels("m1", []string{"INBOX"}, nil) || store.applyLabels("missing", []string{"X"}, nil) {
+		t.Fatalf("expected no change")
+	}
+}
+
+func TestGmailSync_FullThenIncremental(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	historyCalls := 0
+	setupGmailTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := r.URL.Path
+		switch {
+		case strings.HasSuffix(path, "/users/me/profile"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"emailAddress": "a@b.com", "historyId": "100"})
+		case strings.HasSuffix(path, "/users/me/messages"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"messages": []map[string]any{{"id": "m1"}, {"id": "m2"}}})
+		case strings.HasSuffix(path, "/users/me/history"):
+			historyCalls++
+			if r.URL.Query().Get("startHistoryId") != "100" {
+				t.Errorf("unexpected startHistoryId: %q", r.URL.Query().Get("startHistoryId"))
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"historyId": "120",
+				"history": []map[string]any{
+					{"id": "110", "messagesAdded": []map[string]any{{"message": map[string]any{"id": "m3"}}}},
+					{"id": "111", "messagesDeleted": []map[string]any{{"message": map[string]any{"id": "m1"}}}},
+					{"id": "112", "labelsAdded": []map[string]any{{"message": map[string]any{"id": "m2"}, "labelIds": []string{"STARRED"}}}},
+				},
+			})
+		case strings.Contains(path, "/users/me/messages/"):
+			id := path[strings.LastIndex(path, "/")+1:]
+			raw := "Subject: " + id + "\r\n\r\nbody\r\n"
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id":           id,
+				"threadId":     "t-" + id,
+				"internalDate": "1700000000000",
+				"labelIds":     []string{"INBOX"},
+				"raw":          base64.URLEncoding.EncodeToString([]byte(raw)),
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	dir := t.TempDir()
+	parsed := runDriveCmdJSON(t, &GmailSyncCmd{}, []string{"--dir", dir})
+	if parsed["mode"] != "full" || parsed["added"] != float64(2) || parsed["historyId"] != "100" {
+		t.Fatalf("unexpected full sync: %v", parsed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "messages", "m1", "m1.eml")); err != nil {
+		t.Fatalf("expected m1.eml: %v", err)
+	}
+
+	parsed = runDriveCmdJSON(t, &GmailSyncCmd{}, []string{"--dir", dir})
+	if parsed["mode"] != "incremental" || parsed["added"] != float64(1) || parsed["deleted"] != float64(1) ||
+		parsed["labelsChanged"] != float64(1) || parsed["historyId"] != "120" || parsed["total"] != float64(2) || historyCalls != 1 {
+		t.Fatalf("unexpected incremental sync: %v", parsed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "messages", "m1", "m1.eml")); !os.IsNotExist(err) {
+		t.Fatalf("expected m1.eml removed: %v", err)
+	}
+
+	var index map[string]gmailSyncEntry
+	data, err := os.ReadFile(filepath.Join(dir, gmailSyncIndexFile))
+	if err != nil || json.Unmarshal(data, &index) != nil {
+		t.Fatalf("read index: %v", err)
+	}
+	if strings.Join(index["m2"].Labels, ",") != "INBOX,STARRED" || index["m3"].ThreadID != "t-m3" {
+		t.Fatalf("unexpected index: %+v", index)
+	}
+}
+
+func TestGmailSync_SpamTrashLeavesMirror(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var fetched []string
+	setupGmailTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := r.URL.Path
+		switch {
+		case strings.HasSuffix(path, "/users/me/profile"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"emailAddress": "a@b.com", "historyId": "100"})
+		case strings.HasSuffix(path, "/users/me/messages"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"messages": []map[string]any{{"id": "m1"}, {"id": "m2"}}})
+		case strings.HasSuffix(path, "/users/me/history"):
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"historyId": "120",
+				"history": []map[string]any{
+					{"id": "110", "messagesAdded": []map[string]any{{"message": map[string]any{"id": "m3", "labelIds": []string{"SPAM"}}}}},
+					{"id": "111", "labelsAdded": []map[string]any{{"message": map[string]any{"id": "m2"}, "labelIds": []string{"TRASH"}}}},
+				},
+			})
+		case strings.Contains(path, "/users/me/messages/"):
+			id := path[strings.LastIndex(path, "/")+1:]
+			fetched = append(fetched, id)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id":       id,
+				"threadId": "t-" + id,
+				"labelIds": []string{"INBOX"},
+				"raw":      base64.URLEncoding.EncodeToString([]byte("Subject: " + id + "\r\n\r\nbody\r\n")),
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	dir := t.TempDir()
+	_ = runDriveCmdJSON(t, &GmailSyncCmd{}, []string{"--dir", dir})
+	fetched = nil
+
+	parsed := runDriveCmdJSON(t, &GmailSyncCmd{}, []string{"--dir", dir})
+	if parsed["mode"] != "incremental" || parsed["added"] != float64(0) || parsed["deleted"] != float64(1) || parsed["total"] != float64(1) {
+		t.Fatalf("unexpected incremental sync: %v", parsed)
+	}
+	if len(fetched) != 0 {
+		t.Fatalf("expected no downloads, got %v", fetched)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "messages", "m2", "m2.eml")); !os.IsNotExist(err) {
+		t.Fatalf("expected trashed m2.eml removed: %v", err)
+	}
+
+	parsed = runDriveCmdJSON(t, &GmailSyncCmd{}, []string{"--dir", dir, "--include-spam-trash"})
+	if parsed["mode"] != "full" {
+		t.Fatalf("expected changing --include-spam-trash to run a full sync: %v", parsed)
+	}
+	var state gmailSyncState
+	data, err := os.ReadFile(filepath.Join(dir, gmailSyncStateFile))
+	if err != nil || json.Unmarshal(data, &state) != nil || !state.IncludeSpamTrash {
+		t.Fatalf("expected includeSpamTrash in state: %s (%v)", data, err)
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
@@ -30,14 +31,19 @@ type GmailWatchCmd struct {
 	Renew  GmailWatchRenewCmd  `cmd:"" name:"renew" help:"Renew Gmail watch using stored config"`
 	Stop   GmailWatchStopCmd   `cmd:"" name:"stop" help:"Stop Gmail watch and clear stored state"`
 	Serve  GmailWatchServeCmd  `cmd:"" name:"serve" help:"Run Pub/Sub push handler"`
+	Poll   GmailWatchPollCmd   `cmd:"" name:"poll" help:"Poll history (or pull Pub/Sub) and deliver to the hook without a public endpoint"`
+	Outbox GmailWatchOutboxCmd `cmd:"" name:"outbox" help:"Inspect and retry failed hook deliveries"`
+	Verify GmailWatchVerifyCmd `cmd:"" name:"verify" help:"Verify an HMAC-signed hook payload"`
+	Rules  GmailWatchRulesCmd  `cmd:"" name:"rules" help:"Filter and route watch events before forwarding"`
 }
 
 type GmailWatchStartCmd struct {
 	Topic       string   `name:"topic" help:"Pub/Sub topic (projects/.../topics/...)"`
 	Labels      []string `name:"label" help:"Label IDs or names (repeatable, comma-separated)"`
 	TTL         string   `name:"ttl" help:"Renew after duration (seconds or Go duration)"`
-	HookURL     string   `name:"hook-url" help:"Webhook URL to forward messages"`
+	HookURL     string   `name:"hook-url" help:"Hook to forward messages to: http(s)://, file://, unix:// or exec:<command>"`
 	HookToken   string   `name:"hook-token" help:"Webhook bearer token"`
+	HookSecret  string   `name:"hook-secret" help:"Sign hook payloads with HMAC-SHA256 using this secret"`
 	IncludeBody bool     `name:"include-body" help:"Include text/plain body in hook payload"`
 	MaxBytes    int      `name:"max-bytes" help:"Max bytes of body to include" default:"20000"`
 }
@@ -63,6 +69,12 @@ func (c *GmailWatchStartCmd) Run(ctx context.Context, kctx *kong.Context, flags
 			return err
 		}
 	}
+	if c.HookSecret != "" {
+		if hook == nil {
+			return usage("--hook-url required when using --hook-secret")
+		}
+		hook.Secret = c.HookSecret
+	}
 
 	svc, err := newGmailService(ctx, account)
 	if err != nil {
@@ -82,6 +94,9 @@ func (c *GmailWatchStartCmd) Run(ctx context.Context, kctx *kong.Context, flags
 		return err
 	}
 
+	if prev, loadErr := loadGmailWatchStore(account); loadErr == nil {
+		state.Rules = prev.Get().Rules
+	}
 	store, err := newGmailWatchStore(account)
 	if err != nil {
 		return err
@@ -150,6 +165,7 @@ func (c *GmailWatchRenewCmd) Run(ctx context.Context, flags *RootFlags) error {
 	}
 
 	if err := store.Update(func(s *gmailWatchState) error {
+		updated.Rules = s.Rules
 		*s = updated
 		return nil
 	}); err != nil {
@@ -191,23 +207,36 @@ func (c *GmailWatchStopCmd) Run(ctx context.Context, flags *RootFlags) error {
 }
 
 type GmailWatchServeCmd struct {
-	Bind         string `name:"bind" help:"Bind address" default:"127.0.0.1"`
-	Port         int    `name:"port" help:"Listen port" default:"8788"`
-	Path         string `name:"path" help:"Push handler path" default:"/gmail-pubsub"`
-	VerifyOIDC   bool   `name:"verify-oidc" help:"Verify Pub/Sub OIDC tokens"`
-	OIDCEmail    string `name:"oidc-email" help:"Expected service account email"`
-	OIDCAudience string `name:"oidc-audience" help:"Expected OIDC audience"`
-	SharedToken  string `name:"token" help:"Shared token for x-gog-token or ?token="`
-	HookURL      string `name:"hook-url" help:"Webhook URL to forward messages"`
-	HookToken    string `name:"hook-token" help:"Webhook bearer token"`
-	IncludeBody  bool   `name:"include-body" help:"Include text/plain body in hook payload"`
-	MaxBytes     int    `name:"max-bytes" help:"Max bytes of body to include" default:"20000"`
-	SaveHook     bool   `name:"save-hook" help:"Persist hook settings to watch state"`
+	Bind         string   `name:"bind" help:"Bind address" default:"127.0.0.1"`
+	Port         int      `name:"port" help:"Listen port" default:"8788"`
+	Path         string   `name:"path" help:"Push handler path" default:"/gmail-pubsub"`
+	Accounts     []string `name:"accounts" help:"Serve several accounts (comma-separated or repeated); pushes are routed by emailAddress"`
+	VerifyOIDC   bool     `name:"verify-oidc" help:"Verify Pub/Sub OIDC tokens"`
+	OIDCEmail    string   `name:"oidc-email" help:"Expected service account email"`
+	OIDCAudience string   `name:"oidc-audience" help:"Expected OIDC audience"`
+	SharedToken  string   `name:"token" help:"Shared token for x-gog-token or ?token="`
+
+	Hook GmailWatchHookFlags `embed:""`
+}
+
+// GmailWatchHookFlags configures where `watch serve` and `watch poll`
+// deliver payloads.
+type GmailWatchHookFlags struct {
+	HookURL     string `name:"hook-url" help:"Hook to forward messages to: http(s)://, file://, unix:// or exec:<command>"`
+	HookToken   string `name:"hook-token" help:"Webhook bearer token"`
+	HookSecret  string `name:"hook-secret" help:"Sign hook payloads with HMAC-SHA256 using this secret"`
+	IncludeBody bool   `name:"include-body" help:"Include text/plain body in hook payload"`
+	MaxBytes    int    `name:"max-bytes" help:"Max bytes of body to include" default:"20000"`
+	SaveHook    bool   `name:"save-hook" help:"Persist hook settings to watch state"`
+	Rules       string `name:"rules" help:"Filter/route messages with this rules file instead of the stored rules (see 'watch rules')"`
+
+	OutboxMaxAttempts int           `name:"outbox-max-attempts" help:"Queue failed hook deliveries and dead-letter them after this many attempts (0 disables the outbox)" default:"8"`
+	OutboxInterval    time.Duration `name:"outbox-interval" help:"How often to retry queued hook deliveries" default:"30s"`
 }
 
 func (c *GmailWatchServeCmd) Run(ctx context.Context, kctx *kong.Context, flags *RootFlags) error {
 	u := ui.FromContext(ctx)
-	account, err := requireAccount(flags)
+	accounts, err := c.serveAccounts(flags)
 	if err != nil {
 		return err
 	}
@@ -226,23 +255,132 @@ func (c *GmailWatchServeCmd) Run(ctx context.Context, kctx *kong.Context, flags
 	if c.OIDCAudience != "" && !c.VerifyOIDC {
 		return usage("--oidc-audience requires --verify-oidc")
 	}
+	if err := c.Hook.validate(); err != nil {
+		return err
+	}
+
+	servers := make([]*gmailWatchServer, 0, len(accounts))
+	for _, account := range accounts {
+		server, serverErr := c.newServer(kctx, u, account, len(accounts) > 1)
+		if serverErr != nil {
+			if len(accounts) > 1 {
+				return fmt.Errorf("%s: %w", account, serverErr)
+			}
+			return serverErr
+		}
+		servers = append(servers, server)
+	}
+
+	if c.VerifyOIDC {
+		validator, validatorErr := newOIDCValidator(ctx)
+		if validatorErr != nil {
+			return validatorErr
+		}
+		for _, server := range servers {
+			server.validator = validator
+		}
+	}
+
+	var handler http.Handler = servers[0]
+	addr := net.JoinHostPort(c.Bind, strconv.Itoa(c.Port))
+	if len(servers) > 1 {
+		handler = newGmailWatchMux(servers)
+		u.Err().Printf("watch: listening on %s%s for %s", addr, c.Path, strings.Join(accounts, ", "))
+	} else {
+		u.Err().Printf("watch: listening on %s%s", addr, c.Path)
+	}
+
+	outboxCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for _, server := range servers {
+		if server.cfg.OutboxMaxAttempts > 0 {
+			go server.runOutbox(outboxCtx)
+		}
+	}
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return listenAndServe(httpServer)
+}
+
+// serveAccounts returns --accounts (deduplicated) or the single account
+// from the root flags.
+func (c *GmailWatchServeCmd) serveAccounts(flags *RootFlags) ([]string, error) {
+	if len(c.Accounts) == 0 {
+		account, err := requireAccount(flags)
+		if err != nil {
+			return nil, err
+		}
+		return []string{account}, nil
+	}
+	seen := make(map[string]bool, len(c.Accounts))
+	accounts := make([]string, 0, len(c.Accounts))
+	for _, account := range c.Accounts {
+		account = strings.TrimSpace(account)
+		if account == "" || seen[strings.ToLower(account)] {
+			continue
+		}
+		seen[strings.ToLower(account)] = true
+		accounts = append(accounts, account)
+	}
+	if len(accounts) == 0 {
+		return nil, usage("--accounts requires at least one account")
+	}
+	return accounts, nil
+}
 
+// newServer builds the push handler for one account from its stored watch
+// state.
+func (c *GmailWatchServeCmd) newServer(kctx *kong.Context, u *ui.UI, account string, prefixLogs bool) (*gmailWatchServer, error) {
 	store, err := loadGmailWatchStore(account)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return c.Hook.newWatchServer(kctx, u, store, gmailWatchServeConfig{
+		Account:      account,
+		Bind:         c.Bind,
+		Port:         c.Port,
+		Path:         c.Path,
+		VerifyOIDC:   c.VerifyOIDC,
+		OIDCEmail:    c.OIDCEmail,
+		OIDCAudience: c.OIDCAudience,
+		SharedToken:  c.SharedToken,
+	}, prefixLogs)
+}
+
+func (f *GmailWatchHookFlags) validate() error {
+	if f.OutboxMaxAttempts < 0 {
+		return usage("--outbox-max-attempts must be >= 0")
+	}
+	if f.OutboxMaxAttempts > 0 && f.OutboxInterval <= 0 {
+		return usage("--outbox-interval must be > 0")
 	}
+	return nil
+}
+
+// newWatchServer builds a watch handler for cfg.Account; hook flags override
+// the hook stored in the watch state.
+func (f *GmailWatchHookFlags) newWatchServer(kctx *kong.Context, u *ui.UI, store *gmailWatchStore, cfg gmailWatchServeConfig, prefixLogs bool) (*gmailWatchServer, error) {
+	account := cfg.Account
 	state := store.Get()
 
-	hookURL := c.HookURL
-	hookToken := c.HookToken
-	includeBody := c.IncludeBody
-	maxBytes := c.MaxBytes
+	hookURL := f.HookURL
+	hookToken := f.HookToken
+	hookSecret := f.HookSecret
+	includeBody := f.IncludeBody
+	maxBytes := f.MaxBytes
 
 	if hookURL == "" && state.Hook != nil {
 		hookURL = state.Hook.URL
 		if !flagProvided(kctx, "hook-token") {
 			hookToken = state.Hook.Token
 		}
+		if !flagProvided(kctx, "hook-secret") {
+			hookSecret = state.Hook.Secret
+		}
 		if !flagProvided(kctx, "include-body") {
 			includeBody = state.Hook.IncludeBody
 		}
@@ -257,74 +395,74 @@ func (c *GmailWatchServeCmd) Run(ctx context.Context, kctx *kong.Context, flags
 		if errors.Is(err, errNoHookConfigured) {
 			hook = nil
 		} else {
-			return err
+			return nil, err
 		}
 	}
-	if c.SaveHook && hook != nil {
+	if hook != nil {
+		hook.Secret = hookSecret
+	} else if hookSecret != "" {
+		return nil, usage("--hook-url required when using --hook-secret")
+	}
+	if f.SaveHook && hook != nil {
 		if updateErr := store.Update(func(s *gmailWatchState) error {
 			s.Hook = hook
 			s.UpdatedAtMs = time.Now().UnixMilli()
 			return nil
 		}); updateErr != nil {
-			return updateErr
-		}
-	}
-
-	validator := (*idtoken.Validator)(nil)
-	if c.VerifyOIDC {
-		validator, err = newOIDCValidator(ctx)
-		if err != nil {
-			return err
+			return nil, updateErr
 		}
 	}
 
-	cfg := gmailWatchServeConfig{
-		Account:      account,
-		Bind:         c.Bind,
-		Port:         c.Port,
-		Path:         c.Path,
-		VerifyOIDC:   c.VerifyOIDC,
-		OIDCEmail:    c.OIDCEmail,
-		OIDCAudience: c.OIDCAudience,
-		SharedToken:  c.SharedToken,
-		HookTimeout:  defaultHookRequestTimeoutSec * time.Second,
-		HistoryMax:   defaultHistoryMaxResults,
-		ResyncMax:    defaultHistoryResyncMax,
-		AllowNoHook:  hook == nil,
-		IncludeBody:  includeBody,
-		MaxBodyBytes: maxBytes,
-	}
+	cfg.HookTimeout = defaultHookRequestTimeoutSec * time.Second
+	cfg.HistoryMax = defaultHistoryMaxResults
+	cfg.ResyncMax = defaultHistoryResyncMax
+	cfg.AllowNoHook = hook == nil
+	cfg.IncludeBody = includeBody
+	cfg.MaxBodyBytes = maxBytes
 	if hook != nil {
 		cfg.HookURL = hook.URL
 		cfg.HookToken = hook.Token
+		cfg.HookSecret = hook.Secret
 		cfg.IncludeBody = hook.IncludeBody
 		cfg.MaxBodyBytes = hook.MaxBytes
+		cfg.OutboxMaxAttempts = f.OutboxMaxAttempts
+		cfg.OutboxInterval = f.OutboxInterval
 	}
 
 	if cfg.MaxBodyBytes <= 0 {
 		cfg.MaxBodyBytes = defaultHookMaxBytes
 	}
 
-	hookClient := &http.Client{Timeout: cfg.HookTimeout}
-	server := &gmailWatchServer{
-		cfg:        cfg,
-		store:      store,
-		validator:  validator,
-		newService: newGmailService,
-		hookClient: hookClient,
-		logf:       u.Err().Printf,
-		warnf:      u.Err().Printf,
+	ruleList := state.Rules
+	if f.Rules != "" {
+		if ruleList, err = readGmailWatchRulesFile(f.Rules); err != nil {
+			return nil, err
+		}
+	}
+	rules, err := newGmailWatchRules(ruleList)
+	if err != nil {
+		return nil, err
+	}
+	if hook == nil && rules.routes() {
+		cfg.OutboxMaxAttempts = f.OutboxMaxAttempts
+		cfg.OutboxInterval = f.OutboxInterval
 	}
 
-	addr := net.JoinHostPort(c.Bind, strconv.Itoa(c.Port))
-	u.Err().Printf("watch: listening on %s%s", addr, c.Path)
-
-	httpServer := &http.Server{
-		Addr:              addr,
-		Handler:           server,
-		ReadHeaderTimeout: 5 * time.Second,
+	logf, warnf := u.Err().Printf, u.Err().Printf
+	if prefixLogs {
+		prefix := account + ": "
+		logf = func(format string, args ...any) { u.Err().Printf(prefix+format, args...) }
+		warnf = logf
 	}
-	return listenAndServe(httpServer)
+	return &gmailWatchServer{
+		cfg:        cfg,
+		store:      store,
+		newService: newGmailService,
+		hookClient: &http.Client{Timeout: cfg.HookTimeout},
+		rules:      rules,
+		logf:       logf,
+		warnf:      warnf,
+	}, nil
 }
 
 func writeWatchState(ctx context.Context, state gmailWatchState) error {
@@ -361,6 +499,9 @@ func writeWatchState(ctx context.Context, state gmailWatchState) error {
 		if state.Hook.Token != "" {
 			u.Out().Printf("hook_token\t%s", state.Hook.Token)
 		}
+		if state.Hook.Secret != "" {
+			u.Out().Printf("hook_secret\t%s", state.Hook.Secret)
+		}
 	}
 	if state.LastDeliveryStatus != "" {
 		u.Out().Printf("last_delivery_status\t%s", state.LastDeliveryStatus)
@@ -420,6 +561,9 @@ func hookFromFlags(url, token string, includeBody bool, maxBytes int, maxBytesCh
 		}
 		return nil, errNoHookConfigured
 	}
+	if _, err := parseGmailHookSink(url); err != nil {
+		return nil, usage(err.Error())
+	}
 	if maxBytes <= 0 {
 		if includeBody {
 			maxBytes = defaultHookMaxBytes
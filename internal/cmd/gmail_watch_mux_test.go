@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+func TestGmailWatchMux_RoutesByEmailAddress(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var (
+		mu       sync.Mutex
+		services []string
+		hooks    = map[string][]gmailHookPayload{}
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(r.URL.Path, "/users/me/history"):
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"historyId": "200",
+				"history":   []map[string]any{{"messagesAdded": []map[string]any{{"message": map[string]any{"id": "m1"}}}}},
+			})
+		case strings.Contains(r.URL.Path, "/users/me/messages/m1"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "m1", "threadId": "t1"})
+		case strings.HasPrefix(r.URL.Path, "/hook/"):
+			var p gmailHookPayload
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			mu.Lock()
+			hooks[r.URL.Path] = append(hooks[r.URL.Path], p)
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer api.Close()
+
+	gsvc, err := gmail.NewService(context.Background(), option.WithoutAuthentication(), option.WithHTTPClient(api.Client()), option.WithEndpoint(api.URL+"/"))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+
+	var servers []*gmailWatchServer
+	for _, account := range []string{"a@b.com", "C@d.com"} {
+		store, storeErr := newGmailWatchStore(account)
+		if storeErr != nil {
+			t.Fatalf("store: %v", storeErr)
+		}
+		if updateErr := store.Update(func(s *gmailWatchState) error {
+			s.Account = account
+			s.HistoryID = "100"
+			return nil
+		}); updateErr != nil {
+			t.Fatalf("seed: %v", updateErr)
+		}
+		servers = append(servers, &gmailWatchServer{
+			cfg: gmailWatchServeConfig{
+				Account:      account,
+				Path:         "/gmail-pubsub",
+				SharedToken:  "tok",
+				HookURL:      api.URL + "/hook/" + account,
+				MaxBodyBytes: 10,
+				HistoryMax:   100,
+				ResyncMax:    10,
+			},
+			store: store,
+			newService: func(_ context.Context, account string) (*gmail.Service, error) {
+				services = append(services, account)
+				return gsvc, nil
+			},
+			hookClient: api.Client(),
+			logf:       func(string, ...any) {},
+			warnf:      func(string, ...any) {},
+		})
+	}
+	mux := newGmailWatchMux(servers)
+
+	push := func(email string) int {
+		env := pubsubPushEnvelope{}
+		env.Message.Data = base64.StdEncoding.EncodeToString([]byte(`{"emailAddress":"` + email + `","historyId":"200"}`))
+		body, _ := json.Marshal(env)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/gmail-pubsub?token=tok", bytes.NewReader(body)))
+		return rr.Code
+	}
+
+	if code := push("c@d.com"); code != http.StatusOK {
+		t.Fatalf("status: %d", code)
+	}
+	if code := push("nobody@example.com"); code != http.StatusAccepted {
+		t.Fatalf("unknown account status: %d", code)
+	}
+	if got := hooks["/hook/C@d.com"]; len(got) != 1 || got[0].Account != "C@d.com" || len(hooks) != 1 {
+		t.Fatalf("unexpected hooks: %v", hooks)
+	}
+	if strings.Join(services, ",") != "C@d.com" {
+		t.Fatalf("unexpected service accounts: %v", services)
+	}
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/gmail-pubsub/stats?token=tok", nil))
+	var stats struct {
+		Accounts map[string]gmailWatchStats `json:"accounts"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("stats: %v (%q)", err, rr.Body.String())
+	}
+	if c := stats.Accounts["C@d.com"]; c.Pushes != 1 || c.Messages != 1 || c.HookOK != 1 || stats.Accounts["a@b.com"].Pushes != 0 {
+		t.Fatalf("unexpected stats: %+v", stats.Accounts)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/gmail-pubsub/stats", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected stats to require the token, got %d", rr.Code)
+	}
+}
+
+func TestGmailWatchServeCmd_Accounts(t *testing.T) {
+	origListen := listenAndServe
+	t.Cleanup(func() { listenAndServe = origListen })
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	for _, account := range []string{"a@b.com", "c@d.com"} {
+		store, err := newGmailWatchStore(account)
+		if err != nil {
+			t.Fatalf("store: %v", err)
+		}
+		if err := store.Update(func(s *gmailWatchState) error {
+			s.Account = account
+			s.Hook = &gmailWatchHook{URL: "http://example.com/" + account}
+			return nil
+		}); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	var got http.Handler
+	listenAndServe = func(srv *http.Server) error {
+		got = srv.Handler
+		return nil
+	}
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	if err := runKong(t, &GmailWatchServeCmd{}, []string{"--accounts", "a@b.com,c@d.com"}, ui.WithUI(context.Background(), u), &RootFlags{}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	mux, ok := got.(*gmailWatchMux)
+	if !ok || len(mux.servers) != 2 || mux.servers["c@d.com"].cfg.HookURL != "http://example.com/c@d.com" {
+		t.Fatalf("unexpected handler: %#v", got)
+	}
+
+	if err := runKong(t, &GmailWatchServeCmd{}, []string{"--accounts", "a@b.com,missing@d.com"}, ui.WithUI(context.Background(), u), &RootFlags{}); err == nil || !strings.Contains(err.Error(), "missing@d.com") {
+		t.Fatalf("expected error for an account without watch state, got %v", err)
+	}
+}
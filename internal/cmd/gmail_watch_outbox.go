@@ -0,0 +1,456 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steipete/gogcli/internal/config"
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+const (
+	gmailWatchOutboxPending = "pending"
+	gmailWatchOutboxDead    = "dead"
+
+	defaultOutboxMaxAttempts = 8
+	gmailWatchOutboxMinDelay = 30 * time.Second
+	gmailWatchOutboxMaxDelay = time.Hour
+	// gmailWatchOutboxLease is how long a retry owns the entries it is
+	// delivering; a retry that dies mid-way releases them when it expires.
+	gmailWatchOutboxLease = 10 * time.Minute
+)
+
+// gmailWatchOutboxMu serializes outbox updates within the process (push
+// handlers and the retry loop run concurrently); the outbox lock file does
+// the same across processes (`watch serve` next to `watch outbox retry`).
+var gmailWatchOutboxMu sync.Mutex
+
+// gmailWatchOutboxEntry is a hook payload whose delivery failed. Pending
+// entries are retried with exponential backoff by `watch serve`; after the
+// max attempts they are dead-lettered and only `watch outbox retry` sends
+// them again.
+type gmailWatchOutboxEntry struct {
+	ID            string           `json:"id"`
+	HookURL       string           `json:"hookUrl"`
+	Payload       gmailHookPayload `json:"payload"`
+	Status        string           `json:"status"`
+	Attempts      int              `json:"attempts"`
+	LastError     string           `json:"lastError,omitempty"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	LastAttemptAt time.Time        `json:"lastAttemptAt"`
+	NextAttemptAt *time.Time       `json:"nextAttemptAt,omitempty"`
+	ClaimID       string           `json:"claimId,omitempty"`
+	ClaimedUntil  *time.Time       `json:"claimedUntil,omitempty"`
+}
+
+type gmailWatchOutboxFile struct {
+	Entries []gmailWatchOutboxEntry `json:"entries"`
+}
+
+func gmailWatchOutboxPath(account string) (string, error) {
+	dir, err := config.GmailWatchDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "outbox", sanitizeAccountForPath(account)+".json"), nil
+}
+
+func loadGmailWatchOutbox(account string) (*gmailWatchOutboxFile, error) {
+	path, err := gmailWatchOutboxPath(account)
+	if err != nil {
+		return nil, err
+	}
+	box := &gmailWatchOutboxFile{}
+	data, err := os.ReadFile(path) //nolint:gosec // path is derived from the config dir
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return box, nil
+		}
+		return nil, fmt.Errorf("read watch outbox: %w", err)
+	}
+	if err := json.Unmarshal(data, box); err != nil {
+		return nil, fmt.Errorf("decode watch outbox: %w", err)
+	}
+	return box, nil
+}
+
+// updateGmailWatchOutbox re-reads the outbox, applies fn and writes it back
+// while holding the outbox lock, keeping entries added by other processes.
+func updateGmailWatchOutbox(account string, fn func(box *gmailWatchOutboxFile) error) error {
+	gmailWatchOutboxMu.Lock()
+	defer gmailWatchOutboxMu.Unlock()
+
+	path, err := gmailWatchOutboxPath(account)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("ensure gmail watch outbox dir: %w", err)
+	}
+	unlock, err := lockStateFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	box, err := loadGmailWatchOutbox(account)
+	if err != nil {
+		return err
+	}
+	if err := fn(box); err != nil {
+		return err
+	}
+	payload, err := json.MarshalIndent(box, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(payload, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// gmailWatchOutboxBackoff returns the delay before the next attempt:
+// 30s, 1m, 2m, … capped at 1h.
+func gmailWatchOutboxBackoff(attempts int) time.Duration {
+	delay := gmailWatchOutboxMinDelay
+	for i := 1; i < attempts && delay < gmailWatchOutboxMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, gmailWatchOutboxMaxDelay)
+}
+
+func (e *gmailWatchOutboxEntry) recordFailure(err error, now time.Time, maxAttempts int) {
+	e.Attempts++
+	e.LastError = err.Error()
+	e.LastAttemptAt = now
+	if e.Attempts >= maxAttempts {
+		e.Status = gmailWatchOutboxDead
+		e.NextAttemptAt = nil
+		return
+	}
+	e.Status = gmailWatchOutboxPending
+	next := now.Add(gmailWatchOutboxBackoff(e.Attempts))
+	e.NextAttemptAt = &next
+}
+
+// claimed reports whether another retry is delivering the entry right now.
+func (e gmailWatchOutboxEntry) claimed(now time.Time) bool {
+	return e.ClaimID != "" && e.ClaimedUntil != nil && e.ClaimedUntil.After(now)
+}
+
+func (e gmailWatchOutboxEntry) due(now time.Time) bool {
+	return e.Status == gmailWatchOutboxPending && (e.NextAttemptAt == nil || !e.NextAttemptAt.After(now))
+}
+
+func newGmailWatchOutboxID(now time.Time) string {
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return fmt.Sprintf("%d-%s", now.UnixMilli(), hex.EncodeToString(b[:]))
+}
+
+// enqueueGmailWatchOutbox stores a payload whose first delivery failed.
+func enqueueGmailWatchOutbox(account, hookURL string, payload gmailHookPayload, deliveryErr error, maxAttempts int) (gmailWatchOutboxEntry, error) {
+	now := time.Now()
+	entry := gmailWatchOutboxEntry{
+		ID:        newGmailWatchOutboxID(now),
+		HookURL:   hookURL,
+		Payload:   payload,
+		CreatedAt: now,
+	}
+	entry.recordFailure(deliveryErr, now, maxAttempts)
+	err := updateGmailWatchOutbox(account, func(box *gmailWatchOutboxFile) error {
+		box.Entries = append(box.Entries, entry)
+		return nil
+	})
+	return entry, err
+}
+
+type gmailWatchOutboxResult struct {
+	Delivered []string `json:"delivered"`
+	Failed    []string `json:"failed"`
+	Dead      []string `json:"dead"`
+}
+
+// retryGmailWatchOutbox delivers the entries selected by pick. The entries
+// are leased under the lock first, so a concurrent retry in another process
+// skips them; deliveries happen outside the lock so pushes can keep
+// enqueueing. Delivered entries are removed; failures are rescheduled or
+// dead-lettered.
+func retryGmailWatchOutbox(ctx context.Context, account string, maxAttempts int, pick func(gmailWatchOutboxEntry) bool, deliver func(context.Context, gmailWatchOutboxEntry) error) (gmailWatchOutboxResult, error) {
+	res := gmailWatchOutboxResult{Delivered: []string{}, Failed: []string{}, Dead: []string{}}
+	box, err := loadGmailWatchOutbox(account)
+	if err != nil {
+		return res, err
+	}
+	now := time.Now()
+	if !slices.ContainsFunc(box.Entries, func(e gmailWatchOutboxEntry) bool { return pick(e) && !e.claimed(now) }) {
+		// Nothing to do; don't touch the file.
+		return res, nil
+	}
+
+	claimID := newGmailWatchOutboxID(now)
+	leaseUntil := now.Add(gmailWatchOutboxLease)
+	var claimed []gmailWatchOutboxEntry
+	if err := updateGmailWatchOutbox(account, func(box *gmailWatchOutboxFile) error {
+		for i := range box.Entries {
+			e := &box.Entries[i]
+			if !pick(*e) || e.claimed(now) {
+				continue
+			}
+			e.ClaimID = claimID
+			e.ClaimedUntil = &leaseUntil
+			claimed = append(claimed, *e)
+		}
+		return nil
+	}); err != nil {
+		return res, err
+	}
+
+	outcomes := map[string]error{}
+	for _, e := range claimed {
+		if ctx.Err() != nil {
+			break
+		}
+		outcomes[e.ID] = deliver(ctx, e)
+	}
+
+	now = time.Now()
+	err = updateGmailWatchOutbox(account, func(box *gmailWatchOutboxFile) error {
+		kept := box.Entries[:0]
+		for _, e := range box.Entries {
+			if e.ClaimID != claimID {
+				// Not ours, or the lease expired and another retry took over.
+				kept = append(kept, e)
+				continue
+			}
+			e.ClaimID, e.ClaimedUntil = "", nil
+			deliveryErr, tried := outcomes[e.ID]
+			switch {
+			case !tried:
+				kept = append(kept, e)
+			case deliveryErr == nil:
+				res.Delivered = append(res.Delivered, e.ID)
+			default:
+				e.recordFailure(deliveryErr, now, maxAttempts)
+				if e.Status == gmailWatchOutboxDead {
+					res.Dead = append(res.Dead, e.ID)
+				} else {
+					res.Failed = append(res.Failed, e.ID)
+				}
+				kept = append(kept, e)
+			}
+		}
+		box.Entries = kept
+		return nil
+	})
+	return res, err
+}
+
+// runOutbox retries this account's due outbox entries every
+// OutboxInterval until ctx is done.
+func (s *gmailWatchServer) runOutbox(ctx context.Context) {
+	for ctx.Err() == nil {
+		res, err := retryGmailWatchOutbox(ctx, s.cfg.Account, s.cfg.OutboxMaxAttempts,
+			func(e gmailWatchOutboxEntry) bool { return e.due(time.Now()) },
+			func(ctx context.Context, e gmailWatchOutboxEntry) error {
+				return deliverGmailHook(ctx, s.hookClient, s.targetFor(e.HookURL), &e.Payload)
+			})
+		switch {
+		case err != nil:
+			s.warnf("watch: outbox retry failed: %v", err)
+		case len(res.Delivered)+len(res.Failed)+len(res.Dead) > 0:
+			s.recordStats(func(st *gmailWatchStats) { st.Redelivered += int64(len(res.Delivered)) })
+			s.logf("watch: outbox delivered=%d failed=%d dead=%d", len(res.Delivered), len(res.Failed), len(res.Dead))
+		}
+
+		timer := time.NewTimer(s.cfg.OutboxInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+type GmailWatchOutboxCmd struct {
+	List  GmailWatchOutboxListCmd  `cmd:"" name:"list" default:"withargs" help:"List failed hook deliveries"`
+	Retry GmailWatchOutboxRetryCmd `cmd:"" name:"retry" help:"Deliver outbox entries now"`
+	Purge GmailWatchOutboxPurgeCmd `cmd:"" name:"purge" help:"Delete outbox entries"`
+}
+
+type GmailWatchOutboxListCmd struct {
+	Dead bool `name:"dead" help:"Only show dead-lettered entries"`
+}
+
+func (c *GmailWatchOutboxListCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	box, err := loadGmailWatchOutbox(account)
+	if err != nil {
+		return err
+	}
+	entries := make([]gmailWatchOutboxEntry, 0, len(box.Entries))
+	for _, e := range box.Entries {
+		if !c.Dead || e.Status == gmailWatchOutboxDead {
+			entries = append(entries, e)
+		}
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"entries": entries})
+	}
+	if len(entries) == 0 {
+		u.Err().Println("Outbox is empty")
+		return nil
+	}
+
+	w, flush := tableWriter(ctx)
+	defer flush()
+	fmt.Fprintln(w, "ID\tSTATUS\tATTEMPTS\tNEXT ATTEMPT\tMESSAGES\tHISTORY ID\tLAST ERROR")
+	for _, e := range entries {
+		next := "-"
+		if e.NextAttemptAt != nil {
+			next = e.NextAttemptAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%d\t%s\t%s\n", e.ID, e.Status, e.Attempts, next, len(e.Payload.Messages), e.Payload.HistoryID, sanitizeTab(e.LastError))
+	}
+	return nil
+}
+
+type GmailWatchOutboxRetryCmd struct {
+	IDs         []string `arg:"" optional:"" name:"id" help:"Entry IDs (default: all pending entries)"`
+	Dead        bool     `name:"dead" help:"Also retry dead-lettered entries"`
+	HookURL     string   `name:"hook-url" help:"Deliver to this URL instead of the one stored with each entry"`
+	HookToken   string   `name:"hook-token" help:"Webhook bearer token (default: the stored hook's token)"`
+	HookSecret  string   `name:"hook-secret" help:"HMAC signing secret (default: the stored hook's secret)"`
+	MaxAttempts int      `name:"max-attempts" help:"Dead-letter entries after this many failed attempts" default:"8"`
+}
+
+func (c *GmailWatchOutboxRetryCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	if c.MaxAttempts <= 0 {
+		return usage("--max-attempts must be > 0")
+	}
+
+	var stored *gmailWatchHook
+	if store, loadErr := loadGmailWatchStore(account); loadErr == nil {
+		stored = store.Get().Hook
+	}
+
+	client := &http.Client{Timeout: defaultHookRequestTimeoutSec * time.Second}
+	res, err := retryGmailWatchOutbox(ctx, account, c.MaxAttempts,
+		func(e gmailWatchOutboxEntry) bool {
+			if len(c.IDs) > 0 {
+				return slices.Contains(c.IDs, e.ID)
+			}
+			return e.Status == gmailWatchOutboxPending || c.Dead
+		},
+		func(ctx context.Context, e gmailWatchOutboxEntry) error {
+			target := gmailHookTarget{URL: e.HookURL, Token: c.HookToken, Secret: c.HookSecret}
+			if c.HookURL != "" {
+				target.URL = c.HookURL
+			}
+			if stored != nil && stored.URL == target.URL {
+				if target.Token == "" {
+					target.Token = stored.Token
+				}
+				if target.Secret == "" {
+					target.Secret = stored.Secret
+				}
+			}
+			return deliverGmailHook(ctx, client, target, &e.Payload)
+		})
+	if err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, res)
+	}
+	u.Out().Printf("delivered\t%d", len(res.Delivered))
+	u.Out().Printf("failed\t%d", len(res.Failed))
+	u.Out().Printf("dead\t%d", len(res.Dead))
+	if len(res.Failed)+len(res.Dead) > 0 {
+		return fmt.Errorf("%d deliveries failed", len(res.Failed)+len(res.Dead))
+	}
+	return nil
+}
+
+type GmailWatchOutboxPurgeCmd struct {
+	IDs  []string `arg:"" optional:"" name:"id" help:"Entry IDs (default: all entries)"`
+	Dead bool     `name:"dead" help:"Only purge dead-lettered entries"`
+}
+
+func (c *GmailWatchOutboxPurgeCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+
+	match := func(e gmailWatchOutboxEntry) bool {
+		if len(c.IDs) > 0 && !slices.Contains(c.IDs, e.ID) {
+			return false
+		}
+		return !c.Dead || e.Status == gmailWatchOutboxDead
+	}
+	box, err := loadGmailWatchOutbox(account)
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, e := range box.Entries {
+		if match(e) {
+			count++
+		}
+	}
+	if count == 0 {
+		u.Err().Println("Nothing to purge")
+		return nil
+	}
+	if err := confirmDestructive(ctx, flags, fmt.Sprintf("purge %d outbox entries", count)); err != nil {
+		return err
+	}
+
+	purged := []string{}
+	if err := updateGmailWatchOutbox(account, func(box *gmailWatchOutboxFile) error {
+		box.Entries = slices.DeleteFunc(box.Entries, func(e gmailWatchOutboxEntry) bool {
+			if match(e) {
+				purged = append(purged, e.ID)
+				return true
+			}
+			return false
+		})
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"purged": purged})
+	}
+	u.Out().Printf("purged\t%s", strings.Join(purged, ","))
+	return nil
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+func TestGmailWatchOutboxBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		4:  4 * time.Minute,
+		8:  time.Hour,
+		20: time.Hour,
+	}
+	for attempts, want := range cases {
+		if got := gmailWatchOutboxBackoff(attempts); got != want {
+			t.Fatalf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+
+	now := time.Now()
+	e := gmailWatchOutboxEntry{}
+	e.recordFailure(errors.New("boom"), now, 2)
+	if e.Status != gmailWatchOutboxPending || e.Attempts != 1 || e.NextAttemptAt == nil || !e.NextAttemptAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("unexpected entry after first failure: %+v", e)
+	}
+	if e.due(now) || !e.due(now.Add(time.Minute)) {
+		t.Fatalf("unexpected due: %+v", e)
+	}
+	e.recordFailure(errors.New("boom"), now, 2)
+	if e.Status != gmailWatchOutboxDead || e.NextAttemptAt != nil || e.due(now.Add(time.Hour)) {
+		t.Fatalf("expected dead entry: %+v", e)
+	}
+}
+
+func TestGmailWatchServer_QueuesAndRedeliversFailedHooks(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var (
+		hookDown  atomic.Bool
+		delivered atomic.Int32
+	)
+	hookDown.Store(true)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(r.URL.Path, "/users/me/history"):
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"historyId": "200",
+				"history":   []map[string]any{{"messagesAdded": []map[string]any{{"message": map[string]any{"id": "m1"}}}}},
+			})
+		case strings.Contains(r.URL.Path, "/users/me/messages/m1"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "m1", "threadId": "t1"})
+		case r.URL.Path == "/hook":
+			if hookDown.Load() {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			delivered.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer api.Close()
+
+	gsvc, err := gmail.NewService(context.Background(), option.WithoutAuthentication(), option.WithHTTPClient(api.Client()), option.WithEndpoint(api.URL+"/"))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	store, err := newGmailWatchStore("a@b.com")
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if err := store.Update(func(s *gmailWatchState) error {
+		s.Account = "a@b.com"
+		s.HistoryID = "100"
+		return nil
+	}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	server := &gmailWatchServer{
+		cfg: gmailWatchServeConfig{
+			Account:           "a@b.com",
+			Path:              "/gmail-pubsub",
+			HookURL:           api.URL + "/hook",
+			HookToken:         "secret",
+			MaxBodyBytes:      10,
+			HistoryMax:        100,
+			ResyncMax:         10,
+			OutboxMaxAttempts: 3,
+			OutboxInterval:    time.Hour,
+		},
+		store:      store,
+		newService: func(context.Context, string) (*gmail.Service, error) { return gsvc, nil },
+		hookClient: api.Client(),
+		logf:       func(string, ...any) {},
+		warnf:      func(string, ...any) {},
+	}
+
+	env := pubsubPushEnvelope{}
+	env.Message.Data = base64.StdEncoding.EncodeToString([]byte(`{"emailAddress":"a@b.com","historyId":"200"}`))
+	body, _ := json.Marshal(env)
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/gmail-pubsub", bytes.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d", rr.Code)
+	}
+
+	box, err := loadGmailWatchOutbox("a@b.com")
+	if err != nil {
+		t.Fatalf("load outbox: %v", err)
+	}
+	if len(box.Entries) != 1 {
+		t.Fatalf("expected one queued entry, got %+v", box.Entries)
+	}
+	queued := box.Entries[0]
+	if queued.HookURL != api.URL+"/hook" || queued.Attempts != 1 || queued.LastError != "hook status 502" || len(queued.Payload.Messages) != 1 {
+		t.Fatalf("unexpected entry: %+v", queued)
+	}
+	if st := server.Stats(); st.HookFailed != 1 || st.Queued != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+
+	// Make the entry due and bring the hook back; one loop iteration should
+	// deliver it with the configured token and empty the outbox.
+	if err := updateGmailWatchOutbox("a@b.com", func(box *gmailWatchOutboxFile) error {
+		box.Entries[0].NextAttemptAt = nil
+		return nil
+	}); err != nil {
+		t.Fatalf("update outbox: %v", err)
+	}
+	hookDown.Store(false)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		server.runOutbox(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for server.Stats().Redelivered == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if delivered.Load() != 1 || server.Stats().Redelivered != 1 {
+		t.Fatalf("expected one redelivery, got %d (%+v)", delivered.Load(), server.Stats())
+	}
+	box, err = loadGmailWatchOutbox("a@b.com")
+	if err != nil || len(box.Entries) != 0 {
+		t.Fatalf("expected empty outbox, got %+v (%v)", box, err)
+	}
+}
+
+func TestGmailWatchOutboxCmds(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var hits atomic.Int32
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer stored" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer hook.Close()
+
+	store, err := newGmailWatchStore("a@b.com")
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if err := store.Update(func(s *gmailWatchState) error {
+		s.Account = "a@b.com"
+		s.Hook = &gmailWatchHook{URL: hook.URL, Token: "stored"}
+		return nil
+	}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	payload := gmailHookPayload{Source: "gmail", Account: "a@b.com", HistoryID: "1"}
+	pending, err := enqueueGmailWatchOutbox("a@b.com", hook.URL, payload, errors.New("down"), 3)
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	dead, err := enqueueGmailWatchOutbox("a@b.com", hook.URL, payload, errors.New("down"), 1)
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if dead.Status != gmailWatchOutboxDead {
+		t.Fatalf("expected dead entry: %+v", dead)
+	}
+
+	out := runDriveCmdJSON(t, &GmailWatchOutboxCmd{}, []string{"list", "--dead"})
+	if entries, _ := out["entries"].([]any); len(entries) != 1 || entries[0].(map[string]any)["id"] != dead.ID {
+		t.Fatalf("unexpected dead list: %v", out)
+	}
+
+	// Pending entries are retried even before their next attempt is due;
+	// dead ones are left alone without --dead.
+	out = runDriveCmdJSON(t, &GmailWatchOutboxCmd{}, []string{"retry"})
+	if got, _ := out["delivered"].([]any); len(got) != 1 || got[0] != pending.ID || hits.Load() != 1 {
+		t.Fatalf("unexpected retry result: %v (hits %d)", out, hits.Load())
+	}
+
+	out = runDriveCmdJSON(t, &GmailWatchOutboxCmd{}, []string{"purge", "--dead"})
+	if got, _ := out["purged"].([]any); len(got) != 1 || got[0] != dead.ID {
+		t.Fatalf("unexpected purge result: %v", out)
+	}
+	out = runDriveCmdJSON(t, &GmailWatchOutboxCmd{}, []string{})
+	if entries, _ := out["entries"].([]any); len(entries) != 0 {
+		t.Fatalf("expected empty outbox: %v", out)
+	}
+}
+
+func TestRetryGmailWatchOutbox_LeasesEntries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	payload := gmailHookPayload{Source: "gmail", Account: "a@b.com", HistoryID: "1"}
+	var ids []string
+	for range 3 {
+		e, err := enqueueGmailWatchOutbox("a@b.com", "https://example.com/hook", payload, errors.New("down"), 5)
+		if err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+		ids = append(ids, e.ID)
+	}
+	// Entry 0 is leased by a retry in another process; entry 1's lease ran out.
+	live := time.Now().Add(time.Minute)
+	expired := time.Now().Add(-time.Minute)
+	if err := updateGmailWatchOutbox("a@b.com", func(box *gmailWatchOutboxFile) error {
+		box.Entries[0].ClaimID, box.Entries[0].ClaimedUntil = "other", &live
+		box.Entries[1].ClaimID, box.Entries[1].ClaimedUntil = "crashed", &expired
+		return nil
+	}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	all := func(gmailWatchOutboxEntry) bool { return true }
+	var delivered, nested []string
+	res, err := retryGmailWatchOutbox(context.Background(), "a@b.com", 5, all, func(ctx context.Context, e gmailWatchOutboxEntry) error {
+		delivered = append(delivered, e.ID)
+		// An overlapping retry must not get the entries leased to this one.
+		if _, nestedErr := retryGmailWatchOutbox(ctx, "a@b.com", 5, all, func(_ context.Context, e gmailWatchOutboxEntry) error {
+			nested = append(nested, e.ID)
+			return nil
+		}); nestedErr != nil {
+			t.Errorf("nested retry: %v", nestedErr)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if strings.Join(delivered, ",") != ids[1]+","+ids[2] || len(nested) != 0 || len(res.Delivered) != 2 {
+		t.Fatalf("unexpected deliveries: %v nested=%v res=%+v", delivered, nested, res)
+	}
+	box, err := loadGmailWatchOutbox("a@b.com")
+	if err != nil || len(box.Entries) != 1 || box.Entries[0].ID != ids[0] || box.Entries[0].ClaimID != "other" {
+		t.Fatalf("expected only the foreign lease to remain, got %+v (%v)", box, err)
+	}
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"google.golang.org/api/pubsub/v1"
+
+	"github.com/steipete/gogcli/internal/googleapi"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+const (
+	gmailWatchPullMaxMessages = 10
+	gmailWatchPullIdleDelay   = time.Second
+)
+
+var newPubSubService = googleapi.NewPubSub
+
+// GmailWatchPollCmd delivers the same hook payloads as `watch serve`
+// without a public endpoint: it polls history.list, or pulls the watch's
+// notifications from a Pub/Sub subscription.
+type GmailWatchPollCmd struct {
+	Interval     time.Duration `name:"interval" help:"History poll interval (retry delay after errors with --subscription)" default:"30s"`
+	Once         bool          `name:"once" help:"Poll once and exit"`
+	Subscription string        `name:"subscription" help:"Pull notifications from this Pub/Sub subscription (projects/<p>/subscriptions/<s>) instead of polling history"`
+	Credentials  string        `name:"pubsub-credentials" help:"Service account JSON for --subscription (default: application default credentials)"`
+
+	Hook GmailWatchHookFlags `embed:""`
+}
+
+func (c *GmailWatchPollCmd) Run(ctx context.Context, kctx *kong.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	if c.Interval <= 0 {
+		return usage("--interval must be > 0")
+	}
+	if c.Credentials != "" && c.Subscription == "" {
+		return usage("--pubsub-credentials requires --subscription")
+	}
+	if err := c.Hook.validate(); err != nil {
+		return err
+	}
+
+	store, err := loadGmailWatchStore(account)
+	if errors.Is(err, errGmailWatchStateNotFound) && c.Subscription == "" {
+		// History polling works without `watch start`.
+		store, err = newGmailWatchStore(account)
+	}
+	if err != nil {
+		return err
+	}
+	server, err := c.Hook.newWatchServer(kctx, u, store, gmailWatchServeConfig{Account: account}, false)
+	if err != nil {
+		return err
+	}
+	if err := server.ensureHistoryID(ctx); err != nil {
+		return err
+	}
+
+	if !c.Once && server.cfg.OutboxMaxAttempts > 0 {
+		outboxCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go server.runOutbox(outboxCtx)
+	}
+
+	if c.Subscription != "" {
+		return c.pull(ctx, server)
+	}
+	return c.poll(ctx, server)
+}
+
+func (c *GmailWatchPollCmd) poll(ctx context.Context, s *gmailWatchServer) error {
+	s.logf("watch: polling history every %s", c.Interval)
+	for {
+		result, err := s.handlePush(ctx, gmailPushPayload{})
+		switch {
+		case err == nil:
+			s.emit(ctx, result)
+		case errors.Is(err, errNoNewMessages):
+		case ctx.Err() != nil:
+			return nil
+		case c.Once:
+			return err
+		default:
+			s.warnf("watch: poll failed: %v", err)
+		}
+		if c.Once {
+			return nil
+		}
+
+		if !sleepContext(ctx, c.Interval) {
+			return nil
+		}
+	}
+}
+
+// pull long-polls a Pub/Sub subscription. Notifications are acknowledged
+// once handled; failures are left for Pub/Sub to redeliver.
+func (c *GmailWatchPollCmd) pull(ctx context.Context, s *gmailWatchServer) error {
+	svc, err := newPubSubService(ctx, c.Credentials)
+	if err != nil {
+		return err
+	}
+	s.logf("watch: pulling %s", c.Subscription)
+	for {
+		resp, err := svc.Projects.Subscriptions.Pull(c.Subscription, &pubsub.PullRequest{MaxMessages: gmailWatchPullMaxMessages}).Context(ctx).Do()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if c.Once {
+				return err
+			}
+			s.warnf("watch: pull failed: %v", err)
+			if !sleepContext(ctx, c.Interval) {
+				return nil
+			}
+			continue
+		}
+
+		ackIDs := make([]string, 0, len(resp.ReceivedMessages))
+		for _, received := range resp.ReceivedMessages {
+			if received == nil || received.Message == nil {
+				continue
+			}
+			if s.handlePulled(ctx, received.Message) {
+				ackIDs = append(ackIDs, received.AckId)
+			}
+		}
+		if len(ackIDs) > 0 {
+			if _, ackErr := svc.Projects.Subscriptions.Acknowledge(c.Subscription, &pubsub.AcknowledgeRequest{AckIds: ackIDs}).Context(ctx).Do(); ackErr != nil {
+				s.warnf("watch: acknowledge failed: %v", ackErr)
+			}
+		}
+		if c.Once {
+			return nil
+		}
+		if len(resp.ReceivedMessages) == 0 && !sleepContext(ctx, gmailWatchPullIdleDelay) {
+			return nil
+		}
+	}
+}
+
+// handlePulled processes one pulled notification like a push and reports
+// whether it can be acknowledged.
+func (s *gmailWatchServer) handlePulled(ctx context.Context, msg *pubsub.PubsubMessage) bool {
+	envelope := &pubsubPushEnvelope{}
+	envelope.Message.Data = msg.Data
+	envelope.Message.MessageID = msg.MessageId
+	payload, err := decodeGmailPushPayload(envelope)
+	if err != nil {
+		s.warnf("watch: invalid pulled message %s: %v", msg.MessageId, err)
+		return true
+	}
+	if payload.EmailAddress != "" && !strings.EqualFold(payload.EmailAddress, s.cfg.Account) {
+		s.warnf("watch: ignoring notification for %s", payload.EmailAddress)
+		return true
+	}
+	result, err := s.handlePush(ctx, payload)
+	switch {
+	case errors.Is(err, errNoNewMessages):
+		return true
+	case err != nil:
+		s.warnf("watch: handle notification failed: %v", err)
+		return false
+	}
+	s.emit(ctx, result)
+	return true
+}
+
+// emit forwards a polled payload to the rule routes and the hook, or prints
+// it as one JSON line when no hook is configured.
+func (s *gmailWatchServer) emit(ctx context.Context, result *gmailHookPayload) {
+	if result == nil || len(result.Messages) == 0 {
+		return
+	}
+	s.recordStats(func(st *gmailWatchStats) { st.Messages += int64(len(result.Messages)) })
+	if result = s.deliverRoutes(ctx, result); result == nil {
+		return
+	}
+	if s.cfg.HookURL == "" {
+		_ = json.NewEncoder(os.Stdout).Encode(result)
+		return
+	}
+	s.forward(ctx, s.hookTarget(), result)
+}
+
+// ensureHistoryID starts an empty watch state at the mailbox's current
+// historyId, so polling only reports mail that arrives from now on.
+func (s *gmailWatchServer) ensureHistoryID(ctx context.Context) error {
+	if s.store.Get().HistoryID != "" {
+		return nil
+	}
+	svc, err := s.newService(ctx, s.cfg.Account)
+	if err != nil {
+		return err
+	}
+	profile, err := svc.Users.GetProfile("me").Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+	historyID := formatHistoryID(profile.HistoryId)
+	s.logf("watch: starting at historyId=%s", historyID)
+	return s.store.Update(func(state *gmailWatchState) error {
+		state.Account = s.cfg.Account
+		state.HistoryID = historyID
+		state.UpdatedAtMs = time.Now().UnixMilli()
+		return nil
+	})
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+	"google.golang.org/api/pubsub/v1"
+
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+func newGmailWatchPollTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/users/me/profile"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"emailAddress": "a@b.com", "historyId": "100"})
+		case strings.Contains(r.URL.Path, "/users/me/history"):
+			if r.URL.Query().Get("startHistoryId") != "100" {
+				t.Errorf("unexpected startHistoryId %q", r.URL.Query().Get("startHistoryId"))
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"historyId": "200",
+				"history":   []map[string]any{{"messagesAdded": []map[string]any{{"message": map[string]any{"id": "m1"}}}}},
+			})
+		case strings.Contains(r.URL.Path, "/users/me/messages/m1"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "m1", "threadId": "t1", "snippet": "hi"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(api.Close)
+
+	gsvc, err := gmail.NewService(context.Background(), option.WithoutAuthentication(), option.WithHTTPClient(api.Client()), option.WithEndpoint(api.URL+"/"))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	origNew := newGmailService
+	t.Cleanup(func() { newGmailService = origNew })
+	newGmailService = func(context.Context, string) (*gmail.Service, error) { return gsvc, nil }
+	return api
+}
+
+func readHookLines(t *testing.T, path string) []gmailHookPayload {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read hook file: %v", err)
+	}
+	var out []gmailHookPayload
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var p gmailHookPayload
+		if err := json.Unmarshal([]byte(line), &p); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func TestGmailWatchPollCmd_History(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	newGmailWatchPollTestServer(t)
+	hookFile := filepath.Join(t.TempDir(), "events.ndjson")
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	// No `watch start`: the first poll starts at the profile's historyId.
+	if err := runKong(t, &GmailWatchPollCmd{}, []string{"--once", "--hook-url", "file://" + hookFile}, ui.WithUI(context.Background(), u), &RootFlags{Account: "a@b.com"}); err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+
+	got := readHookLines(t, hookFile)
+	if len(got) != 1 || got[0].Account != "a@b.com" || got[0].HistoryID != "200" || len(got[0].Messages) != 1 || got[0].Messages[0].ID != "m1" {
+		t.Fatalf("unexpected hook payloads: %+v", got)
+	}
+	store, err := loadGmailWatchStore("a@b.com")
+	if err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if store.Get().HistoryID != "200" {
+		t.Fatalf("expected historyId to advance, got %q", store.Get().HistoryID)
+	}
+}
+
+func TestGmailWatchPollCmd_PubSubPull(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	newGmailWatchPollTestServer(t)
+	hookFile := filepath.Join(t.TempDir(), "events.ndjson")
+
+	var (
+		mu     sync.Mutex
+		acked  []string
+		pulled int
+	)
+	ps := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/projects/p/subscriptions/s:pull":
+			mu.Lock()
+			pulled++
+			mu.Unlock()
+			data := func(email string) string {
+				return base64.StdEncoding.EncodeToString([]byte(`{"emailAddress":"` + email + `","historyId":"200"}`))
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"receivedMessages": []map[string]any{
+				{"ackId": "ack-1", "message": map[string]any{"data": data("a@b.com"), "messageId": "pm1"}},
+				{"ackId": "ack-2", "message": map[string]any{"data": data("other@b.com"), "messageId": "pm2"}},
+			}})
+		case "/v1/projects/p/subscriptions/s:acknowledge":
+			var req pubsub.AcknowledgeRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			mu.Lock()
+			acked = append(acked, req.AckIds...)
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ps.Close()
+
+	origPubSub := newPubSubService
+	t.Cleanup(func() { newPubSubService = origPubSub })
+	newPubSubService = func(ctx context.Context, _ string) (*pubsub.Service, error) {
+		return pubsub.NewService(ctx, option.WithoutAuthentication(), option.WithHTTPClient(ps.Client()), option.WithEndpoint(ps.URL+"/"))
+	}
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := ui.WithUI(context.Background(), u)
+	args := []string{"--once", "--subscription", "projects/p/subscriptions/s", "--hook-url", "file://" + hookFile}
+
+	if err := runKong(t, &GmailWatchPollCmd{}, args, ctx, &RootFlags{Account: "a@b.com"}); err == nil || !strings.Contains(err.Error(), "watch start") {
+		t.Fatalf("expected pull mode to require watch state, got %v", err)
+	}
+
+	store, err := newGmailWatchStore("a@b.com")
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if err := store.Update(func(s *gmailWatchState) error {
+		s.Account = "a@b.com"
+		s.HistoryID = "100"
+		return nil
+	}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if err := runKong(t, &GmailWatchPollCmd{}, args, ctx, &RootFlags{Account: "a@b.com"}); err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+
+	got := readHookLines(t, hookFile)
+	if len(got) != 1 || got[0].HistoryID != "200" || len(got[0].Messages) != 1 {
+		t.Fatalf("unexpected hook payloads: %+v", got)
+	}
+	if pulled != 1 || strings.Join(acked, ",") != "ack-1,ack-2" {
+		t.Fatalf("unexpected pull/ack: pulled=%d acked=%v", pulled, acked)
+	}
+	reloaded, err := loadGmailWatchStore("a@b.com")
+	if err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if st := reloaded.Get(); st.HistoryID != "200" || st.LastPushMessageID != "pm1" {
+		t.Fatalf("unexpected state: %+v", st)
+	}
+}
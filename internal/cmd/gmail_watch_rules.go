@@ -0,0 +1,489 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/gmail/v1"
+	"gopkg.in/yaml.v3"
+
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+// gmailWatchRulesFile is the rules file read by `watch rules set` and
+// `--rules`. Rules are evaluated in order and the first match decides.
+type gmailWatchRulesFile struct {
+	Rules []gmailWatchRule `yaml:"rules" json:"rules"`
+}
+
+// gmailWatchRule selects messages by label, sender, recipient, subject or
+// Gmail query. A matching message is dropped, or delivered to HookURL (the
+// watch's hook when empty). Messages no rule matches are dropped.
+type gmailWatchRule struct {
+	Name          string   `yaml:"name,omitempty" json:"name,omitempty"`
+	Labels        []string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	ExcludeLabels []string `yaml:"excludeLabels,omitempty" json:"excludeLabels,omitempty"`
+	From          string   `yaml:"from,omitempty" json:"from,omitempty"`
+	To            string   `yaml:"to,omitempty" json:"to,omitempty"`
+	Subject       string   `yaml:"subject,omitempty" json:"subject,omitempty"`
+	Query         string   `yaml:"query,omitempty" json:"query,omitempty"`
+	Drop          bool     `yaml:"drop,omitempty" json:"drop,omitempty"`
+	HookURL       string   `yaml:"hookUrl,omitempty" json:"hookUrl,omitempty"`
+	HookToken     string   `yaml:"hookToken,omitempty" json:"hookToken,omitempty"`
+	HookSecret    string   `yaml:"hookSecret,omitempty" json:"hookSecret,omitempty"`
+}
+
+func (r gmailWatchRule) label(i int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+func (r gmailWatchRule) validate() error {
+	if r.Drop && r.HookURL != "" {
+		return fmt.Errorf("drop and hookUrl are mutually exclusive")
+	}
+	if r.HookURL == "" && (r.HookToken != "" || r.HookSecret != "") {
+		return fmt.Errorf("hookToken and hookSecret require hookUrl")
+	}
+	if r.HookURL != "" {
+		if _, err := parseGmailHookSink(r.HookURL); err != nil {
+			return err
+		}
+	}
+	for _, pattern := range []string{r.From, r.To} {
+		if _, err := path.Match(strings.ToLower(pattern), ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	if r.Subject != "" {
+		if _, err := regexp.Compile(r.Subject); err != nil {
+			return fmt.Errorf("invalid subject regex: %w", err)
+		}
+	}
+	return nil
+}
+
+func parseGmailWatchRules(data []byte) ([]gmailWatchRule, error) {
+	var file gmailWatchRulesFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("parse rules: %w", err)
+	}
+	for i, rule := range file.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.label(i), err)
+		}
+	}
+	return file.Rules, nil
+}
+
+func readGmailWatchRulesFile(path string) ([]gmailWatchRule, error) {
+	data, err := readBodyFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rules: %w", err)
+	}
+	return parseGmailWatchRules([]byte(data))
+}
+
+// gmailWatchRules evaluates rules against fetched messages. Label names are
+// resolved to IDs on first use.
+type gmailWatchRules struct {
+	rules   []gmailWatchRule
+	subject []*regexp.Regexp
+
+	mu       sync.Mutex
+	labelIDs map[string]string // lower-case name or ID -> ID
+}
+
+//nolint:nilnil // nil return is intentional: nil means "forward everything"
+func newGmailWatchRules(rules []gmailWatchRule) (*gmailWatchRules, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	r := &gmailWatchRules{rules: rules, subject: make([]*regexp.Regexp, len(rules))}
+	for i, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.label(i), err)
+		}
+		if rule.Subject != "" {
+			r.subject[i] = regexp.MustCompile(rule.Subject)
+		}
+	}
+	return r, nil
+}
+
+func (r *gmailWatchRules) usesLabels() bool {
+	return slices.ContainsFunc(r.rules, func(rule gmailWatchRule) bool {
+		return len(rule.Labels)+len(rule.ExcludeLabels) > 0
+	})
+}
+
+// routes reports whether any rule delivers to its own hook.
+func (r *gmailWatchRules) routes() bool {
+	return r != nil && slices.ContainsFunc(r.rules, func(rule gmailWatchRule) bool { return rule.HookURL != "" })
+}
+
+// target returns the hook settings of the first rule routing to url.
+func (r *gmailWatchRules) target(url string) (gmailHookTarget, bool) {
+	if r == nil || url == "" {
+		return gmailHookTarget{}, false
+	}
+	for _, rule := range r.rules {
+		if rule.HookURL == url {
+			return gmailHookTarget{URL: rule.HookURL, Token: rule.HookToken, Secret: rule.HookSecret}, true
+		}
+	}
+	return gmailHookTarget{}, false
+}
+
+// apply decides what happens to msg: it is dropped (keep false), or kept
+// with the matching rule's name and hook URL (empty for the default hook).
+func (r *gmailWatchRules) apply(ctx context.Context, svc *gmail.Service, msg *gmail.Message) (rule, route string, keep bool, err error) {
+	idx, err := r.match(ctx, svc, msg)
+	if err != nil || idx < 0 || r.rules[idx].Drop {
+		if idx >= 0 {
+			rule = r.rules[idx].label(idx)
+		}
+		return rule, "", false, err
+	}
+	return r.rules[idx].label(idx), r.rules[idx].HookURL, true, nil
+}
+
+func (r *gmailWatchRules) resolveLabels(svc *gmail.Service) (map[string]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.labelIDs != nil || !r.usesLabels() {
+		return r.labelIDs, nil
+	}
+	ids, err := fetchLabelNameToID(svc)
+	if err != nil {
+		return nil, fmt.Errorf("resolve rule labels: %w", err)
+	}
+	r.labelIDs = ids
+	return ids, nil
+}
+
+// unknownLabels lists rule labels that are neither a label name nor an ID
+// in the mailbox.
+func (r *gmailWatchRules) unknownLabels(svc *gmail.Service) ([]string, error) {
+	ids, err := r.resolveLabels(svc)
+	if err != nil {
+		return nil, err
+	}
+	var unknown []string
+	for _, rule := range r.rules {
+		for _, label := range slices.Concat(rule.Labels, rule.ExcludeLabels) {
+			if _, ok := ids[strings.ToLower(strings.TrimSpace(label))]; !ok && !slices.Contains(unknown, label) {
+				unknown = append(unknown, label)
+			}
+		}
+	}
+	return unknown, nil
+}
+
+// match returns the index of the first rule matching msg, or -1.
+func (r *gmailWatchRules) match(ctx context.Context, svc *gmail.Service, msg *gmail.Message) (int, error) {
+	ids, err := r.resolveLabels(svc)
+	if err != nil {
+		return -1, err
+	}
+	hasLabel := func(label string) bool {
+		id := strings.TrimSpace(label)
+		if resolved, ok := ids[strings.ToLower(id)]; ok {
+			id = resolved
+		}
+		return slices.Contains(msg.LabelIds, id)
+	}
+
+	for i, rule := range r.rules {
+		if !slices.ContainsFunc(rule.Labels, func(l string) bool { return !hasLabel(l) }) &&
+			!slices.ContainsFunc(rule.ExcludeLabels, hasLabel) &&
+			matchGmailWatchAddress(rule.From, headerValue(msg.Payload, "From")) &&
+			matchGmailWatchAddress(rule.To, headerValue(msg.Payload, "To"), headerValue(msg.Payload, "Cc")) &&
+			(r.subject[i] == nil || r.subject[i].MatchString(headerValue(msg.Payload, "Subject"))) {
+			if rule.Query == "" {
+				return i, nil
+			}
+			ok, err := matchGmailWatchQuery(ctx, svc, rule.Query, msg)
+			if err != nil {
+				return -1, err
+			}
+			if ok {
+				return i, nil
+			}
+		}
+	}
+	return -1, nil
+}
+
+// matchGmailWatchAddress matches pattern against the headers, ignoring case.
+// Patterns with *, ? or [ are globs against each address (or the whole
+// header); anything else is a substring match.
+func matchGmailWatchAddress(pattern string, headers ...string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" {
+		return true
+	}
+	for _, header := range headers {
+		header = strings.ToLower(header)
+		if header == "" {
+			continue
+		}
+		if !strings.ContainsAny(pattern, "*?[") {
+			if strings.Contains(header, pattern) {
+				return true
+			}
+			continue
+		}
+		candidates := []string{header}
+		if addrs, err := mail.ParseAddressList(header); err == nil {
+			for _, a := range addrs {
+				candidates = append(candidates, a.Address)
+			}
+		}
+		for _, c := range candidates {
+			if ok, _ := path.Match(pattern, c); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchGmailWatchQuery reports whether msg matches a Gmail search query by
+// searching for the query restricted to the message's Message-ID.
+func matchGmailWatchQuery(ctx context.Context, svc *gmail.Service, query string, msg *gmail.Message) (bool, error) {
+	msgID := strings.Trim(headerValue(msg.Payload, "Message-ID"), "<> ")
+	if msgID == "" {
+		return false, nil
+	}
+	resp, err := svc.Users.Messages.List("me").
+		Q(fmt.Sprintf("(%s) rfc822msgid:%s", query, msgID)).
+		IncludeSpamTrash(true).
+		MaxResults(10).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return false, fmt.Errorf("rule query %q: %w", query, err)
+	}
+	return slices.ContainsFunc(resp.Messages, func(m *gmail.Message) bool { return m != nil && m.Id == msg.Id }), nil
+}
+
+type GmailWatchRulesCmd struct {
+	List  GmailWatchRulesListCmd  `cmd:"" name:"list" default:"withargs" help:"Show the stored watch rules"`
+	Set   GmailWatchRulesSetCmd   `cmd:"" name:"set" help:"Replace the watch rules from a YAML/JSON file"`
+	Clear GmailWatchRulesClearCmd `cmd:"" name:"clear" help:"Remove all watch rules (forward every message)"`
+	Test  GmailWatchRulesTestCmd  `cmd:"" name:"test" help:"Show which rule matches existing messages"`
+}
+
+type GmailWatchRulesListCmd struct{}
+
+func (c *GmailWatchRulesListCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	store, err := openGmailWatchStore(account)
+	if err != nil {
+		return err
+	}
+	file := gmailWatchRulesFile{Rules: store.Get().Rules}
+	if file.Rules == nil {
+		file.Rules = []gmailWatchRule{}
+	}
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, file)
+	}
+	if len(file.Rules) == 0 {
+		u.Err().Println("No watch rules (every message is forwarded)")
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(file); err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(buf.Bytes())
+	return err
+}
+
+type GmailWatchRulesSetCmd struct {
+	File string `arg:"" name:"file" help:"Rules file (YAML or JSON; '-' for stdin)"`
+}
+
+func (c *GmailWatchRulesSetCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	rules, err := readGmailWatchRulesFile(c.File)
+	if err != nil {
+		return err
+	}
+	compiled, err := newGmailWatchRules(rules)
+	if err != nil {
+		return err
+	}
+	if compiled != nil && compiled.usesLabels() {
+		svc, svcErr := newGmailService(ctx, account)
+		if svcErr != nil {
+			return svcErr
+		}
+		unknown, labelErr := compiled.unknownLabels(svc)
+		if labelErr != nil {
+			return labelErr
+		}
+		if len(unknown) > 0 {
+			return usagef("unknown labels in rules: %s", strings.Join(unknown, ", "))
+		}
+	}
+
+	store, err := openGmailWatchStore(account)
+	if err != nil {
+		return err
+	}
+	if err := store.Update(func(s *gmailWatchState) error {
+		s.Account = account
+		s.Rules = rules
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"rules": len(rules)})
+	}
+	u.Out().Printf("rules\t%d", len(rules))
+	u.Err().Println("Restart running `watch serve`/`watch poll` processes to apply the rules")
+	return nil
+}
+
+type GmailWatchRulesClearCmd struct{}
+
+func (c *GmailWatchRulesClearCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	store, err := loadGmailWatchStore(account)
+	if err != nil {
+		return err
+	}
+	if err := store.Update(func(s *gmailWatchState) error {
+		s.Rules = nil
+		return nil
+	}); err != nil {
+		return err
+	}
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"rules": 0})
+	}
+	u.Out().Printf("rules\t0")
+	return nil
+}
+
+type GmailWatchRulesTestCmd struct {
+	MessageIDs []string `arg:"" name:"messageId" help:"Message IDs to evaluate"`
+	Rules      string   `name:"rules" help:"Rules file to test instead of the stored rules"`
+}
+
+func (c *GmailWatchRulesTestCmd) Run(ctx context.Context, flags *RootFlags) error {
+	account, err := requireAccount(flags)
+	if err != nil {
+		return err
+	}
+	var rules []gmailWatchRule
+	if c.Rules != "" {
+		rules, err = readGmailWatchRulesFile(c.Rules)
+	} else {
+		var store *gmailWatchStore
+		if store, err = openGmailWatchStore(account); err == nil {
+			rules = store.Get().Rules
+		}
+	}
+	if err != nil {
+		return err
+	}
+	compiled, err := newGmailWatchRules(rules)
+	if err != nil {
+		return err
+	}
+
+	svc, err := newGmailService(ctx, account)
+	if err != nil {
+		return err
+	}
+	type result struct {
+		ID     string `json:"id"`
+		Rule   string `json:"rule,omitempty"`
+		Action string `json:"action"`
+	}
+	results := make([]result, 0, len(c.MessageIDs))
+	for _, id := range c.MessageIDs {
+		msg, getErr := svc.Users.Messages.Get("me", id).
+			Format(gmailWatchFormatMetadata).
+			MetadataHeaders(gmailWatchMetadataHeaders...).
+			Context(ctx).
+			Do()
+		if getErr != nil {
+			return getErr
+		}
+		res := result{ID: id, Action: "forward"}
+		if compiled != nil {
+			rule, route, keep, applyErr := compiled.apply(ctx, svc, msg)
+			if applyErr != nil {
+				return applyErr
+			}
+			res.Rule = rule
+			switch {
+			case !keep:
+				res.Action = "drop"
+			case route != "":
+				res.Action = "route " + route
+			}
+		}
+		results = append(results, res)
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"results": results})
+	}
+	w, flush := tableWriter(ctx)
+	defer flush()
+	fmt.Fprintln(w, "ID\tRULE\tACTION")
+	for _, r := range results {
+		rule := r.Rule
+		if rule == "" {
+			rule = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.ID, sanitizeTab(rule), sanitizeTab(r.Action))
+	}
+	return nil
+}
+
+// openGmailWatchStore loads the account's watch state, or returns an empty
+// store when `watch start` has not run (rules also apply to `watch poll`).
+func openGmailWatchStore(account string) (*gmailWatchStore, error) {
+	store, err := loadGmailWatchStore(account)
+	if errors.Is(err, errGmailWatchStateNotFound) {
+		return newGmailWatchStore(account)
+	}
+	return store, err
+}
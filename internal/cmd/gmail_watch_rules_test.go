@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+func TestParseGmailWatchRules(t *testing.T) {
+	rules, err := parseGmailWatchRules([]byte(`
+rules:
+  - name: promos
+    labels: [CATEGORY_PROMOTIONS]
+    drop: true
+  - from: "*@stripe.com"
+    subject: "(?i)invoice"
+    hookUrl: file:///tmp/billing.ndjson
+  - {}
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(rules) != 3 || !rules[0].Drop || rules[1].HookURL != "file:///tmp/billing.ndjson" || rules[1].label(1) != "#2" {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+	if _, err := parseGmailWatchRules([]byte(`{"rules":[{"name":"json","to":"me@example.com"}]}`)); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+
+	for _, bad := range []string{
+		"rules:\n  - drop: true\n    hookUrl: https://example.com\n",
+		"rules:\n  - hookToken: t\n",
+		"rules:\n  - subject: \"(\"\n",
+		"rules:\n  - from: \"[a\"\n",
+		"rules:\n  - hookUrl: ftp://example.com\n",
+		"rules:\n  - sender: x\n",
+	} {
+		if _, err := parseGmailWatchRules([]byte(bad)); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestMatchGmailWatchAddress(t *testing.T) {
+	cases := []struct {
+		pattern string
+		headers []string
+		want    bool
+	}{
+		{"", nil, true},
+		{"stripe.com", []string{"Stripe <billing@Stripe.com>"}, true},
+		{"*@stripe.com", []string{"Stripe <billing@stripe.com>"}, true},
+		{"*@stripe.com", []string{"a@example.com, b@STRIPE.com"}, true},
+		{"*@stripe.com", []string{"billing@stripe.com.evil.test"}, false},
+		{"team@example.com", []string{"me@example.com", "Team <team@example.com>"}, true},
+		{"team@example.com", []string{"me@example.com", ""}, false},
+	}
+	for _, tc := range cases {
+		if got := matchGmailWatchAddress(tc.pattern, tc.headers...); got != tc.want {
+			t.Fatalf("match(%q, %q) = %v, want %v", tc.pattern, tc.headers, got, tc.want)
+		}
+	}
+}
+
+type gmailWatchRulesTestAPI struct {
+	mu      sync.Mutex
+	full    []string
+	queries []string
+}
+
+func newGmailWatchRulesTestAPI(t *testing.T) (*gmailWatchRulesTestAPI, *gmail.Service) {
+	t.Helper()
+	messages := map[string]map[string]any{
+		"m1": {"from": "Stripe <billing@stripe.com>", "subject": "Your invoice", "labels": []string{"INBOX"}},
+		"m2": {"from": "Shop <deals@shop.test>", "subject": "Sale", "labels": []string{"INBOX", "CATEGORY_PROMOTIONS"}},
+		"m3": {"from": "Alice <alice@example.com>", "subject": "Lunch?", "labels": []string{"INBOX", "Label_1"}},
+	}
+	api := &gmailWatchRulesTestAPI{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/users/me/labels"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"labels": []map[string]any{
+				{"id": "INBOX", "name": "INBOX"},
+				{"id": "CATEGORY_PROMOTIONS", "name": "CATEGORY_PROMOTIONS"},
+				{"id": "Label_1", "name": "Friends"},
+			}})
+		case strings.Contains(r.URL.Path, "/users/me/history"):
+			added := []map[string]any{}
+			for _, id := range []string{"m1", "m2", "m3"} {
+				added = append(added, map[string]any{"message": map[string]any{"id": id}})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"historyId": "200", "history": []map[string]any{{"messagesAdded": added}}})
+		case strings.HasSuffix(r.URL.Path, "/users/me/messages"):
+			q := r.URL.Query().Get("q")
+			api.mu.Lock()
+			api.queries = append(api.queries, q)
+			api.mu.Unlock()
+			var found []map[string]any
+			if strings.Contains(q, "rfc822msgid:m3@example.com") {
+				found = append(found, map[string]any{"id": "m3"})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"messages": found})
+		case strings.Contains(r.URL.Path, "/users/me/messages/"):
+			id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+			m, ok := messages[id]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			payload := map[string]any{"headers": []map[string]any{
+				{"name": "From", "value": m["from"]},
+				{"name": "Subject", "value": m["subject"]},
+				{"name": "Message-ID", "value": "<" + id + "@example.com>"},
+			}}
+			if r.URL.Query().Get("format") == "full" {
+				api.mu.Lock()
+				api.full = append(api.full, id)
+				api.mu.Unlock()
+				payload["mimeType"] = "text/plain"
+				payload["body"] = map[string]any{"data": base64.URLEncoding.EncodeToString([]byte("body of " + id))}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "threadId": "t-" + id, "labelIds": m["labels"], "payload": payload})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	svc, err := gmail.NewService(context.Background(), option.WithoutAuthentication(), option.WithHTTPClient(srv.Client()), option.WithEndpoint(srv.URL+"/"))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	return api, svc
+}
+
+func TestGmailWatchServer_RulesFilterAndRoute(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	api, svc := newGmailWatchRulesTestAPI(t)
+	dir := t.TempDir()
+	billing := filepath.Join(dir, "billing.ndjson")
+	defaultHook := filepath.Join(dir, "default.ndjson")
+
+	rules, err := newGmailWatchRules([]gmailWatchRule{
+		{Name: "promos", Labels: []string{"category_promotions"}, Drop: true},
+		{Name: "billing", From: "*@stripe.com", Subject: "(?i)invoice", HookURL: "file://" + billing},
+		{Name: "friends", Labels: []string{"Friends"}, Query: "has:attachment"},
+	})
+	if err != nil {
+		t.Fatalf("rules: %v", err)
+	}
+	store, err := newGmailWatchStore("a@b.com")
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if err := store.Update(func(s *gmailWatchState) error {
+		s.Account = "a@b.com"
+		s.HistoryID = "100"
+		return nil
+	}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	server := &gmailWatchServer{
+		cfg: gmailWatchServeConfig{
+			Account:      "a@b.com",
+			Path:         "/gmail-pubsub",
+			HookURL:      "file://" + defaultHook,
+			IncludeBody:  true,
+			MaxBodyBytes: 100,
+			HistoryMax:   100,
+			ResyncMax:    10,
+		},
+		store:      store,
+		newService: func(context.Context, string) (*gmail.Service, error) { return svc, nil },
+		hookClient: http.DefaultClient,
+		rules:      rules,
+		logf:       func(string, ...any) {},
+		warnf:      func(string, ...any) {},
+	}
+
+	env := pubsubPushEnvelope{}
+	env.Message.Data = base64.StdEncoding.EncodeToString([]byte(`{"emailAddress":"a@b.com","historyId":"200"}`))
+	body, _ := json.Marshal(env)
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/gmail-pubsub", bytes.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: %d", rr.Code)
+	}
+
+	routed := readHookLines(t, billing)
+	if len(routed) != 1 || len(routed[0].Messages) != 1 || routed[0].Messages[0].ID != "m1" || routed[0].Messages[0].Rule != "billing" || routed[0].Messages[0].Body != "body of m1" {
+		t.Fatalf("unexpected routed payloads: %+v", routed)
+	}
+	// m2 is dropped by the first rule; m3 matches the friends labels and query
+	// and goes to the default hook.
+	rest := readHookLines(t, defaultHook)
+	if len(rest) != 1 || len(rest[0].Messages) != 1 || rest[0].Messages[0].ID != "m3" || rest[0].Messages[0].Rule != "friends" {
+		t.Fatalf("unexpected default payloads: %+v", rest)
+	}
+	if got := strings.Join(api.full, ","); got != "m1,m3" {
+		t.Fatalf("expected full fetches only for kept messages, got %q", got)
+	}
+	if len(api.queries) != 1 || api.queries[0] != "(has:attachment) rfc822msgid:m3@example.com" {
+		t.Fatalf("unexpected rule queries: %q", api.queries)
+	}
+	if st := server.Stats(); st.Filtered != 1 || st.Messages != 2 || st.HookOK != 2 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+func TestGmailWatchRulesCmds(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	_, svc := newGmailWatchRulesTestAPI(t)
+	origNew := newGmailService
+	t.Cleanup(func() { newGmailService = origNew })
+	newGmailService = func(context.Context, string) (*gmail.Service, error) { return svc, nil }
+
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(bad, []byte("rules:\n  - labels: [Nope]\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := runKong(t, &GmailWatchRulesCmd{}, []string{"set", bad}, context.Background(), &RootFlags{Account: "a@b.com"}); err == nil || !strings.Contains(err.Error(), "Nope") {
+		t.Fatalf("expected unknown label error, got %v", err)
+	}
+
+	file := filepath.Join(dir, "rules.yaml")
+	if err := os.WriteFile(file, []byte("rules:\n  - name: promos\n    labels: [CATEGORY_PROMOTIONS]\n    drop: true\n  - name: billing\n    from: stripe.com\n    hookUrl: https://example.com/billing\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	// No `watch start`: rules can be stored before the watch exists.
+	out := runDriveCmdJSON(t, &GmailWatchRulesCmd{}, []string{"set", file})
+	if out["rules"] != float64(2) {
+		t.Fatalf("unexpected set result: %v", out)
+	}
+	out = runDriveCmdJSON(t, &GmailWatchRulesCmd{}, []string{})
+	if rules, _ := out["rules"].([]any); len(rules) != 2 || rules[1].(map[string]any)["hookUrl"] != "https://example.com/billing" {
+		t.Fatalf("unexpected list: %v", out)
+	}
+
+	out = runDriveCmdJSON(t, &GmailWatchRulesCmd{}, []string{"test", "m1", "m2", "m3"})
+	results, _ := out["results"].([]any)
+	actions := make([]string, 0, len(results))
+	for _, r := range results {
+		m := r.(map[string]any)
+		rule, _ := m["rule"].(string)
+		actions = append(actions, m["id"].(string)+":"+rule+":"+m["action"].(string))
+	}
+	if got := strings.Join(actions, ","); got != "m1:billing:route https://example.com/billing,m2:promos:drop,m3::drop" {
+		t.Fatalf("unexpected test results: %s", got)
+	}
+
+	out = runDriveCmdJSON(t, &GmailWatchRulesCmd{}, []string{"clear"})
+	if out["rules"] != float64(0) {
+		t.Fatalf("unexpected clear result: %v", out)
+	}
+	store, err := loadGmailWatchStore("a@b.com")
+	if err != nil || len(store.Get().Rules) != 0 {
+		t.Fatalf("expected rules cleared: %v", err)
+	}
+}
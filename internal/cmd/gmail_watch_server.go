@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"bytes"
 	"context"
 	"crypto/subtle"
 	"encoding/base64"
@@ -11,6 +10,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"google.golang.org/api/gmail/v1"
@@ -25,48 +25,86 @@ const (
 	gmailWatchStatusHTTPError = "http_error"
 )
 
+// gmailWatchMetadataHeaders are the headers fetched for hook payloads and
+// rule matching.
+var gmailWatchMetadataHeaders = []string{"From", "To", "Cc", "Subject", "Date", "Message-ID"}
+
 type gmailWatchServer struct {
 	cfg        gmailWatchServeConfig
 	store      *gmailWatchStore
 	validator  *idtoken.Validator
 	newService func(context.Context, string) (*gmail.Service, error)
 	hookClient *http.Client
+	rules      *gmailWatchRules
 	logf       func(string, ...any)
 	warnf      func(string, ...any)
+
+	statsMu sync.Mutex
+	stats   gmailWatchStats
 }
 
 func (s *gmailWatchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	payload, ok := s.readPush(w, r, func() map[string]gmailWatchStats {
+		return map[string]gmailWatchStats{s.cfg.Account: s.Stats()}
+	})
+	if !ok {
+		return
+	}
+	if payload.EmailAddress != "" && !strings.EqualFold(payload.EmailAddress, s.cfg.Account) {
+		s.warnf("watch: ignoring push for %s", payload.EmailAddress)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	s.servePush(w, r, payload)
+}
+
+// readPush validates and decodes a Pub/Sub push request, answering GET
+// <path>/stats with the given stats. It writes the response and returns
+// false when there is nothing left to do.
+func (s *gmailWatchServer) readPush(w http.ResponseWriter, r *http.Request, stats func() map[string]gmailWatchStats) (gmailPushPayload, bool) {
 	if !pathMatches(s.cfg.Path, r.URL.Path) {
 		w.WriteHeader(http.StatusNotFound)
-		return
+		return gmailPushPayload{}, false
+	}
+	if r.Method == http.MethodGet && r.URL.Path == strings.TrimSuffix(s.cfg.Path, "/")+"/stats" {
+		if !s.authorize(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return gmailPushPayload{}, false
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"accounts": stats()})
+		return gmailPushPayload{}, false
 	}
 	if r.Method != http.MethodPost {
 		w.Header().Set("Allow", http.MethodPost)
 		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
+		return gmailPushPayload{}, false
 	}
 	if ok := s.authorize(r); !ok {
 		w.WriteHeader(http.StatusUnauthorized)
-		return
+		return gmailPushPayload{}, false
 	}
 
 	push, err := parsePubSubPush(r)
 	if err != nil {
 		s.warnf("watch: invalid push payload: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
-		return
+		return gmailPushPayload{}, false
 	}
 	payload, err := decodeGmailPushPayload(push)
 	if err != nil {
 		s.warnf("watch: invalid push data: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-	if payload.EmailAddress != "" && !strings.EqualFold(payload.EmailAddress, s.cfg.Account) {
-		s.warnf("watch: ignoring push for %s", payload.EmailAddress)
-		w.WriteHeader(http.StatusAccepted)
-		return
+		return gmailPushPayload{}, false
 	}
+	return payload, true
+}
+
+func (s *gmailWatchServer) servePush(w http.ResponseWriter, r *http.Request, payload gmailPushPayload) {
+	s.recordStats(func(st *gmailWatchStats) {
+		st.Pushes++
+		st.LastPushAtMs = time.Now().UnixMilli()
+	})
 
 	result, err := s.handlePush(r.Context(), payload)
 	if err != nil {
@@ -74,6 +112,7 @@ func (s *gmailWatchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusAccepted)
 			return
 		}
+		s.recordStats(func(st *gmailWatchStats) { st.Errors++ })
 		s.warnf("watch: handle push failed: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -82,7 +121,13 @@ func (s *gmailWatchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusAccepted)
 		return
 	}
+	s.recordStats(func(st *gmailWatchStats) { st.Messages += int64(len(result.Messages)) })
 
+	result = s.deliverRoutes(r.Context(), result)
+	if result == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
 	if s.cfg.HookURL == "" {
 		if s.cfg.AllowNoHook {
 			_ = json.NewEncoder(w).Encode(result)
@@ -92,12 +137,116 @@ func (s *gmailWatchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.sendHook(r.Context(), result); err != nil {
+	s.forward(r.Context(), s.hookTarget(), result)
+	w.WriteHeader(http.StatusOK)
+}
+
+// deliverRoutes forwards messages that rules routed to their own hooks and
+// returns the rest for the default hook, or nil when nothing is left.
+// Without rules the payload is returned unchanged.
+func (s *gmailWatchServer) deliverRoutes(ctx context.Context, result *gmailHookPayload) *gmailHookPayload {
+	if s.rules == nil {
+		return result
+	}
+	var (
+		order  []string
+		routed = map[string][]gmailHookMessage{}
+		rest   []gmailHookMessage
+	)
+	for _, msg := range result.Messages {
+		if msg.route == "" {
+			rest = append(rest, msg)
+			continue
+		}
+		if _, ok := routed[msg.route]; !ok {
+			order = append(order, msg.route)
+		}
+		routed[msg.route] = append(routed[msg.route], msg)
+	}
+	for _, url := range order {
+		payload := *result
+		payload.Messages = routed[url]
+		s.forward(ctx, s.targetFor(url), &payload)
+	}
+	if len(rest) == 0 {
+		return nil
+	}
+	payload := *result
+	payload.Messages = rest
+	return &payload
+}
+
+// forward delivers a payload to target, queueing it in the outbox when
+// delivery fails.
+func (s *gmailWatchServer) forward(ctx context.Context, target gmailHookTarget, result *gmailHookPayload) {
+	if err := s.sendHookTo(ctx, target, result); err != nil {
+		s.recordStats(func(st *gmailWatchStats) { st.HookFailed++ })
 		s.warnf("watch: hook failed: %v", err)
-		w.WriteHeader(http.StatusOK)
+		if s.cfg.OutboxMaxAttempts > 0 {
+			if entry, queueErr := enqueueGmailWatchOutbox(s.cfg.Account, target.URL, *result, err, s.cfg.OutboxMaxAttempts); queueErr != nil {
+				s.warnf("watch: outbox enqueue failed: %v", queueErr)
+			} else {
+				s.recordStats(func(st *gmailWatchStats) { st.Queued++ })
+				s.logf("watch: queued %s for retry", entry.ID)
+			}
+		}
 		return
 	}
-	w.WriteHeader(http.StatusOK)
+	s.recordStats(func(st *gmailWatchStats) { st.HookOK++ })
+}
+
+func (s *gmailWatchServer) recordStats(fn func(*gmailWatchStats)) {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	fn(&s.stats)
+}
+
+// Stats returns a snapshot of the in-memory counters since startup.
+func (s *gmailWatchServer) Stats() gmailWatchStats {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	return s.stats
+}
+
+// gmailWatchMux serves several accounts on one listener, routing each push
+// by the emailAddress in its payload. Auth settings are shared, so any
+// account's server can validate the request.
+type gmailWatchMux struct {
+	servers  map[string]*gmailWatchServer // keyed by lower-case account
+	accounts []string
+}
+
+func newGmailWatchMux(servers []*gmailWatchServer) *gmailWatchMux {
+	m := &gmailWatchMux{servers: make(map[string]*gmailWatchServer, len(servers))}
+	for _, srv := range servers {
+		m.servers[strings.ToLower(srv.cfg.Account)] = srv
+		m.accounts = append(m.accounts, srv.cfg.Account)
+	}
+	return m
+}
+
+func (m *gmailWatchMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	front := m.servers[strings.ToLower(m.accounts[0])]
+	payload, ok := front.readPush(w, r, m.Stats)
+	if !ok {
+		return
+	}
+	srv := m.servers[strings.ToLower(strings.TrimSpace(payload.EmailAddress))]
+	if srv == nil {
+		front.warnf("watch: ignoring push for unknown account %q", payload.EmailAddress)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	srv.servePush(w, r, payload)
+}
+
+// Stats returns the counters of every account.
+func (m *gmailWatchMux) Stats() map[string]gmailWatchStats {
+	out := make(map[string]gmailWatchStats, len(m.servers))
+	for _, account := range m.accounts {
+		out[account] = m.servers[strings.ToLower(account)].Stats()
+	}
+	return out
 }
 
 func (s *gmailWatchServer) authorize(r *http.Request) bool {
@@ -182,7 +331,16 @@ func (s *gmailWatchServer) handlePush(ctx context.Context, payload gmailPushPayl
 	historyResp, err := historyCall.Do()
 	if err != nil {
 		if isStaleHistoryError(err) {
-			return s.resyncHistory(ctx, svc, payload.HistoryID, payload.MessageID)
+			historyID := payload.HistoryID
+			if historyID == "" {
+				// Polling has no push historyId; restart from the mailbox's.
+				profile, profileErr := svc.Users.GetProfile("me").Context(ctx).Do()
+				if profileErr != nil {
+					return nil, profileErr
+				}
+				historyID = formatHistoryID(profile.HistoryId)
+			}
+			return s.resyncHistory(ctx, svc, historyID, payload.MessageID)
 		}
 		return nil, err
 	}
@@ -266,18 +424,14 @@ func (s *gmailWatchServer) resyncHistory(ctx context.Context, svc *gmail.Service
 func (s *gmailWatchServer) fetchMessages(ctx context.Context, svc *gmail.Service, ids []string) ([]gmailHookMessage, error) {
 	messages := make([]gmailHookMessage, 0, len(ids))
 	format := gmailWatchFormatMetadata
-	if s.cfg.IncludeBody {
+	if s.cfg.IncludeBody && s.rules == nil {
 		format = "full"
 	}
 	for _, id := range ids {
 		if strings.TrimSpace(id) == "" {
 			continue
 		}
-		msg, err := svc.Users.Messages.Get("me", id).
-			Format(format).
-			MetadataHeaders("From", "To", "Subject", "Date").
-			Context(ctx).
-			Do()
+		msg, err := s.getMessage(ctx, svc, id, format)
 		if err != nil {
 			if isNotFoundAPIError(err) {
 				continue
@@ -287,6 +441,29 @@ func (s *gmailWatchServer) fetchMessages(ctx context.Context, svc *gmail.Service
 		if msg == nil {
 			continue
 		}
+
+		var rule, route string
+		if s.rules != nil {
+			var keep bool
+			rule, route, keep, err = s.rules.apply(ctx, svc, msg)
+			if err != nil {
+				return nil, err
+			}
+			if !keep {
+				s.recordStats(func(st *gmailWatchStats) { st.Filtered++ })
+				continue
+			}
+			// Rules match on metadata; only kept messages pay for the body.
+			if s.cfg.IncludeBody {
+				if msg, err = s.getMessage(ctx, svc, id, "full"); err != nil {
+					if isNotFoundAPIError(err) {
+						continue
+					}
+					return nil, err
+				}
+			}
+		}
+
 		item := gmailHookMessage{
 			ID:       msg.Id,
 			ThreadID: msg.ThreadId,
@@ -296,6 +473,8 @@ func (s *gmailWatchServer) fetchMessages(ctx context.Context, svc *gmail.Service
 			Date:     formatGmailDate(headerValue(msg.Payload, "Date")),
 			Snippet:  msg.Snippet,
 			Labels:   msg.LabelIds,
+			Rule:     rule,
+			route:    route,
 		}
 		if s.cfg.IncludeBody {
 			body := bestBodyText(msg.Payload)
@@ -306,46 +485,61 @@ func (s *gmailWatchServer) fetchMessages(ctx context.Context, svc *gmail.Service
 	return messages, nil
 }
 
+func (s *gmailWatchServer) getMessage(ctx context.Context, svc *gmail.Service, id, format string) (*gmail.Message, error) {
+	return svc.Users.Messages.Get("me", id).
+		Format(format).
+		MetadataHeaders(gmailWatchMetadataHeaders...).
+		Context(ctx).
+		Do()
+}
+
 func (s *gmailWatchServer) sendHook(ctx context.Context, payload *gmailHookPayload) error {
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.HookURL, bytes.NewReader(data))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	if s.cfg.HookToken != "" {
-		req.Header.Set("Authorization", "Bearer "+s.cfg.HookToken)
-	}
-	resp, err := s.hookClient.Do(req)
-	if err != nil {
+	return s.sendHookTo(ctx, s.hookTarget(), payload)
+}
+
+func (s *gmailWatchServer) sendHookTo(ctx context.Context, target gmailHookTarget, payload *gmailHookPayload) error {
+	err := deliverGmailHook(ctx, s.hookClient, target, payload)
+	var statusErr *gmailHookStatusError
+	switch {
+	case errors.As(err, &statusErr):
+		_ = s.store.Update(func(state *gmailWatchState) error {
+			state.LastDeliveryStatus = gmailWatchStatusHTTPError
+			state.LastDeliveryAtMs = time.Now().UnixMilli()
+			state.LastDeliveryStatusNote = fmt.Sprintf("status %d", statusErr.StatusCode)
+			return nil
+		})
+	case err != nil:
 		_ = s.store.Update(func(state *gmailWatchState) error {
 			state.LastDeliveryStatus = "error"
 			state.LastDeliveryAtMs = time.Now().UnixMilli()
 			state.LastDeliveryStatusNote = err.Error()
 			return nil
 		})
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+	default:
 		_ = s.store.Update(func(state *gmailWatchState) error {
-			state.LastDeliveryStatus = gmailWatchStatusHTTPError
+			state.LastDeliveryStatus = "ok"
 			state.LastDeliveryAtMs = time.Now().UnixMilli()
-			state.LastDeliveryStatusNote = fmt.Sprintf("status %d", resp.StatusCode)
+			state.LastDeliveryStatusNote = ""
 			return nil
 		})
-		return fmt.Errorf("hook status %d", resp.StatusCode)
 	}
-	_ = s.store.Update(func(state *gmailWatchState) error {
-		state.LastDeliveryStatus = "ok"
-		state.LastDeliveryAtMs = time.Now().UnixMilli()
-		state.LastDeliveryStatusNote = ""
-		return nil
-	})
-	return nil
+	return err
+}
+
+func (s *gmailWatchServer) hookTarget() gmailHookTarget {
+	return gmailHookTarget{URL: s.cfg.HookURL, Token: s.cfg.HookToken, Secret: s.cfg.HookSecret}
+}
+
+// targetFor returns the credentials for a hook URL: the default hook's, a
+// routing rule's, or none.
+func (s *gmailWatchServer) targetFor(url string) gmailHookTarget {
+	if url == s.cfg.HookURL {
+		return s.hookTarget()
+	}
+	if target, ok := s.rules.target(url); ok {
+		return target
+	}
+	return gmailHookTarget{URL: url}
 }
 
 func parsePubSubPush(r *http.Request) (*pubsubPushEnvelope, error) {
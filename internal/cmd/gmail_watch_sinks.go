@@ -0,0 +1,382 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/steipete/gogcli/internal/config"
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+const (
+	gmailHookTimestampHeader = "X-Gog-Timestamp"
+	gmailHookSignatureHeader = "X-Gog-Signature"
+	gmailHookSignatureScheme = "v1="
+
+	gmailHookExecStderrLimit = 512
+)
+
+// gmailHookFileMu serializes appends to file sinks so concurrent pushes
+// never interleave NDJSON lines.
+var gmailHookFileMu sync.Mutex
+
+// gmailHookTarget is where a hook payload goes. URL selects the sink:
+//
+//	http(s)://host/path  POST JSON (bearer token + HMAC headers)
+//	file:///path.ndjson  append one JSON line (signed: gmailHookSignedLine)
+//	unix:///path.sock    write one JSON line to a Unix socket (same)
+//	exec:cmd args...     run cmd with the JSON on stdin (HMAC in the env)
+type gmailHookTarget struct {
+	URL    string
+	Token  string
+	Secret string
+}
+
+// gmailHookSignedLine is the line file and unix sinks write when the target
+// has a secret. The signature covers the payload bytes exactly as they appear
+// in the line.
+type gmailHookSignedLine struct {
+	Timestamp int64           `json:"timestamp"`
+	Signature string          `json:"signature"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+type gmailHookSink struct {
+	kind string // http, file, unix or exec
+	path string // file or socket path
+	argv []string
+}
+
+func parseGmailHookSink(raw string) (gmailHookSink, error) {
+	raw = strings.TrimSpace(raw)
+	scheme, rest, ok := strings.Cut(raw, ":")
+	if !ok {
+		return gmailHookSink{}, fmt.Errorf("invalid hook URL %q (want http(s)://, file://, unix:// or exec:)", raw)
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https":
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return gmailHookSink{}, fmt.Errorf("invalid hook URL %q", raw)
+		}
+		return gmailHookSink{kind: "http"}, nil
+	case "file", "unix":
+		u, err := url.Parse(raw)
+		if err != nil {
+			return gmailHookSink{}, fmt.Errorf("invalid hook URL %q", raw)
+		}
+		path := u.Path
+		if u.Opaque != "" {
+			path = u.Opaque
+		}
+		path, err = config.ExpandPath(path)
+		if err != nil {
+			return gmailHookSink{}, err
+		}
+		if strings.TrimSpace(path) == "" {
+			return gmailHookSink{}, fmt.Errorf("hook URL %q has no path", raw)
+		}
+		return gmailHookSink{kind: strings.ToLower(scheme), path: path}, nil
+	case "exec":
+		argv, err := splitGmailHookCommand(rest)
+		if err != nil {
+			return gmailHookSink{}, fmt.Errorf("hook URL %q: %w", raw, err)
+		}
+		if len(argv) == 0 {
+			return gmailHookSink{}, fmt.Errorf("hook URL %q has no command", raw)
+		}
+		return gmailHookSink{kind: "exec", argv: argv}, nil
+	default:
+		return gmailHookSink{}, fmt.Errorf("unsupported hook URL scheme %q (want http(s)://, file://, unix:// or exec:)", scheme)
+	}
+}
+
+// splitGmailHookCommand splits an exec: command on whitespace. Single or
+// double quotes keep spaces in an argument. There is no shell, so no
+// escapes, variables or globs; backslashes are literal (Windows paths).
+func splitGmailHookCommand(s string) ([]string, error) {
+	var (
+		argv  []string
+		cur   strings.Builder
+		inArg bool
+		quote rune
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				argv = append(argv, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		argv = append(argv, cur.String())
+	}
+	return argv, nil
+}
+
+// signGmailHook returns the HMAC-SHA256 signature of "<timestamp>.<body>"
+// in the X-Gog-Signature format.
+func signGmailHook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return gmailHookSignatureScheme + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyGmailHookSignature checks a signature produced by signGmailHook and
+// rejects timestamps more than window away from now.
+func verifyGmailHookSignature(secret, timestamp, signature string, body []byte, now time.Time, window time.Duration) error {
+	ts, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return errors.New("invalid signature timestamp")
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > window || age < -window {
+		return fmt.Errorf("signature timestamp outside the %s window", window)
+	}
+	if !hmac.Equal([]byte(strings.TrimSpace(signature)), []byte(signGmailHook(secret, ts, body))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// deliverGmailHook sends payload to target. Non-2xx HTTP responses return a
+// *gmailHookStatusError.
+func deliverGmailHook(ctx context.Context, client *http.Client, target gmailHookTarget, payload *gmailHookPayload) error {
+	sink, err := parseGmailHookSink(target.URL)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	switch sink.kind {
+	case "file", "unix":
+		line, lineErr := gmailHookLine(target.Secret, data)
+		if lineErr != nil {
+			return lineErr
+		}
+		if sink.kind == "file" {
+			return appendGmailHookFile(sink.path, line)
+		}
+		return writeGmailHookSocket(ctx, client.Timeout, sink.path, line)
+	case "exec":
+		return runGmailHookCommand(ctx, client.Timeout, sink.argv, target.Secret, data)
+	default:
+		return postGmailHook(ctx, client, target, data)
+	}
+}
+
+// gmailHookLine returns the NDJSON line for a file or unix sink: the payload
+// itself, or a gmailHookSignedLine wrapping it when there is a secret.
+func gmailHookLine(secret string, data []byte) ([]byte, error) {
+	if secret == "" {
+		return data, nil
+	}
+	ts := time.Now().Unix()
+	return json.Marshal(gmailHookSignedLine{
+		Timestamp: ts,
+		Signature: signGmailHook(secret, ts, data),
+		Payload:   data,
+	})
+}
+
+type gmailHookStatusError struct {
+	StatusCode int
+}
+
+func (e *gmailHookStatusError) Error() string {
+	return fmt.Sprintf("hook status %d", e.StatusCode)
+}
+
+func postGmailHook(ctx context.Context, client *http.Client, target gmailHookTarget, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if target.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+target.Token)
+	}
+	if target.Secret != "" {
+		ts := time.Now().Unix()
+		req.Header.Set(gmailHookTimestampHeader, strconv.FormatInt(ts, 10))
+		req.Header.Set(gmailHookSignatureHeader, signGmailHook(target.Secret, ts, data))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &gmailHookStatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+func appendGmailHookFile(path string, data []byte) error {
+	gmailHookFileMu.Lock()
+	defer gmailHookFileMu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600) //nolint:gosec // path is the configured hook sink
+	if err != nil {
+		return fmt.Errorf("open hook file: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write hook file: %w", err)
+	}
+	return f.Close()
+}
+
+func writeGmailHookSocket(ctx context.Context, timeout time.Duration, path string, data []byte) error {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if err != nil {
+		return fmt.Errorf("dial hook socket: %w", err)
+	}
+	defer conn.Close()
+	if timeout > 0 {
+		_ = conn.SetWriteDeadline(time.Now().Add(timeout))
+	}
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write hook socket: %w", err)
+	}
+	return nil
+}
+
+// runGmailHookCommand runs argv with the payload on stdin. With a secret the
+// signature is passed as GOG_HOOK_TIMESTAMP and GOG_HOOK_SIGNATURE.
+func runGmailHookCommand(ctx context.Context, timeout time.Duration, argv []string, secret string, data []byte) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...) //nolint:gosec // the command is the configured hook sink
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = os.Environ()
+	if secret != "" {
+		ts := time.Now().Unix()
+		cmd.Env = append(cmd.Env,
+			"GOG_HOOK_TIMESTAMP="+strconv.FormatInt(ts, 10),
+			"GOG_HOOK_SIGNATURE="+signGmailHook(secret, ts, data),
+		)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > gmailHookExecStderrLimit {
+			msg = msg[:gmailHookExecStderrLimit] + "…"
+		}
+		if msg != "" {
+			return fmt.Errorf("hook command %s: %w: %s", argv[0], err, msg)
+		}
+		return fmt.Errorf("hook command %s: %w", argv[0], err)
+	}
+	return nil
+}
+
+type GmailWatchVerifyCmd struct {
+	Secret    string        `name:"secret" help:"HMAC secret (default: the stored hook's secret)"`
+	Timestamp string        `name:"timestamp" help:"X-Gog-Timestamp value (default: $GOG_HOOK_TIMESTAMP)"`
+	Signature string        `name:"signature" help:"X-Gog-Signature value (default: $GOG_HOOK_SIGNATURE)"`
+	Window    time.Duration `name:"window" help:"Reject timestamps further than this from now" default:"5m"`
+	File      string        `name:"file" help:"Payload file, or a signed file/unix sink line ('-' for stdin)" default:"-"`
+}
+
+func (c *GmailWatchVerifyCmd) Run(ctx context.Context, flags *RootFlags) error {
+	u := ui.FromContext(ctx)
+	secret := c.Secret
+	if secret == "" {
+		account, err := requireAccount(flags)
+		if err != nil {
+			return err
+		}
+		store, err := loadGmailWatchStore(account)
+		if err != nil {
+			return err
+		}
+		if hook := store.Get().Hook; hook != nil {
+			secret = hook.Secret
+		}
+		if secret == "" {
+			return usage("--secret required (no hook secret stored)")
+		}
+	}
+	if c.Window <= 0 {
+		return usage("--window must be > 0")
+	}
+	body, err := readBodyFile(c.File)
+	if err != nil {
+		return err
+	}
+	payload := []byte(body)
+
+	timestamp := c.Timestamp
+	if timestamp == "" {
+		timestamp = os.Getenv("GOG_HOOK_TIMESTAMP")
+	}
+	signature := c.Signature
+	if signature == "" {
+		signature = os.Getenv("GOG_HOOK_SIGNATURE")
+	}
+	if timestamp == "" && signature == "" {
+		// A line from a signed file or unix sink carries its own signature.
+		var line gmailHookSignedLine
+		if json.Unmarshal(payload, &line) == nil && line.Signature != "" && len(line.Payload) > 0 {
+			timestamp = strconv.FormatInt(line.Timestamp, 10)
+			signature = line.Signature
+			payload = line.Payload
+		}
+	}
+	if timestamp == "" || signature == "" {
+		return usage("--timestamp and --signature are required")
+	}
+
+	if err := verifyGmailHookSignature(secret, timestamp, signature, payload, time.Now(), c.Window); err != nil {
+		return err
+	}
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"valid": true})
+	}
+	u.Out().Printf("valid\ttrue")
+	return nil
+}
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
+)
+
+func TestGmailHookSignature(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"source":"gmail"}`)
+	sig := signGmailHook("s3cret", now.Unix(), body)
+	if !strings.HasPrefix(sig, "v1=") || len(sig) != 3+64 {
+		t.Fatalf("unexpected signature: %q", sig)
+	}
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	if err := verifyGmailHookSignature("s3cret", ts, sig, body, now.Add(time.Minute), 5*time.Minute); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := verifyGmailHookSignature("other", ts, sig, body, now, 5*time.Minute); err == nil {
+		t.Fatalf("expected mismatch for wrong secret")
+	}
+	if err := verifyGmailHookSignature("s3cret", ts, sig, []byte(`{"source":"evil"}`), now, 5*time.Minute); err == nil {
+		t.Fatalf("expected mismatch for modified body")
+	}
+	if err := verifyGmailHookSignature("s3cret", ts, sig, body, now.Add(10*time.Minute), 5*time.Minute); err == nil || !strings.Contains(err.Error(), "window") {
+		t.Fatalf("expected replay window error, got %v", err)
+	}
+	if err := verifyGmailHookSignature("s3cret", "nope", sig, body, now, 5*time.Minute); err == nil {
+		t.Fatalf("expected invalid timestamp error")
+	}
+}
+
+func TestParseGmailHookSink(t *testing.T) {
+	cases := []struct {
+		raw  string
+		kind string
+		path string
+		argv []string
+	}{
+		{raw: "https://example.com/hook", kind: "http"},
+		{raw: "file:///var/log/mail.ndjson", kind: "file", path: "/var/log/mail.ndjson"},
+		{raw: "file:mail.ndjson", kind: "file", path: "mail.ndjson"},
+		{raw: "unix:///run/gog.sock", kind: "unix", path: "/run/gog.sock"},
+		{raw: "exec:/usr/local/bin/on-mail --quiet", kind: "exec", argv: []string{"/usr/local/bin/on-mail", "--quiet"}},
+		{raw: `exec:"/opt/My Hooks/on-mail" --tag 'new mail' ""`, kind: "exec", argv: []string{"/opt/My Hooks/on-mail", "--tag", "new mail", ""}},
+		{raw: `exec:C:\hooks\on-mail.exe`, kind: "exec", argv: []string{`C:\hooks\on-mail.exe`}},
+	}
+	for _, tc := range cases {
+		sink, err := parseGmailHookSink(tc.raw)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.raw, err)
+		}
+		if sink.kind != tc.kind || sink.path != tc.path || strings.Join(sink.argv, "|") != strings.Join(tc.argv, "|") {
+			t.Fatalf("%s: unexpected sink %+v", tc.raw, sink)
+		}
+	}
+	for _, raw := range []string{"ftp://example.com", "exec:", "exec:on-mail 'oops", "file://", "http://", "example.com/hook"} {
+		if _, err := parseGmailHookSink(raw); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+	if _, err := hookFromFlags("smtp://example.com", "", false, 0, false, false); err == nil {
+		t.Fatalf("expected hookFromFlags to reject unsupported schemes")
+	}
+}
+
+func TestDeliverGmailHook_HTTPSigned(t *testing.T) {
+	var (
+		gotBody []byte
+		gotTS   string
+		gotSig  string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotTS = r.Header.Get("X-Gog-Timestamp")
+		gotSig = r.Header.Get("X-Gog-Signature")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	payload := &gmailHookPayload{Source: "gmail", Account: "a@b.com", HistoryID: "1"}
+	if err := deliverGmailHook(context.Background(), srv.Client(), gmailHookTarget{URL: srv.URL, Secret: "s3cret"}, payload); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if err := verifyGmailHookSignature("s3cret", gotTS, gotSig, gotBody, time.Now(), time.Minute); err != nil {
+		t.Fatalf("verify delivered signature: %v (ts=%q sig=%q)", err, gotTS, gotSig)
+	}
+
+	if err := deliverGmailHook(context.Background(), srv.Client(), gmailHookTarget{URL: srv.URL}, payload); err != nil {
+		t.Fatalf("deliver unsigned: %v", err)
+	}
+	if gotTS != "" || gotSig != "" {
+		t.Fatalf("expected no signature headers without a secret")
+	}
+}
+
+func TestDeliverGmailHook_FileAndSocket(t *testing.T) {
+	dir := t.TempDir()
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	file := filepath.Join(dir, "events.ndjson")
+	for _, id := range []string{"1", "2"} {
+		if err := deliverGmailHook(context.Background(), client, gmailHookTarget{URL: "file://" + file}, &gmailHookPayload{Source: "gmail", HistoryID: id}); err != nil {
+			t.Fatalf("deliver file: %v", err)
+		}
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected two lines, got %q", data)
+	}
+	var p gmailHookPayload
+	if err := json.Unmarshal([]byte(lines[1]), &p); err != nil || p.HistoryID != "2" {
+		t.Fatalf("unexpected line %q (%v)", lines[1], err)
+	}
+
+	sock := filepath.Join(dir, "hook.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+	got := make(chan string, 1)
+	go func() {
+		conn, acceptErr := ln.Accept()
+		if acceptErr != nil {
+			got <- acceptErr.Error()
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		got <- line
+	}()
+	if err := deliverGmailHook(context.Background(), client, gmailHookTarget{URL: "unix://" + sock}, &gmailHookPayload{Source: "gmail", HistoryID: "3"}); err != nil {
+		t.Fatalf("deliver socket: %v", err)
+	}
+	if line := <-got; !strings.HasSuffix(line, "\n") || !strings.Contains(line, `"historyId":"3"`) {
+		t.Fatalf("unexpected socket line %q", line)
+	}
+}
+
+func TestDeliverGmailHook_FileSigned(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "events.ndjson")
+	client := &http.Client{Timeout: 5 * time.Second}
+	payload := &gmailHookPayload{Source: "gmail", Account: "a@b.com", HistoryID: "1", Messages: []gmailHookMessage{{Subject: "<b>&co</b>"}}}
+	if err := deliverGmailHook(context.Background(), client, gmailHookTarget{URL: "file://" + file, Secret: "s3cret"}, payload); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var line gmailHookSignedLine
+	if err := json.Unmarshal(data, &line); err != nil || line.Timestamp == 0 || !strings.HasPrefix(line.Signature, "v1=") {
+		t.Fatalf("unexpected signed line %q (%v)", data, err)
+	}
+	if err := verifyGmailHookSignature("s3cret", strconv.FormatInt(line.Timestamp, 10), line.Signature, line.Payload, time.Now(), time.Minute); err != nil {
+		t.Fatalf("verify line: %v", err)
+	}
+
+	// watch verify takes the line as is.
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := outfmt.WithMode(ui.WithUI(context.Background(), u), outfmt.Mode{JSON: true})
+	for secret, wantOK := range map[string]bool{"s3cret": true, "wrong": false} {
+		var runErr error
+		captureStdout(t, func() {
+			runErr = runKong(t, &GmailWatchVerifyCmd{}, []string{"--secret", secret, "--file", file}, ctx, &RootFlags{Account: "a@b.com"})
+		})
+		if (runErr == nil) != wantOK {
+			t.Fatalf("verify with %q: %v", secret, runErr)
+		}
+	}
+}
+
+func TestDeliverGmailHook_Exec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script sink")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "hook.sh")
+	out := filepath.Join(dir, "out.json")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncat > \"$1\"\necho \"$GOG_HOOK_TIMESTAMP $GOG_HOOK_SIGNATURE\" > \"$1.sig\"\n"), 0o700); err != nil { //nolint:gosec // test script must be executable
+		t.Fatalf("write script: %v", err)
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	payload := &gmailHookPayload{Source: "gmail", Account: "a@b.com", HistoryID: "9"}
+	if err := deliverGmailHook(context.Background(), client, gmailHookTarget{URL: "exec:" + script + " " + out, Secret: "s3cret"}, payload); err != nil {
+		t.Fatalf("deliver exec: %v", err)
+	}
+	body, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read out: %v", err)
+	}
+	sig, err := os.ReadFile(out + ".sig")
+	if err != nil {
+		t.Fatalf("read sig: %v", err)
+	}
+	fields := strings.Fields(string(sig))
+	if len(fields) != 2 {
+		t.Fatalf("unexpected env: %q", sig)
+	}
+	if err := verifyGmailHookSignature("s3cret", fields[0], fields[1], body, time.Now(), time.Minute); err != nil {
+		t.Fatalf("verify exec signature: %v", err)
+	}
+
+	failing := filepath.Join(dir, "fail.sh")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\necho nope >&2\nexit 3\n"), 0o700); err != nil { //nolint:gosec // test script must be executable
+		t.Fatalf("write script: %v", err)
+	}
+	err = deliverGmailHook(context.Background(), client, gmailHookTarget{URL: "exec:" + failing}, payload)
+	if err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Fatalf("expected command failure with stderr, got %v", err)
+	}
+}
+
+func TestGmailWatchVerifyCmd(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	body := `{"source":"gmail","historyId":"1"}`
+	file := filepath.Join(t.TempDir(), "payload.json")
+	if err := os.WriteFile(file, []byte(body), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	ts := time.Now().Unix()
+	sig := signGmailHook("s3cret", ts, []byte(body))
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := outfmt.WithMode(ui.WithUI(context.Background(), u), outfmt.Mode{JSON: true})
+	run := func(args ...string) error {
+		var runErr error
+		captureStdout(t, func() {
+			runErr = runKong(t, &GmailWatchVerifyCmd{}, args, ctx, &RootFlags{Account: "a@b.com"})
+		})
+		return runErr
+	}
+
+	if err := run("--secret", "s3cret", "--timestamp", strconv.FormatInt(ts, 10), "--signature", sig, "--file", file); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	t.Setenv("GOG_HOOK_TIMESTAMP", strconv.FormatInt(ts, 10))
+	t.Setenv("GOG_HOOK_SIGNATURE", sig)
+	if err := run("--secret", "wrong", "--file", file); err == nil {
+		t.Fatalf("expected signature mismatch")
+	}
+	if err := run("--file", file); err == nil {
+		t.Fatalf("expected error without a secret")
+	}
+}
@@ -54,6 +54,8 @@ func sanitizeAccountForPath(account string) string {
 	return b.String()
 }
 
+var errGmailWatchStateNotFound = errors.New("watch state not found; run gmail watch start")
+
 func newGmailWatchStore(account string) (*gmailWatchStore, error) {
 	path, err := gmailWatchStatePath(account)
 	if err != nil {
@@ -70,7 +72,7 @@ func loadGmailWatchStore(account string) (*gmailWatchStore, error) {
 	data, err := os.ReadFile(store.path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return nil, errors.New("watch state not found; run gmail watch start")
+			return nil, errGmailWatchStateNotFound
 		}
 		return nil, err
 	}
@@ -19,24 +19,26 @@ const (
 type gmailWatchHook struct {
 	URL         string `json:"url"`
 	Token       string `json:"token,omitempty"`
+	Secret      string `json:"secret,omitempty"`
 	IncludeBody bool   `json:"includeBody,omitempty"`
 	MaxBytes    int    `json:"maxBytes,omitempty"`
 }
 
 type gmailWatchState struct {
-	Account                string          `json:"account"`
-	Topic                  string          `json:"topic"`
-	Labels                 []string        `json:"labels,omitempty"`
-	HistoryID              string          `json:"historyId"`
-	ExpirationMs           int64           `json:"expirationMs,omitempty"`
-	ProviderExpirationMs   int64           `json:"providerExpirationMs,omitempty"`
-	RenewAfterMs           int64           `json:"renewAfterMs,omitempty"`
-	UpdatedAtMs            int64           `json:"updatedAtMs,omitempty"`
-	Hook                   *gmailWatchHook `json:"hook,omitempty"`
-	LastDeliveryStatus     string          `json:"lastDeliveryStatus,omitempty"`
-	LastDeliveryAtMs       int64           `json:"lastDeliveryAtMs,omitempty"`
-	LastDeliveryStatusNote string          `json:"lastDeliveryStatusNote,omitempty"`
-	LastPushMessageID      string          `json:"lastPushMessageId,omitempty"`
+	Account                string           `json:"account"`
+	Topic                  string           `json:"topic"`
+	Labels                 []string         `json:"labels,omitempty"`
+	HistoryID              string           `json:"historyId"`
+	ExpirationMs           int64            `json:"expirationMs,omitempty"`
+	ProviderExpirationMs   int64            `json:"providerExpirationMs,omitempty"`
+	RenewAfterMs           int64            `json:"renewAfterMs,omitempty"`
+	UpdatedAtMs            int64            `json:"updatedAtMs,omitempty"`
+	Hook                   *gmailWatchHook  `json:"hook,omitempty"`
+	LastDeliveryStatus     string           `json:"lastDeliveryStatus,omitempty"`
+	LastDeliveryAtMs       int64            `json:"lastDeliveryAtMs,omitempty"`
+	LastDeliveryStatusNote string           `json:"lastDeliveryStatusNote,omitempty"`
+	LastPushMessageID      string           `json:"lastPushMessageId,omitempty"`
+	Rules                  []gmailWatchRule `json:"rules,omitempty"`
 }
 
 type gmailWatchServeConfig struct {
@@ -50,6 +52,7 @@ type gmailWatchServeConfig struct {
 	SharedToken   string
 	HookURL       string
 	HookToken     string
+	HookSecret    string
 	IncludeBody   bool
 	MaxBodyBytes  int
 	HistoryMax    int64
@@ -58,6 +61,24 @@ type gmailWatchServeConfig struct {
 	PersistHook   bool
 	AllowNoHook   bool
 	VerboseOutput bool
+	// OutboxMaxAttempts > 0 stores failed hook deliveries in the outbox and
+	// retries them every OutboxInterval.
+	OutboxMaxAttempts int
+	OutboxInterval    time.Duration
+}
+
+// gmailWatchStats counts what a watch server did for one account since it
+// started.
+type gmailWatchStats struct {
+	Pushes       int64 `json:"pushes"`
+	Messages     int64 `json:"messages"`
+	HookOK       int64 `json:"hookOk"`
+	HookFailed   int64 `json:"hookFailed"`
+	Errors       int64 `json:"errors"`
+	Queued       int64 `json:"queued"`
+	Redelivered  int64 `json:"redelivered"`
+	Filtered     int64 `json:"filtered"`
+	LastPushAtMs int64 `json:"lastPushAtMs,omitempty"`
 }
 
 type pubsubPushEnvelope struct {
@@ -115,6 +136,9 @@ type gmailHookMessage struct {
 	Body          string   `json:"body,omitempty"`
 	BodyTruncated bool     `json:"bodyTruncated,omitempty"`
 	Labels        []string `json:"labels,omitempty"`
+	Rule          string   `json:"rule,omitempty"`
+
+	route string // hook URL of the matching rule; empty for the default hook
 }
 
 type gmailHookPayload struct {
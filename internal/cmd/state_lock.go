@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	stateLockTimeout = 10 * time.Second
+	stateLockStale   = time.Minute
+	stateLockPoll    = 20 * time.Millisecond
+)
+
+// lockStateFile serializes read-modify-write cycles on a state file across
+// processes (a `--daemon` and a manual run, `watch serve` and `watch outbox
+// retry`). It creates <path>.lock exclusively and returns a func that
+// removes it. A lock left behind by a crashed process is broken after
+// stateLockStale; updates finish in milliseconds, so a live holder never
+// gets that old.
+func lockStateFile(path string) (func(), error) {
+	lock := path + ".lock"
+	deadline := time.Now().Add(stateLockTimeout)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600) //nolint:gosec // path is derived from the config dir
+		if err == nil {
+			_, _ = f.WriteString(strconv.Itoa(os.Getpid()))
+			_ = f.Close()
+			return func() { _ = os.Remove(lock) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if info, statErr := os.Stat(lock); statErr == nil && time.Since(info.ModTime()) > stateLockStale {
+			_ = os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("lock %s: held by another process (remove %s if none is running)", path, lock)
+		}
+		time.Sleep(stateLockPoll)
+	}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	// Concurrent read-modify-write cycles must not lose updates.
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := lockStateFile(path)
+			if err != nil {
+				t.Errorf("lock: %v", err)
+				return
+			}
+			defer unlock()
+			data, _ := os.ReadFile(path)
+			n, _ := strconv.Atoi(string(data))
+			if err := os.WriteFile(path, []byte(strconv.Itoa(n+1)), 0o600); err != nil {
+				t.Errorf("write: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if data, _ := os.ReadFile(path); string(data) != "20" {
+		t.Fatalf("expected 20 updates, got %q", data)
+	}
+
+	// A lock left by a crashed process is broken once stale.
+	lock := path + ".lock"
+	if err := os.WriteFile(lock, []byte("1"), 0o600); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+	old := time.Now().Add(-2 * stateLockStale)
+	if err := os.Chtimes(lock, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	unlock, err := lockStateFile(path)
+	if err != nil {
+		t.Fatalf("lock over stale file: %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(lock); !os.IsNotExist(err) {
+		t.Fatalf("expected lock file removed, got %v", err)
+	}
+}
@@ -6,13 +6,19 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
 
 	"github.com/alecthomas/kong"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
 
 	"github.com/steipete/gogcli/internal/googleauth"
+	"github.com/steipete/gogcli/internal/outfmt"
+	"github.com/steipete/gogcli/internal/ui"
 )
 
 // withPrimaryCalendar wraps an http.Handler to also respond to primary calendar requests
@@ -134,3 +140,78 @@ func runKong(t *testing.T, cmd any, args []string, ctx context.Context, flags *R
 
 	return kctx.Run()
 }
+
+// setupGmailTestServer points newGmailService at a test server running handler.
+func setupGmailTestServer(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+
+	origNew := newGmailService
+	t.Cleanup(func() { newGmailService = origNew })
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	svc, err := gmail.NewService(context.Background(),
+		option.WithoutAuthentication(),
+		option.WithHTTPClient(srv.Client()),
+		option.WithEndpoint(srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	newGmailService = func(context.Context, string) (*gmail.Service, error) { return svc, nil }
+}
+
+// setupDriveTestServer points newDriveService and the raw Drive HTTP clients
+// at a test server running handler and returns its URL.
+func setupDriveTestServer(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+
+	origNew := newDriveService
+	origClient := newDriveUploadClient
+	origHTTPClient := newDriveHTTPClient
+	t.Cleanup(func() {
+		newDriveService = origNew
+		newDriveUploadClient = origClient
+		newDriveHTTPClient = origHTTPClient
+	})
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	svc, err := drive.NewService(context.Background(),
+		option.WithoutAuthentication(),
+		option.WithHTTPClient(srv.Client()),
+		option.WithEndpoint(srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	newDriveService = func(context.Context, string) (*drive.Service, error) { return svc, nil }
+	newDriveUploadClient = func(context.Context, string) (*http.Client, error) { return srv.Client(), nil }
+	newDriveHTTPClient = func(context.Context, string) (*http.Client, error) { return srv.Client(), nil }
+	return srv.URL
+}
+
+// runDriveCmdJSON runs cmd with --json as a@b.com (with --force) and decodes
+// its output.
+func runDriveCmdJSON(t *testing.T, cmd any, args []string) map[string]any {
+	t.Helper()
+
+	u, err := ui.New(ui.Options{Stdout: io.Discard, Stderr: io.Discard, Color: "never"})
+	if err != nil {
+		t.Fatalf("ui.New: %v", err)
+	}
+	ctx := outfmt.WithMode(ui.WithUI(context.Background(), u), outfmt.Mode{JSON: true})
+
+	out := captureStdout(t, func() {
+		if execErr := runKong(t, cmd, args, ctx, &RootFlags{Account: "a@b.com", Force: true}); execErr != nil {
+			t.Fatalf("run %v: %v", args, execErr)
+		}
+	})
+	var parsed map[string]any
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("json: %v\n%s", err, out)
+	}
+	return parsed
+}
@@ -91,6 +91,77 @@ func EnsureDriveDownloadsDir() (string, error) {
 	return dir, nil
 }
 
+// DriveUploadsDir holds resumable upload sessions so interrupted uploads can
+// continue with `drive upload --resume`.
+func DriveUploadsDir() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "state", "drive-uploads"), nil
+}
+
+func EnsureDriveUploadsDir() (string, error) {
+	dir, err := DriveUploadsDir()
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("ensure drive uploads dir: %w", err)
+	}
+
+	return dir, nil
+}
+
+// DriveChangesDir holds the saved changes page token per account so
+// `drive changes watch` resumes where it stopped.
+func DriveChangesDir() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "state", "drive-changes"), nil
+}
+
+func EnsureDriveChangesDir() (string, error) {
+	dir, err := DriveChangesDir()
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("ensure drive changes dir: %w", err)
+	}
+
+	return dir, nil
+}
+
+// GmailQueueDir holds the scheduled-send queue per account (`gmail send --at`).
+func GmailQueueDir() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "state", "gmail-queue"), nil
+}
+
+func EnsureGmailQueueDir() (string, error) {
+	dir, err := GmailQueueDir()
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("ensure gmail queue dir: %w", err)
+	}
+
+	return dir, nil
+}
+
 func GmailAttachmentsDir() (string, error) {
 	dir, err := Dir()
 	if err != nil {
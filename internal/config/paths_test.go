@@ -43,6 +43,33 @@ func TestPaths_CreateDirs(t *testing.T) {
 		t.Fatalf("expected downloads dir: %v", statErr)
 	}
 
+	uploadsDir, err := EnsureDriveUploadsDir()
+	if err != nil {
+		t.Fatalf("EnsureDriveUploadsDir: %v", err)
+	}
+
+	if _, statErr := os.Stat(uploadsDir); statErr != nil {
+		t.Fatalf("expected uploads dir: %v", statErr)
+	}
+
+	changesDir, err := EnsureDriveChangesDir()
+	if err != nil {
+		t.Fatalf("EnsureDriveChangesDir: %v", err)
+	}
+
+	if _, statErr := os.Stat(changesDir); statErr != nil {
+		t.Fatalf("expected changes dir: %v", statErr)
+	}
+
+	queueDir, err := EnsureGmailQueueDir()
+	if err != nil {
+		t.Fatalf("EnsureGmailQueueDir: %v", err)
+	}
+
+	if _, statErr := os.Stat(queueDir); statErr != nil {
+		t.Fatalf("expected queue dir: %v", statErr)
+	}
+
 	attachmentsDir, err := EnsureGmailAttachmentsDir()
 	if err != nil {
 		t.Fatalf("EnsureGmailAttachmentsDir: %v", err)
@@ -3,6 +3,7 @@ package googleapi
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"google.golang.org/api/drive/v3"
 
@@ -18,3 +19,34 @@ func NewDrive(ctx context.Context, email string) (*drive.Service, error) {
 		return svc, nil
 	}
 }
+
+// NewDriveClient returns an authorized HTTP client for raw Drive requests
+// that the generated service can't make, such as revision export links.
+func NewDriveClient(ctx context.Context, email string) (*http.Client, error) {
+	scopes, err := googleauth.Scopes(googleauth.ServiceDrive)
+	if err != nil {
+		return nil, fmt.Errorf("resolve scopes: %w", err)
+	}
+
+	c, err := httpClientForAccountScopes(ctx, string(googleauth.ServiceDrive), email, scopes)
+	if err != nil {
+		return nil, fmt.Errorf("drive client: %w", err)
+	}
+
+	return c, nil
+}
+
+// NewDriveUploadClient returns an authorized HTTP client for raw Drive upload
+// requests (resumable sessions). It has no overall timeout because a single
+// chunk on a slow link can take longer than the default; callers bound
+// requests via their context.
+func NewDriveUploadClient(ctx context.Context, email string) (*http.Client, error) {
+	c, err := NewDriveClient(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Timeout = 0
+
+	return c, nil
+}
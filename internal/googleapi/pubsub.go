@@ -0,0 +1,43 @@
+package googleapi
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	"google.golang.org/api/pubsub/v1"
+)
+
+// NewPubSub returns a Pub/Sub client authorized with the given service
+// account file, or with application default credentials when path is empty.
+// Pub/Sub lives in a GCP project rather than a user's mailbox, so it does not
+// use the stored OAuth accounts.
+func NewPubSub(ctx context.Context, serviceAccountPath string) (*pubsub.Service, error) {
+	if serviceAccountPath == "" {
+		svc, err := pubsub.NewService(ctx, option.WithScopes(pubsub.PubsubScope))
+		if err != nil {
+			return nil, fmt.Errorf("create pubsub service: %w", err)
+		}
+
+		return svc, nil
+	}
+
+	data, err := os.ReadFile(serviceAccountPath) //nolint:gosec // user-provided path
+	if err != nil {
+		return nil, fmt.Errorf("read service account file: %w", err)
+	}
+
+	creds, err := google.CredentialsFromJSON(ctx, data, pubsub.PubsubScope)
+	if err != nil {
+		return nil, fmt.Errorf("parse service account: %w", err)
+	}
+
+	svc, err := pubsub.NewService(ctx, option.WithCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("create pubsub service: %w", err)
+	}
+
+	return svc, nil
+}
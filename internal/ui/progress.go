@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+)
+
+const (
+	progressBarWidth    = 30
+	progressRedrawEvery = 100 * time.Millisecond
+)
+
+// Progress renders a single-line byte progress bar that is redrawn in place.
+// It only draws when the printer writes to a terminal, so scripts and logs
+// never see carriage-return noise.
+type Progress struct {
+	w        io.Writer
+	label    string
+	total    int64
+	current  int64
+	enabled  bool
+	lastDraw time.Time
+	now      func() time.Time
+}
+
+// Progress starts a progress bar for total bytes labelled with label.
+func (p *Printer) Progress(label string, total int64) *Progress {
+	return &Progress{
+		w:       p.o,
+		label:   label,
+		total:   total,
+		enabled: isTerminal(p.o.TTY()),
+		now:     time.Now,
+	}
+}
+
+func isTerminal(f termenv.File) bool {
+	if f == nil {
+		return false
+	}
+
+	return term.IsTerminal(int(f.Fd())) //nolint:gosec // fd fits in int
+}
+
+// Set records the number of bytes transferred so far.
+func (b *Progress) Set(current int64) {
+	if b == nil {
+		return
+	}
+
+	b.current = current
+	if !b.enabled {
+		return
+	}
+
+	now := b.now()
+	if current < b.total && now.Sub(b.lastDraw) < progressRedrawEvery {
+		return
+	}
+
+	b.lastDraw = now
+	_, _ = io.WriteString(b.w, "\r"+b.render())
+}
+
+// Done finishes the bar and moves the cursor to the next line.
+func (b *Progress) Done() {
+	if b == nil || !b.enabled {
+		return
+	}
+
+	_, _ = io.WriteString(b.w, "\r"+b.render()+"\n")
+}
+
+func (b *Progress) render() string {
+	pct := 100.0
+	if b.total > 0 {
+		pct = float64(b.current) * 100 / float64(b.total)
+	}
+
+	if pct > 100 {
+		pct = 100
+	}
+
+	filled := int(pct / 100 * progressBarWidth)
+	bar := strings.Repeat("#", filled) + strings.Repeat(".", progressBarWidth-filled)
+
+	return fmt.Sprintf("%s [%s] %5.1f%% %s/%s", b.label, bar, pct, formatBytes(b.current), formatBytes(b.total))
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
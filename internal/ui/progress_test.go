@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muesli/termenv"
+)
+
+func TestProgress_DisabledWhenNotTerminal(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	p := newPrinter(termenv.NewOutput(&buf, termenv.WithProfile(termenv.Ascii)), termenv.Ascii)
+
+	bar := p.Progress("upload", 100)
+	bar.Set(50)
+	bar.Done()
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output for non-terminal, got %q", buf.String())
+	}
+}
+
+func TestProgress_RenderAndThrottle(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	now := time.Unix(0, 0)
+	bar := &Progress{w: &buf, label: "up", total: 2048, enabled: true, now: func() time.Time { return now }}
+
+	bar.Set(512)
+	bar.Set(600) // throttled: same instant
+
+	if got := strings.Count(buf.String(), "\r"); got != 1 {
+		t.Fatalf("expected one redraw, got %d: %q", got, buf.String())
+	}
+
+	now = now.Add(time.Second)
+	bar.Set(1024)
+	bar.Done()
+
+	out := buf.String()
+	if !strings.Contains(out, "up [###############...............]  50.0% 1.0 KiB/2.0 KiB") {
+		t.Fatalf("unexpected render: %q", out)
+	}
+
+	if !strings.HasSuffix(out, "\n") {
+		t.Fatalf("expected trailing newline after Done: %q", out)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+
+	cases := map[int64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 5 << 30: "5.0 GiB"}
+	for in, want := range cases {
+		if got := formatBytes(in); got != want {
+			t.Fatalf("formatBytes(%d) = %q, want %q", in, got, want)
+		}
+	}
+}